@@ -0,0 +1,38 @@
+// Command backfill-geohash adds the geohash field used by nearby search, and
+// the department used for scoping, to existing video-analysis documents.
+//
+//	go run ./cmd/backfill-geohash [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/jimil-28/crowd-monitor/config"
+	"github.com/jimil-28/crowd-monitor/internal/services/firebase"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the documents that would change without writing")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+
+	firebaseClient, err := firebase.NewFirebaseClient(
+		cfg.FirebaseCredPath,
+		cfg.FirebaseDatabaseURL,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize Firebase client: %v", err)
+	}
+	defer firebaseClient.Close()
+
+	result, err := firebaseClient.BackfillGeohashes(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatalf("Backfill failed after %d documents: %v", result.Scanned, err)
+	}
+
+	log.Printf("Scanned %d documents, %d updated, %d invalid (dry run: %v)",
+		result.Scanned, result.Updated, result.Invalid, *dryRun)
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,60 +17,313 @@ import (
 	"github.com/jimil-28/crowd-monitor/config"
 	"github.com/jimil-28/crowd-monitor/internal/api"
 	"github.com/jimil-28/crowd-monitor/internal/api/handlers"
+	"github.com/jimil-28/crowd-monitor/internal/api/middleware"
+	"github.com/jimil-28/crowd-monitor/internal/health"
+	"github.com/jimil-28/crowd-monitor/internal/logging"
+	"github.com/jimil-28/crowd-monitor/internal/metrics"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/openapi"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/alerts"
 	"github.com/jimil-28/crowd-monitor/internal/services/auth"
+	"github.com/jimil-28/crowd-monitor/internal/services/cameras"
+	"github.com/jimil-28/crowd-monitor/internal/services/devices"
+	"github.com/jimil-28/crowd-monitor/internal/services/events"
 	"github.com/jimil-28/crowd-monitor/internal/services/firebase"
+	"github.com/jimil-28/crowd-monitor/internal/services/incidents"
+	"github.com/jimil-28/crowd-monitor/internal/services/ingest"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+	"github.com/jimil-28/crowd-monitor/internal/services/officers"
+	"github.com/jimil-28/crowd-monitor/internal/services/roster"
+	"github.com/jimil-28/crowd-monitor/internal/services/sessions"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
 	"github.com/jimil-28/crowd-monitor/internal/services/twilio"
-	"github.com/jimil-28/crowd-monitor/internal/utils"
+	"github.com/jimil-28/crowd-monitor/internal/services/zones"
+	"github.com/jimil-28/crowd-monitor/internal/tracing"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
 
-	// Initialize logger
-	if err := utils.InitLogger(); err != nil {
-		log.Fatalf("Failed to initialize logger: %v", err)
+	// Initialize logging; everything below logs through slog
+	logFile, err := logging.Setup(logging.Options{
+		Level:     cfg.LogLevel,
+		Format:    cfg.LogFormat,
+		File:      cfg.LogFile,
+		MaxSize:   int64(cfg.LogFileMaxSizeMB) << 20,
+		MaxAge:    cfg.LogFileMaxAge,
+		Retention: cfg.LogFileRetention,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
+	}
+	defer logFile.Close()
+	gin.DebugPrintFunc = func(format string, values ...interface{}) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
 	}
 
-	log.Printf("Twilio Account SID: %s", cfg.TwilioAccountSid)
-	log.Printf("Twilio Auth Token: %s", cfg.TwilioAuthToken)
-	log.Printf("Twilio Service SID: %s", cfg.TwilioServiceSid)
-
-	// Initialize Twilio client
-	twilioClient, err := twilio.NewTwilioClient(
-		cfg.TwilioAccountSid,
-		cfg.TwilioAuthToken,
-		cfg.TwilioServiceSid,
-	)
+	// Initialize tracing; spans are only recorded when an exporter is set
+	tracingHeaders, err := tracing.ParseHeaders(cfg.TracingHeaders)
+	if err != nil {
+		fatal("Invalid OTEL_EXPORTER_OTLP_HEADERS", "error", err)
+	}
+	shutdownTracing, err := tracing.Setup(tracing.Options{
+		Exporters:     cfg.TracingExporters,
+		ServiceName:   cfg.TracingServiceName,
+		Endpoint:      cfg.TracingEndpoint,
+		Headers:       tracingHeaders,
+		File:          cfg.TracingFile,
+		FileMaxSize:   int64(cfg.LogFileMaxSizeMB) << 20,
+		FileMaxAge:    cfg.LogFileMaxAge,
+		FileRetention: cfg.LogFileRetention,
+		SampleRatio:   cfg.TracingSampleRatio,
+	})
 	if err != nil {
-		log.Fatalf("Failed to initialize Twilio client: %v", err)
+		fatal("Failed to initialize tracing", "error", err)
+	}
+	if len(cfg.TracingExporters) > 0 {
+		slog.Info("Tracing enabled", "exporters", cfg.TracingExporters, "sample_ratio", cfg.TracingSampleRatio)
 	}
 
-	// Initialize Firebase client
-	firebaseClient, err := firebase.NewFirebaseClient(
-		cfg.FirebaseCredPath,
-		cfg.FirebaseDatabaseURL,
+	// Initialize storage backend
+	var (
+		userRepo          repository.UserRepository
+		videoAnalysisRepo repository.VideoAnalysisRepository
+		cameraRepo        repository.CameraRepository
+		alertRuleRepo     repository.AlertRuleRepository
+		alertRepo         repository.AlertRepository
+		incidentRepo      repository.IncidentRepository
+		locationStore     repository.OfficerLocationStore
+		zoneRepo          repository.ZoneRepository
+		sessionRepo       repository.SessionRepository
+		otpStore          repository.OTPStore
+		analysisWatcher   repository.VideoAnalysisWatcher
+		watcherHealth     health.CheckFunc
 	)
+	// Dependencies are registered for /readyz as they are set up
+	readiness := health.NewChecker(cfg.ReadinessCacheTTL, cfg.ReadinessTimeout)
+	switch cfg.StorageBackend {
+	case "memory":
+		memoryClient := memory.NewMemoryClient()
+		if cfg.MemorySeedPath != "" {
+			if err := memoryClient.LoadSeedFile(cfg.MemorySeedPath); err != nil {
+				fatal("Failed to load memory seed data", "error", err)
+			}
+		}
+		slog.Info("Using in-memory storage backend")
+		userRepo, videoAnalysisRepo, cameraRepo = memoryClient, memoryClient, memoryClient
+		alertRuleRepo, alertRepo, incidentRepo = memoryClient, memoryClient, memoryClient
+		analysisWatcher, locationStore, zoneRepo = memoryClient, memoryClient, memoryClient
+		sessionRepo, otpStore = memoryClient, memoryClient
+		if cfg.LocationStore == "realtime" {
+			fatal("OFFICER_LOCATION_STORE=realtime requires the firestore storage backend")
+		}
+	case "firestore":
+		firebaseClient, err := firebase.NewFirebaseClient(
+			cfg.FirebaseCredPath,
+			cfg.FirebaseDatabaseURL,
+		)
+		if err != nil {
+			fatal("Failed to initialize Firebase client", "error", err)
+		}
+		defer firebaseClient.Close()
+		userRepo, videoAnalysisRepo, cameraRepo = firebaseClient, firebaseClient, firebaseClient
+		alertRuleRepo, alertRepo, incidentRepo = firebaseClient, firebaseClient, firebaseClient
+		analysisWatcher, zoneRepo, sessionRepo = firebaseClient, firebaseClient, firebaseClient
+		otpStore = firebaseClient
+		watcherHealth = firebaseClient.WatchHealth
+		readiness.Add("firestore", true, firebaseClient.Ping)
+
+		// Officer locations live in the Realtime Database when it is
+		// available and in process memory otherwise
+		switch {
+		case cfg.LocationStore == "memory":
+			locationStore = memory.NewMemoryClient()
+		case firebaseClient.HasRealtimeDatabase():
+			locationStore = firebaseClient
+			readiness.Add("realtime_database", false, firebaseClient.PingRealtimeDatabase)
+		case cfg.LocationStore == "realtime":
+			fatal("OFFICER_LOCATION_STORE=realtime but the Realtime Database is not available")
+		default:
+			slog.Warn("Realtime Database unavailable, officer locations are kept in memory only")
+			locationStore = memory.NewMemoryClient()
+		}
+	default:
+		fatal("Unknown storage backend", "backend", cfg.StorageBackend)
+	}
+
+	// Initialize token signing; without configured keys tokens are signed
+	// with a throwaway key and do not survive a restart
+	signingKeys, err := tokens.LoadKeySet(cfg.JWTKeysFile, cfg.JWTSecret)
 	if err != nil {
-		log.Fatalf("Failed to initialize Firebase client: %v", err)
+		fatal("Failed to load JWT signing keys", "error", err)
+	}
+	if signingKeys.Active() == nil {
+		slog.Warn("JWT_SECRET and JWT_KEYS_FILE are empty, signing tokens with an ephemeral key")
+		if err := signingKeys.GenerateEphemeral(); err != nil {
+			fatal("Failed to generate JWT signing key", "error", err)
+		}
+	}
+	tokenService, err := tokens.NewTokenService(signingKeys, cfg.JWTIssuer, cfg.JWTTTL)
+	if err != nil {
+		fatal("Failed to initialize token service", "error", err)
+	}
+
+	sessionService := sessions.NewSessionService(tokenService, sessionRepo, userRepo, cfg.JWTRefreshTTL)
+
+	// Plain SMS for alerts and locally generated login codes
+	var smsSender *twilio.SMSSender
+	if cfg.TwilioSMSFrom != "" {
+		smsSender, err = twilio.NewSMSSender(cfg.TwilioAccountSid, cfg.TwilioAuthToken, cfg.TwilioSMSFrom)
+		if err != nil {
+			fatal("Failed to initialize Twilio SMS sender", "error", err)
+		}
+	}
+
+	// Initialize the OTP provider; only the twilio provider needs Twilio
+	// Verify credentials
+	var otpProvider auth.OTPProvider
+	switch cfg.OTPProvider {
+	case auth.ProviderTwilio:
+		twilioClient, err := twilio.NewTwilioClient(
+			cfg.TwilioAccountSid,
+			cfg.TwilioAuthToken,
+			cfg.TwilioServiceSid,
+		)
+		if err != nil {
+			fatal("Failed to initialize Twilio client, set OTP_PROVIDER=console to run without Twilio", "error", err)
+		}
+		otpProvider = twilioClient
+		readiness.Add("otp_provider", false, twilioClient.Ping)
+	case auth.ProviderLocal:
+		if smsSender == nil {
+			fatal("OTP_PROVIDER=local requires TWILIO_SMS_FROM to send codes")
+		}
+		readiness.Add("otp_provider", false, smsSender.Ping)
+		otpProvider = auth.NewLocalProvider(otpStore, smsSender, cfg.OTPTTL)
+	case auth.ProviderConsole:
+		slog.Warn("OTP_PROVIDER=console, login codes are logged instead of sent")
+		otpProvider = auth.NewLocalProvider(otpStore, auth.ConsoleSender{}, cfg.OTPTTL)
+	default:
+		fatal("Unknown OTP provider", "provider", cfg.OTPProvider)
 	}
-	defer firebaseClient.Close()
 
 	// Initialize authentication service
-	authService := auth.NewAuthService(twilioClient, firebaseClient)
+	authService := auth.NewAuthService(otpProvider, userRepo, sessionService, auth.Limits{
+		SendPerNumber:  cfg.OTPSendPerNumber,
+		SendPerIP:      cfg.OTPSendPerIP,
+		SendWindow:     cfg.OTPSendWindow,
+		MaxAttempts:    cfg.OTPMaxAttempts,
+		Lockout:        cfg.OTPLockout,
+		RegisteredOnly: cfg.OTPRegisteredOnly,
+	})
+
+	// Initialize ingestion for edge analyzers
+	deviceCredentials, err := devices.ParseCredentials(cfg.DeviceAPIKeys)
+	if err != nil {
+		fatal("Failed to parse device credentials", "error", err)
+	}
+	if len(deviceCredentials) == 0 {
+		slog.Warn("DEVICE_API_KEYS is empty, video analysis ingestion will reject every device")
+	}
+	zoneService := zones.NewZoneService(zoneRepo, videoAnalysisRepo, cfg.ZoneStatusWindow)
+	ingestService := ingest.NewIngestService(videoAnalysisRepo, cameraRepo, zoneService)
+
+	// Start background workers; they stop when the server shuts down
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	staleChecker := cameras.NewStaleChecker(cameraRepo, cfg.CameraStaleAfter, cfg.CameraCheckInterval)
+	go staleChecker.Run(backgroundCtx)
+	// Stale camera checks mostly fail when Firestore does, which the
+	// firestore check already covers
+	readiness.Add("camera_stale_checker", false, staleChecker.Health)
+
+	// Feed every stored analysis change into the event bus for live streams
+	eventBus := events.NewBus(cfg.StreamHistorySize, 256)
+	watcherDone := make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		analysisWatcher.WatchVideoAnalyses(backgroundCtx, func(change repository.VideoAnalysisChange) {
+			eventType := events.TypeAnalysisUpdated
+			if change.Created {
+				eventType = events.TypeAnalysisCreated
+			}
+			eventBus.Publish(eventType, change.Analysis)
+		})
+	}()
+	watcherRunning := health.Running(watcherDone)
+	readiness.Add("analysis_watcher", true, func(ctx context.Context) error {
+		if err := watcherRunning(ctx); err != nil || watcherHealth == nil {
+			return err
+		}
+		return watcherHealth(ctx)
+	})
+
+	// Evaluate alert rules against new analyses and text matching officers
+	var alertNotifier alerts.Notifier = alerts.LogNotifier{}
+	if smsSender != nil {
+		alertNotifier = smsSender
+	} else {
+		slog.Warn("TWILIO_SMS_FROM is empty, alert SMS will only be logged")
+	}
+	officerTracker := officers.NewTracker(locationStore, userRepo, cfg.LocationMaxAge)
+	alertEngine := alerts.NewEngine(eventBus, alertRuleRepo, alertRepo,
+		officerTracker, alertNotifier, cfg.AlertMaxRecipients)
+	incidentService := incidents.NewIncidentService(incidentRepo, videoAnalysisRepo, userRepo, officerTracker)
+	alertEngine.RecordIncidents(incidentService)
+	engineDone := make(chan struct{})
+	go func() {
+		defer close(engineDone)
+		alertEngine.Run(backgroundCtx)
+	}()
+	readiness.Add("alert_engine", true, health.Running(engineDone))
+
+	// Open alerts are counted on every scrape with a count aggregation,
+	// which costs one read however many alerts are open
+	metrics.NewGaugeFunc("crowd_monitor_alerts_open",
+		"Alerts not yet acknowledged or resolved.",
+		func() float64 {
+			ctx, cancel := context.WithTimeout(backgroundCtx, 5*time.Second)
+			defer cancel()
+			open, err := alertRepo.CountAlerts(ctx, models.AlertStatusOpen)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to count open alerts", "error", err)
+				return math.NaN()
+			}
+			return float64(open)
+		})
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	videoAnalysisHandler := handlers.NewVideoAnalysisHandler(firebaseClient)
-	userHandler := handlers.NewUserHandler(firebaseClient) // Add this line
+	authHandler := handlers.NewAuthHandler(authService, tokenService, sessionService, userRepo)
+	videoAnalysisHandler := handlers.NewVideoAnalysisHandler(videoAnalysisRepo, cfg.MaxQueryRadiusKm)
+	userHandler := handlers.NewUserHandler(userRepo, sessionService, roster.NewRosterService(userRepo))
+	ingestHandler := handlers.NewIngestHandler(ingestService)
+	cameraHandler := handlers.NewCameraHandler(cameraRepo)
+	streamHandler := handlers.NewStreamHandler(eventBus, cfg.StreamHeartbeat)
+	alertHandler := handlers.NewAlertHandler(alertRuleRepo, alertRepo)
+	incidentHandler := handlers.NewIncidentHandler(incidentRepo, incidentService)
+	officerHandler := handlers.NewOfficerHandler(officerTracker)
+	zoneHandler := handlers.NewZoneHandler(zoneRepo, zoneService)
+	healthHandler := handlers.NewHealthHandler(readiness)
 
 	// Setup Gin router
-	router := gin.Default()
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Tracing(), middleware.AccessLog(), middleware.Metrics(), middleware.Recovery())
+	// Handlers pass the gin context to the repositories; falling back to
+	// the request context lets them see the department scope that
+	// AuthMiddleware attaches to it.
+	router.ContextWithFallback = true
+	// OTP send limits count per client IP, which only listed proxies may
+	// override through X-Forwarded-For
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal("Invalid TRUSTED_PROXIES", "error", err)
+	}
 
 	// Add CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
@@ -78,8 +334,26 @@ func main() {
 		c.Next()
 	})
 
+	// Requests that do not match the API document are rejected before
+	// they reach a handler; test mode checks the responses too
+	apiDoc, err := openapi.Load()
+	if err != nil {
+		fatal("Invalid OpenAPI document", "error", err)
+	}
+	var validateAPI gin.HandlerFunc
+	switch cfg.OpenAPIValidation {
+	case openapi.ValidationOff:
+	case openapi.ValidationRequests, openapi.ValidationTest:
+		validateAPI = middleware.ValidateOpenAPI(apiDoc, cfg.OpenAPIValidation == openapi.ValidationTest)
+	default:
+		fatal("Invalid OPENAPI_VALIDATION, expected off, requests or test", "value", cfg.OpenAPIValidation)
+	}
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", openapi.Spec())
+	})
+
 	// Setup routes
-	api.SetupRoutes(router, authHandler, videoAnalysisHandler, userHandler)
+	api.SetupRoutes(router, authHandler, videoAnalysisHandler, userHandler, ingestHandler, cameraHandler, streamHandler, alertHandler, incidentHandler, officerHandler, zoneHandler, deviceCredentials, sessionService, cfg.StreamWebSocket, validateAPI)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -88,6 +362,32 @@ func main() {
 			"time":   time.Now().Format(time.RFC3339),
 		})
 	})
+	// Probes for orchestrators: /livez restarts a stuck process, /readyz
+	// takes an instance out of rotation while a dependency is failing
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+
+	// Metrics are served on their own listener when METRICS_ADDR is set,
+	// so they can stay off the public port, and otherwise on the API
+	// behind METRICS_TOKEN
+	metricsHandler := metrics.Handler(metrics.Default, cfg.MetricsToken)
+	var metricsSrv *http.Server
+	switch {
+	case cfg.MetricsAddr != "":
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metricsHandler)
+		metricsSrv = &http.Server{Addr: cfg.MetricsAddr, Handler: mux}
+		go func() {
+			slog.Info("Metrics server is running", "addr", cfg.MetricsAddr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Metrics server failed", "error", err)
+			}
+		}()
+	case cfg.MetricsToken != "":
+		router.GET("/metrics", gin.WrapH(metricsHandler))
+	default:
+		slog.Warn("METRICS_ADDR and METRICS_TOKEN are empty, /metrics is disabled")
+	}
 
 	// Start server
 	srv := &http.Server{
@@ -96,9 +396,9 @@ func main() {
 	}
 
 	go func() {
-		fmt.Printf("Server is running on port %s...\n", cfg.Port)
+		slog.Info("Server is running", "port", cfg.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+			fatal("Server failed", "error", err)
 		}
 	}()
 
@@ -106,14 +406,29 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
+	stopBackground()
+	eventBus.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", "error", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(ctx)
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server exited")
+}
 
-	log.Println("Server exited")
+// fatal logs msg at error level and exits. Deferred calls do not run, as
+// with log.Fatal.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
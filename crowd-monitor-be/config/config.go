@@ -3,17 +3,65 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port              string
-	TwilioAccountSid  string
-	TwilioAuthToken   string
-	TwilioServiceSid  string
-	FirebaseCredPath  string
+	Port                string
+	TwilioAccountSid    string
+	TwilioAuthToken     string
+	TwilioServiceSid    string
+	TwilioSMSFrom       string // sender number or messaging service SID for alert and OTP SMS
+	OTPProvider         string // "twilio", "local" or "console", see auth.Provider*
+	OTPTTL              time.Duration
+	OTPSendPerNumber    int // codes sent to one number per OTPSendWindow, 0 for no limit
+	OTPSendPerIP        int // codes requested from one client IP per OTPSendWindow, 0 for no limit
+	OTPSendWindow       time.Duration
+	OTPMaxAttempts      int // wrong codes before a number is locked out, 0 for no limit
+	OTPLockout          time.Duration
+	OTPRegisteredOnly   bool     // send codes only to registered users
+	TrustedProxies      []string // proxies whose X-Forwarded-For gives the client IP
+	LogLevel            string   // debug, info, warn or error
+	LogFormat           string   // "json" or "text"
+	LogFile             string   // rotated copy of the log, empty for stdout only
+	LogFileMaxSizeMB    int
+	LogFileMaxAge       time.Duration // rotate the log file after this long
+	LogFileRetention    time.Duration // delete rotated log files after this long
+	MetricsAddr         string        // separate listen address for /metrics, e.g. ":9090"
+	MetricsToken        string        // bearer token required by /metrics
+	TracingExporters    []string      // "otlp", "stdout" and/or "file", none disables tracing
+	TracingServiceName  string
+	TracingEndpoint     string // OTLP/HTTP traces URL
+	TracingHeaders      string // "name=value,..." sent with every OTLP export
+	TracingFile         string
+	TracingSampleRatio  float64       // share of new traces recorded, 0 to 1
+	ReadinessCacheTTL   time.Duration // how long /readyz reuses a dependency check
+	ReadinessTimeout    time.Duration // per dependency check
+	OpenAPIValidation   string        // "off", "requests" or "test", which also checks responses
+	FirebaseCredPath    string
 	FirebaseDatabaseURL string
+	StorageBackend      string // "firestore" or "memory"
+	MemorySeedPath      string
+	MaxQueryRadiusKm    float64
+	DeviceAPIKeys       string // "id:sha256hex,..." digests of edge analyzer API keys
+	CameraStaleAfter    time.Duration
+	CameraCheckInterval time.Duration
+	StreamHeartbeat     time.Duration
+	StreamHistorySize   int
+	StreamWebSocket     bool
+	AlertMaxRecipients  int
+	LocationStore       string // "realtime", "memory", or empty to pick automatically
+	LocationMaxAge      time.Duration
+	ZoneStatusWindow    time.Duration
+	JWTSecret           string // HS256 secret, the "default" signing key
+	JWTKeysFile         string // JSON key set with kid-selected keys, see tokens.LoadKeySet
+	JWTIssuer           string
+	JWTTTL              time.Duration // access token lifetime
+	JWTRefreshTTL       time.Duration // session lifetime after its last refresh
 }
 
 func LoadConfig() *Config {
@@ -23,12 +71,57 @@ func LoadConfig() *Config {
 	}
 
 	config := &Config{
-		Port:              getEnv("PORT", "8080"),
-		TwilioAccountSid:  getEnv("TWILIO_ACCOUNT_SID", ""),
-		TwilioAuthToken:   getEnv("TWILIO_AUTH_TOKEN", ""),
-		TwilioServiceSid:  getEnv("TWILIO_SERVICE_SID", ""),
-		FirebaseCredPath:  getEnv("FIREBASE_CRED_PATH", ""),
+		Port:                getEnv("PORT", "8080"),
+		TwilioAccountSid:    getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:     getEnv("TWILIO_AUTH_TOKEN", ""),
+		TwilioServiceSid:    getEnv("TWILIO_SERVICE_SID", ""),
+		TwilioSMSFrom:       getEnv("TWILIO_SMS_FROM", ""),
+		OTPProvider:         getEnv("OTP_PROVIDER", "twilio"),
+		OTPTTL:              getEnvDuration("OTP_TTL", 5*time.Minute),
+		OTPSendPerNumber:    getEnvInt("OTP_SEND_LIMIT_PER_NUMBER", 3),
+		OTPSendPerIP:        getEnvInt("OTP_SEND_LIMIT_PER_IP", 10),
+		OTPSendWindow:       getEnvDuration("OTP_SEND_WINDOW", 15*time.Minute),
+		OTPMaxAttempts:      getEnvInt("OTP_MAX_ATTEMPTS", 5),
+		OTPLockout:          getEnvDuration("OTP_LOCKOUT", 15*time.Minute),
+		OTPRegisteredOnly:   getEnvBool("OTP_REGISTERED_ONLY", true),
+		TrustedProxies:      getEnvList("TRUSTED_PROXIES"),
+		LogLevel:            getEnv("LOG_LEVEL", "info"),
+		LogFormat:           getEnv("LOG_FORMAT", "json"),
+		LogFile:             getEnv("LOG_FILE", "logs/api.log"),
+		LogFileMaxSizeMB:    getEnvInt("LOG_FILE_MAX_SIZE_MB", 100),
+		LogFileMaxAge:       getEnvDuration("LOG_FILE_MAX_AGE", 24*time.Hour),
+		LogFileRetention:    getEnvDuration("LOG_FILE_RETENTION", 14*24*time.Hour),
+		MetricsAddr:         getEnv("METRICS_ADDR", ""),
+		MetricsToken:        getEnv("METRICS_TOKEN", ""),
+		TracingExporters:    getEnvList("TRACING_EXPORTERS"),
+		TracingServiceName:  getEnv("OTEL_SERVICE_NAME", "crowd-monitor"),
+		TracingEndpoint:     getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", strings.TrimSuffix(getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"), "/")+"/v1/traces"),
+		TracingHeaders:      getEnv("OTEL_EXPORTER_OTLP_HEADERS", ""),
+		TracingFile:         getEnv("TRACING_FILE", "logs/traces.jsonl"),
+		TracingSampleRatio:  getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		ReadinessCacheTTL:   getEnvDuration("READINESS_CACHE_TTL", 10*time.Second),
+		ReadinessTimeout:    getEnvDuration("READINESS_CHECK_TIMEOUT", 3*time.Second),
+		OpenAPIValidation:   getEnv("OPENAPI_VALIDATION", "requests"),
+		FirebaseCredPath:    getEnv("FIREBASE_CRED_PATH", ""),
 		FirebaseDatabaseURL: getEnv("FIREBASE_DATABASE_URL", ""),
+		StorageBackend:      getEnv("STORAGE_BACKEND", "firestore"),
+		MemorySeedPath:      getEnv("MEMORY_SEED_PATH", ""),
+		MaxQueryRadiusKm:    getEnvFloat("MAX_QUERY_RADIUS_KM", 50),
+		DeviceAPIKeys:       getEnv("DEVICE_API_KEYS", ""),
+		CameraStaleAfter:    getEnvDuration("CAMERA_STALE_AFTER", 15*time.Minute),
+		CameraCheckInterval: getEnvDuration("CAMERA_CHECK_INTERVAL", time.Minute),
+		StreamHeartbeat:     getEnvDuration("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+		StreamHistorySize:   getEnvInt("STREAM_HISTORY_SIZE", 1000),
+		StreamWebSocket:     getEnvBool("STREAM_WEBSOCKET_ENABLED", true),
+		AlertMaxRecipients:  getEnvInt("ALERT_MAX_RECIPIENTS", 10),
+		LocationStore:       getEnv("OFFICER_LOCATION_STORE", ""),
+		LocationMaxAge:      getEnvDuration("OFFICER_LOCATION_MAX_AGE", 10*time.Minute),
+		ZoneStatusWindow:    getEnvDuration("ZONE_STATUS_WINDOW", 15*time.Minute),
+		JWTSecret:           getEnv("JWT_SECRET", ""),
+		JWTKeysFile:         getEnv("JWT_KEYS_FILE", ""),
+		JWTIssuer:           getEnv("JWT_ISSUER", "crowd-monitor"),
+		JWTTTL:              getEnvDuration("JWT_TTL", 15*time.Minute),
+		JWTRefreshTTL:       getEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
 	}
 
 	return config
@@ -40,4 +133,67 @@ func getEnv(key, fallback string) string {
 		return fallback
 	}
 	return value
-}
\ No newline at end of file
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using %g", key, value, fallback)
+		return fallback
+	}
+	return f
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s=%q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using %t", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+// getEnvList splits a comma separated value, dropping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,112 @@
+// Package access maps officer ranks and roles to the capabilities the API
+// enforces.
+package access
+
+import "sort"
+
+type Permission string
+
+const (
+	ViewUsers          Permission = "users:view"
+	ManageUsers        Permission = "users:manage"
+	ViewAllDepartments Permission = "departments:view_all"
+	ManageCameras      Permission = "cameras:manage"
+	ManageZones        Permission = "zones:manage"
+	ManageAlertRules   Permission = "alert_rules:manage"
+	HandleAlerts       Permission = "alerts:handle"
+	ReportIncidents    Permission = "incidents:report"
+	ManageIncidents    Permission = "incidents:manage"
+	CloseIncidents     Permission = "incidents:close"
+)
+
+// Ranks from junior to senior. Each rank holds the permissions of the ranks
+// below it.
+const (
+	RankASI  = "ASI"
+	RankSI   = "SI"
+	RankPI   = "PI"
+	RankDYSP = "DYSP"
+)
+
+// Roles grant permissions on top of the rank.
+const (
+	RoleAdmin       = "admin"
+	RoleControlRoom = "control_room"
+)
+
+var ranks = []struct {
+	rank        string
+	permissions []Permission
+}{
+	{RankASI, []Permission{ReportIncidents}},
+	{RankSI, []Permission{ViewUsers, HandleAlerts, ManageIncidents}},
+	{RankPI, []Permission{ManageCameras, ManageZones, ManageAlertRules, CloseIncidents}},
+	{RankDYSP, []Permission{ManageUsers, ViewAllDepartments}},
+}
+
+var roles = map[string][]Permission{
+	RoleAdmin:       allPermissions,
+	RoleControlRoom: {ViewUsers, ViewAllDepartments, HandleAlerts, ReportIncidents, ManageIncidents},
+}
+
+var allPermissions = []Permission{
+	ViewUsers, ManageUsers, ViewAllDepartments, ManageCameras, ManageZones,
+	ManageAlertRules, HandleAlerts, ReportIncidents, ManageIncidents, CloseIncidents,
+}
+
+// RankLevel orders ranks from 1 (ASI) upwards; unknown ranks are 0.
+func RankLevel(rank string) int {
+	for i, r := range ranks {
+		if r.rank == rank {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func IsRank(rank string) bool {
+	return RankLevel(rank) > 0
+}
+
+func IsRole(role string) bool {
+	_, ok := roles[role]
+	return ok
+}
+
+// Principal is the authenticated officer as described by their token.
+type Principal struct {
+	PhoneNumber string `json:"phone_number"`
+	Rank        string `json:"rank"`
+	Department  string `json:"department"`
+	Role        string `json:"role,omitempty"`
+}
+
+// Can reports whether the principal's rank or role grants p.
+func (p Principal) Can(perm Permission) bool {
+	level := RankLevel(p.Rank)
+	for i := 0; i < level; i++ {
+		for _, granted := range ranks[i].permissions {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	for _, granted := range roles[p.Role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions lists everything the principal may do, sorted.
+func (p Principal) Permissions() []Permission {
+	granted := []Permission{}
+	for _, perm := range allPermissions {
+		if p.Can(perm) {
+			granted = append(granted, perm)
+		}
+	}
+	sort.Slice(granted, func(i, j int) bool { return granted[i] < granted[j] })
+	return granted
+}
@@ -0,0 +1,70 @@
+package access
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		perm      Permission
+		want      bool
+	}{
+		{"ASI reports incidents", Principal{Rank: RankASI}, ReportIncidents, true},
+		{"ASI cannot handle alerts", Principal{Rank: RankASI}, HandleAlerts, false},
+		{"SI inherits from ASI", Principal{Rank: RankSI}, ReportIncidents, true},
+		{"SI cannot close incidents", Principal{Rank: RankSI}, CloseIncidents, false},
+		{"PI manages zones", Principal{Rank: RankPI}, ManageZones, true},
+		{"PI cannot manage users", Principal{Rank: RankPI}, ManageUsers, false},
+		{"DYSP holds everything", Principal{Rank: RankDYSP}, CloseIncidents, true},
+		{"DYSP sees all departments", Principal{Rank: RankDYSP}, ViewAllDepartments, true},
+		{"unknown rank", Principal{Rank: "IG"}, ReportIncidents, false},
+		{"control room adds to the rank", Principal{Rank: RankASI, Role: RoleControlRoom}, ViewAllDepartments, true},
+		{"control room cannot manage users", Principal{Rank: RankASI, Role: RoleControlRoom}, ManageUsers, false},
+		{"admin without a rank", Principal{Role: RoleAdmin}, ManageUsers, true},
+		{"unknown role", Principal{Rank: RankASI, Role: "auditor"}, ViewUsers, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Can(tt.perm); got != tt.want {
+				t.Errorf("Can(%s) = %v, want %v", tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	tests := []struct {
+		principal Principal
+		want      string
+	}{
+		{Principal{}, "[]"},
+		{Principal{Rank: RankASI}, "[incidents:report]"},
+		{Principal{Rank: RankSI}, "[alerts:handle incidents:manage incidents:report users:view]"},
+		{Principal{Rank: RankASI, Role: RoleControlRoom}, "[alerts:handle departments:view_all incidents:manage incidents:report users:view]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(tt.principal.Permissions()); got != tt.want {
+			t.Errorf("%+v: Permissions = %s, want %s", tt.principal, got, tt.want)
+		}
+	}
+	if got, want := len(Principal{Rank: RankDYSP}.Permissions()), len(allPermissions); got != want {
+		t.Errorf("DYSP holds %d permissions, want all %d", got, want)
+	}
+}
+
+func TestRankLevel(t *testing.T) {
+	for i, rank := range []string{RankASI, RankSI, RankPI, RankDYSP} {
+		if got := RankLevel(rank); got != i+1 || !IsRank(rank) {
+			t.Errorf("RankLevel(%s) = %d, want %d", rank, got, i+1)
+		}
+	}
+	if RankLevel("asi") != 0 || IsRank("") {
+		t.Error("ranks are matched case-sensitively and must not be empty")
+	}
+	if !IsRole(RoleAdmin) || IsRole("") {
+		t.Error("IsRole accepts only known roles")
+	}
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/alerts"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
+)
+
+// Page size bounds for the alert and incident lists.
+const (
+	defaultAlertLimit = 50
+	maxAlertLimit     = 200
+)
+
+type AlertHandler struct {
+	rules  repository.AlertRuleRepository
+	alerts repository.AlertRepository
+}
+
+func NewAlertHandler(rules repository.AlertRuleRepository, alerts repository.AlertRepository) *AlertHandler {
+	return &AlertHandler{
+		rules:  rules,
+		alerts: alerts,
+	}
+}
+
+type alertRuleRequest struct {
+	Name                      string            `json:"name" binding:"required"`
+	Enabled                   *bool             `json:"enabled"`
+	CrowdLevels               []string          `json:"crowd_levels"`
+	MinCrowdCount             float64           `json:"min_crowd_count"`
+	RequirePeakHour           bool              `json:"require_peak_hour"`
+	RequirePoliceIntervention bool              `json:"require_police_intervention"`
+	Area                      *models.AlertArea `json:"area"`
+	ZoneIDs                   []string          `json:"zone_ids"`
+	Department                string            `json:"department"`
+	NotifyRadiusKm            float64           `json:"notify_radius_km"`
+	CooldownSeconds           int               `json:"cooldown_seconds"`
+}
+
+func (r *alertRuleRequest) validate() error {
+	if r.MinCrowdCount < 0 || r.NotifyRadiusKm < 0 || r.CooldownSeconds < 0 {
+		return fmt.Errorf("min_crowd_count, notify_radius_km and cooldown_seconds must not be negative")
+	}
+	if a := r.Area; a != nil {
+		if a.Latitude < -90 || a.Latitude > 90 || a.Longitude < -180 || a.Longitude > 180 || a.RadiusKm <= 0 {
+			return fmt.Errorf("area needs a valid latitude and longitude and a positive radius_km")
+		}
+	}
+	if len(r.CrowdLevels) == 0 && r.MinCrowdCount == 0 && !r.RequirePeakHour && !r.RequirePoliceIntervention {
+		return fmt.Errorf("rule needs at least one of crowd_levels, min_crowd_count, require_peak_hour or require_police_intervention")
+	}
+	return nil
+}
+
+// apply copies the request onto rule. Enabled defaults to true.
+func (r *alertRuleRequest) apply(rule *models.AlertRule) {
+	rule.Name = r.Name
+	rule.Enabled = r.Enabled == nil || *r.Enabled
+	rule.CrowdLevels = r.CrowdLevels
+	rule.MinCrowdCount = r.MinCrowdCount
+	rule.RequirePeakHour = r.RequirePeakHour
+	rule.RequirePoliceIntervention = r.RequirePoliceIntervention
+	rule.Area = r.Area
+	rule.ZoneIDs = r.ZoneIDs
+	rule.Department = r.Department
+	rule.NotifyRadiusKm = r.NotifyRadiusKm
+	rule.CooldownSeconds = r.CooldownSeconds
+	if rule.CooldownSeconds == 0 {
+		rule.CooldownSeconds = int(alerts.DefaultCooldown.Seconds())
+	}
+}
+
+func (h *AlertHandler) GetAllAlertRules(c *gin.Context) {
+	rules, err := h.rules.ListAlertRules(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Alert rules retrieved successfully", rules)
+}
+
+func (h *AlertHandler) GetAlertRule(c *gin.Context) {
+	rule, err := h.rules.GetAlertRule(c, c.Param("ruleId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Alert rule not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Alert rule retrieved successfully", rule)
+}
+
+func (h *AlertHandler) AddAlertRule(c *gin.Context) {
+	var req alertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: name is required")
+		return
+	}
+	if err := req.validate(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	rule := models.AlertRule{
+		ID:        uuid.NewString(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	req.apply(&rule)
+	if err := h.rules.CreateAlertRule(c, rule); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Alert rule added successfully", rule)
+}
+
+func (h *AlertHandler) UpdateAlertRule(c *gin.Context) {
+	var req alertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: name is required")
+		return
+	}
+	if err := req.validate(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.rules.GetAlertRule(c, c.Param("ruleId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Alert rule not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	req.apply(rule)
+	rule.UpdatedAt = time.Now().UTC()
+	if err := h.rules.UpdateAlertRule(c, *rule); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Alert rule updated successfully", rule)
+}
+
+func (h *AlertHandler) DeleteAlertRule(c *gin.Context) {
+	err := h.rules.DeleteAlertRule(c, c.Param("ruleId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Alert rule not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Alert rule deleted successfully", nil)
+}
+
+// GetAllAlerts lists alerts newest first, optionally filtered by ?status=
+// and capped by ?limit= (default 50, max 200).
+func (h *AlertHandler) GetAllAlerts(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.AlertStatusOpen, models.AlertStatusAcknowledged, models.AlertStatusResolved:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("status must be one of %s, %s, %s",
+			models.AlertStatusOpen, models.AlertStatusAcknowledged, models.AlertStatusResolved))
+		return
+	}
+
+	limit := defaultAlertLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxAlertLimit)
+	}
+
+	list, err := h.alerts.ListAlerts(c, status, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Alerts retrieved successfully", list)
+}
+
+func (h *AlertHandler) GetAlert(c *gin.Context) {
+	alert, err := h.alerts.GetAlert(c, c.Param("alertId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Alert not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Alert retrieved successfully", alert)
+}
+
+// AcknowledgeAlert stops further notifications for an open alert while
+// later matches keep being recorded on it.
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	h.setAlertStatus(c, models.AlertStatusAcknowledged, "Alert acknowledged")
+}
+
+// ResolveAlert closes the alert; the next match at the same hotspot opens a
+// new one.
+func (h *AlertHandler) ResolveAlert(c *gin.Context) {
+	h.setAlertStatus(c, models.AlertStatusResolved, "Alert resolved")
+}
+
+func (h *AlertHandler) setAlertStatus(c *gin.Context, status, message string) {
+	alert, err := h.alerts.GetAlert(c, c.Param("alertId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Alert not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if alert.Status == models.AlertStatusResolved ||
+		(alert.Status == models.AlertStatusAcknowledged && status == models.AlertStatusAcknowledged) {
+		utils.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("Alert is already %s", alert.Status))
+		return
+	}
+
+	alert.Status = status
+	alert.UpdatedBy = c.GetString("phone_number")
+	alert.UpdatedAt = time.Now().UTC()
+	if err := h.alerts.UpdateAlert(c, *alert); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, alert)
+}
@@ -1,20 +1,34 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/api/middleware"
 	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
 	"github.com/jimil-28/crowd-monitor/internal/services/auth"
+	"github.com/jimil-28/crowd-monitor/internal/services/sessions"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
 )
 
 type AuthHandler struct {
-	authService *auth.Service
+	authService    *auth.Service
+	tokenService   *tokens.Service
+	sessionService *sessions.Service
+	userRepo       repository.UserRepository
 }
 
-func NewAuthHandler(authService *auth.Service) *AuthHandler {
+func NewAuthHandler(authService *auth.Service, tokenService *tokens.Service, sessionService *sessions.Service, userRepo repository.UserRepository) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:    authService,
+		tokenService:   tokenService,
+		sessionService: sessionService,
+		userRepo:       userRepo,
 	}
 }
 
@@ -25,7 +39,16 @@ func (h *AuthHandler) SendOTP(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.SendOTP(req.PhoneNumber)
+	if !models.IsE164(req.PhoneNumber) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "phone_number must be in E.164 format, e.g. +919876543210"})
+		return
+	}
+
+	err := h.authService.SendOTP(c, req.PhoneNumber, c.ClientIP())
+	if retryAfter(c, err) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,10 +65,111 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 	}
 
 	resp, err := h.authService.VerifyOTP(c, req.PhoneNumber, req.OTPCode)
+	if retryAfter(c, err) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, auth.ErrDeactivated) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
-}
\ No newline at end of file
+}
+
+// retryAfter sets the Retry-After header and reports true when err is an
+// OTP rate limit or lockout.
+func retryAfter(c *gin.Context, err error) bool {
+	var retry *auth.RetryError
+	if !errors.As(err, &retry) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry.RetryAfter.Seconds()))))
+	return true
+}
+
+// Refresh trades a refresh token for a new access and refresh token. The
+// old refresh token stops working.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.authService.Refresh(c, req.RefreshToken)
+	if errors.Is(err, sessions.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout ends the session of the presented access token.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	err := h.sessionService.Logout(c, c.GetString("session_id"))
+	if errors.Is(err, sessions.ErrNoSession) {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Logged out successfully", nil)
+}
+
+// RevokeSessions ends every session of an officer, e.g. when their phone is
+// lost. Their access tokens stop working at once. As with deleting, the
+// caller must be able to create the officer's account.
+func (h *AuthHandler) RevokeSessions(c *gin.Context) {
+	phoneNumber := c.Param("phoneNumber")
+	user, err := h.userRepo.GetUserByPhoneNumber(c, phoneNumber)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := checkGrant(middleware.Principal(c), *user); err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, "cannot revoke sessions of a user you could not create")
+		return
+	}
+
+	ended, err := h.sessionService.RevokeAll(c, phoneNumber)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Sessions revoked successfully", gin.H{
+		"phone_number":     phoneNumber,
+		"sessions_revoked": ended,
+	})
+}
+
+// Me returns the authenticated officer's token claims and permissions.
+func (h *AuthHandler) Me(c *gin.Context) {
+	principal := middleware.Principal(c)
+	utils.SuccessResponse(c, http.StatusOK, "Permissions retrieved successfully", gin.H{
+		"principal":   principal,
+		"permissions": principal.Permissions(),
+	})
+}
+
+// JWKS publishes the public signing keys as a JSON Web Key Set so other
+// services can verify officer tokens.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenService.JWKS())
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
+)
+
+type CameraHandler struct {
+	repo repository.CameraRepository
+}
+
+func NewCameraHandler(repo repository.CameraRepository) *CameraHandler {
+	return &CameraHandler{
+		repo: repo,
+	}
+}
+
+type cameraRequest struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name" binding:"required"`
+	Location   models.GeoPoint `json:"location"`
+	Department string          `json:"department" binding:"required"`
+	Status     string          `json:"status"`
+}
+
+func (r *cameraRequest) validate() error {
+	if r.Location.Latitude < -90 || r.Location.Latitude > 90 ||
+		r.Location.Longitude < -180 || r.Location.Longitude > 180 {
+		return fmt.Errorf("location is out of range")
+	}
+	switch r.Status {
+	case "":
+		r.Status = models.CameraStatusActive
+	case models.CameraStatusActive, models.CameraStatusMaintenance, models.CameraStatusDecommissioned:
+	default:
+		return fmt.Errorf("status must be one of %s, %s, %s",
+			models.CameraStatusActive, models.CameraStatusMaintenance, models.CameraStatusDecommissioned)
+	}
+	return nil
+}
+
+// GetAllCameras lists cameras, optionally filtered by ?department=,
+// ?status= and ?health= (use health=stale to find silent cameras).
+func (h *CameraHandler) GetAllCameras(c *gin.Context) {
+	cameras, err := h.repo.ListCameras(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	department, status, health := c.Query("department"), c.Query("status"), c.Query("health")
+	filtered := []models.Camera{}
+	for _, camera := range cameras {
+		if (department == "" || camera.Department == department) &&
+			(status == "" || camera.Status == status) &&
+			(health == "" || camera.Health == health) {
+			filtered = append(filtered, camera)
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cameras retrieved successfully", filtered)
+}
+
+func (h *CameraHandler) GetCamera(c *gin.Context) {
+	camera, err := h.repo.GetCamera(c, c.Param("cameraId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Camera not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Camera retrieved successfully", camera)
+}
+
+func (h *CameraHandler) AddCamera(c *gin.Context) {
+	var req cameraRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: id, name and department are required")
+		return
+	}
+	if err := req.validate(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	camera := models.Camera{
+		ID:         req.ID,
+		Name:       req.Name,
+		Location:   req.Location,
+		Department: req.Department,
+		Status:     req.Status,
+		Health:     models.CameraHealthOnline,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	err := h.repo.CreateCamera(c, camera)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		utils.ErrorResponse(c, http.StatusConflict, "Camera already exists")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Camera added successfully", camera)
+}
+
+// UpdateCamera replaces a camera's name, location, department and status.
+// Health and last-seen times are left to heartbeats and the stale checker.
+func (h *CameraHandler) UpdateCamera(c *gin.Context) {
+	var req cameraRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: name and department are required")
+		return
+	}
+	if err := req.validate(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	camera, err := h.repo.GetCamera(c, c.Param("cameraId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Camera not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	camera.Name = req.Name
+	camera.Location = req.Location
+	camera.Department = req.Department
+	camera.Status = req.Status
+	camera.UpdatedAt = time.Now().UTC()
+	if err := h.repo.UpdateCamera(c, *camera); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Camera updated successfully", camera)
+}
+
+func (h *CameraHandler) DeleteCamera(c *gin.Context) {
+	err := h.repo.DeleteCamera(c, c.Param("cameraId"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Camera not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Camera deleted successfully", nil)
+}
+
+// Heartbeat is called by the camera's own edge device; the authenticated
+// device ID must match the camera ID.
+func (h *CameraHandler) Heartbeat(c *gin.Context) {
+	cameraID := c.Param("cameraId")
+	if c.GetString("device_id") != cameraID {
+		utils.ErrorResponse(c, http.StatusForbidden, "Device may only send heartbeats for its own camera")
+		return
+	}
+
+	err := h.repo.RecordCameraHeartbeat(c, cameraID, time.Now().UTC())
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Camera not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Heartbeat recorded", nil)
+}
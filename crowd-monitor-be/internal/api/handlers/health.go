@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Livez reports that the process is serving requests. It checks no
+// dependencies, so an outage elsewhere does not get the process restarted.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readyz reports each dependency with its status and latency. It answers
+// 503 only when a critical dependency is failing; a degraded instance can
+// still serve most requests.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.checker.Check(c)
+	code := http.StatusOK
+	if report.Status == health.StatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/health"
+)
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fail := func(context.Context) error { return errors.New("down") }
+	ok := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name     string
+		critical bool
+		want     int
+	}{
+		{"critical dependency down", true, http.StatusServiceUnavailable},
+		{"non-critical dependency down", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker(time.Minute, time.Second)
+			checker.Add("firestore", true, ok)
+			checker.Add("dependency", tt.critical, fail)
+			router := gin.New()
+			router.GET("/readyz", NewHealthHandler(checker).Readyz)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.want || rec.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("status = %d, Cache-Control %q, want %d and no-store", rec.Code, rec.Header().Get("Cache-Control"), tt.want)
+			}
+		})
+	}
+}
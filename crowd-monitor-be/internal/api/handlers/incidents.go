@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/api/middleware"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/incidents"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
+)
+
+type IncidentHandler struct {
+	repo            repository.IncidentRepository
+	incidentService *incidents.Service
+}
+
+func NewIncidentHandler(repo repository.IncidentRepository, incidentService *incidents.Service) *IncidentHandler {
+	return &IncidentHandler{
+		repo:            repo,
+		incidentService: incidentService,
+	}
+}
+
+// respondIncident maps the outcome of an incident change to a response.
+func respondIncident(c *gin.Context, status int, message string, incident *models.Incident, err error) {
+	switch {
+	case err == nil:
+		utils.SuccessResponse(c, status, message, incident)
+	case errors.Is(err, repository.ErrNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "Incident not found")
+	case errors.Is(err, repository.ErrOutOfScope):
+		utils.ErrorResponse(c, http.StatusForbidden, "Incident belongs to another department")
+	case errors.Is(err, incidents.ErrInvalidTransition):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, incidents.ErrInvalidRequest):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetAllIncidents lists incidents newest first, filtered by ?status=,
+// ?department=, ?assigned_to= (phone number) and ?video_id=, and capped by
+// ?limit= (default 50, max 200).
+func (h *IncidentHandler) GetAllIncidents(c *gin.Context) {
+	filter := repository.IncidentFilter{
+		Status:     c.Query("status"),
+		Department: c.Query("department"),
+		AssignedTo: c.Query("assigned_to"),
+		VideoID:    c.Query("video_id"),
+		Limit:      defaultAlertLimit,
+	}
+	if filter.Status != "" && !models.IsIncidentStatus(filter.Status) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Unknown incident status")
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = min(n, maxAlertLimit)
+	}
+
+	list, err := h.repo.ListIncidents(c, filter)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Incidents retrieved successfully", list)
+}
+
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	incident, err := h.repo.GetIncident(c, c.Param("incidentId"))
+	respondIncident(c, http.StatusOK, "Incident retrieved successfully", incident, err)
+}
+
+type createIncidentRequest struct {
+	Title       string           `json:"title" binding:"required"`
+	Description string           `json:"description"`
+	Department  string           `json:"department"`
+	VideoIDs    []string         `json:"video_ids"`
+	Location    *models.GeoPoint `json:"location"`
+}
+
+// CreateIncident files the incident under the caller's department unless
+// the body names one.
+func (h *IncidentHandler) CreateIncident(c *gin.Context) {
+	var req createIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: title is required")
+		return
+	}
+	if req.Department == "" {
+		req.Department = middleware.Principal(c).Department
+	}
+
+	incident, err := h.incidentService.Create(c, c.GetString("phone_number"), incidents.NewIncident{
+		Title:       req.Title,
+		Description: req.Description,
+		Department:  req.Department,
+		VideoIDs:    req.VideoIDs,
+		Location:    req.Location,
+	})
+	respondIncident(c, http.StatusCreated, "Incident created successfully", incident, err)
+}
+
+type transitionIncidentRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
+
+// TransitionIncident moves an incident through its lifecycle; transitions
+// the lifecycle does not allow answer 409. Closing has its own route and
+// permission, see CloseIncident.
+func (h *IncidentHandler) TransitionIncident(c *gin.Context) {
+	var req transitionIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: status is required")
+		return
+	}
+	if req.Status == models.IncidentStatusClosed {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Use the close endpoint to close an incident")
+		return
+	}
+
+	incident, err := h.incidentService.Transition(c, c.GetString("phone_number"), c.Param("incidentId"), req.Status, req.Note)
+	respondIncident(c, http.StatusOK, "Incident updated successfully", incident, err)
+}
+
+type closeIncidentRequest struct {
+	Note string `json:"note"`
+}
+
+// CloseIncident closes a resolved incident.
+func (h *IncidentHandler) CloseIncident(c *gin.Context) {
+	var req closeIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	incident, err := h.incidentService.Transition(c, c.GetString("phone_number"), c.Param("incidentId"), models.IncidentStatusClosed, req.Note)
+	respondIncident(c, http.StatusOK, "Incident closed successfully", incident, err)
+}
+
+type assignIncidentRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Nearest     bool   `json:"nearest"`
+}
+
+// AssignIncident assigns the officer with phone_number, or with
+// {"nearest": true} the closest available officer of the department.
+func (h *IncidentHandler) AssignIncident(c *gin.Context) {
+	var req assignIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.PhoneNumber == "") == !req.Nearest {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: exactly one of phone_number or nearest is required")
+		return
+	}
+
+	actor := c.GetString("phone_number")
+	var (
+		incident *models.Incident
+		err      error
+	)
+	if req.Nearest {
+		incident, err = h.incidentService.AssignNearest(c, actor, c.Param("incidentId"))
+	} else {
+		incident, err = h.incidentService.Assign(c, actor, c.Param("incidentId"), req.PhoneNumber)
+	}
+	respondIncident(c, http.StatusOK, "Incident assigned successfully", incident, err)
+}
+
+type incidentNoteRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+func (h *IncidentHandler) AddIncidentNote(c *gin.Context) {
+	var req incidentNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: message is required")
+		return
+	}
+
+	incident, err := h.incidentService.AddNote(c, c.GetString("phone_number"), c.Param("incidentId"), req.Message)
+	respondIncident(c, http.StatusOK, "Note added successfully", incident, err)
+}
+
+type linkAnalysesRequest struct {
+	VideoIDs []string `json:"video_ids" binding:"required"`
+}
+
+func (h *IncidentHandler) LinkIncidentAnalyses(c *gin.Context) {
+	var req linkAnalysesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: video_ids is required")
+		return
+	}
+
+	incident, err := h.incidentService.LinkAnalyses(c, c.GetString("phone_number"), c.Param("incidentId"), req.VideoIDs)
+	respondIncident(c, http.StatusOK, "Analyses linked successfully", incident, err)
+}
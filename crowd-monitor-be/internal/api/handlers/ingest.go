@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/services/ingest"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
+)
+
+const (
+	// maxIngestBodyBytes caps a single or bulk submission.
+	maxIngestBodyBytes = 10 << 20
+	// maxIngestLineBytes caps one NDJSON line.
+	maxIngestLineBytes = 1 << 20
+	// maxBulkItems caps the analyses in one NDJSON submission.
+	maxBulkItems = 500
+)
+
+type IngestHandler struct {
+	ingestService *ingest.Service
+}
+
+func NewIngestHandler(ingestService *ingest.Service) *IngestHandler {
+	return &IngestHandler{
+		ingestService: ingestService,
+	}
+}
+
+// SubmitVideoAnalyses accepts one analysis as application/json, or many as
+// application/x-ndjson with one analysis per line. Bulk submissions always
+// answer 200 with a result per line; a single submission maps its result to
+// 201, 409 or 422.
+func (h *IngestHandler) SubmitVideoAnalyses(c *gin.Context) {
+	deviceID := c.GetString("device_id")
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxIngestBodyBytes)
+
+	switch c.ContentType() {
+	case "application/x-ndjson", "application/ndjson":
+		h.submitBulk(c, deviceID, body)
+	default:
+		h.submitSingle(c, deviceID, body)
+	}
+}
+
+func (h *IngestHandler) submitSingle(c *gin.Context, deviceID string, body io.Reader) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+
+	result := h.ingestService.Ingest(c.Request.Context(), deviceID, 0, raw)
+	switch result.Status {
+	case ingest.StatusCreated:
+		utils.SuccessResponse(c, http.StatusCreated, "Video analysis stored", result)
+	case ingest.StatusDuplicate:
+		c.JSON(http.StatusConflict, utils.Response{Success: false, Error: "video analysis already exists", Data: result})
+	case ingest.StatusInvalid:
+		c.JSON(http.StatusUnprocessableEntity, utils.Response{Success: false, Error: "video analysis failed validation", Data: result})
+	default:
+		c.JSON(http.StatusInternalServerError, utils.Response{Success: false, Error: result.Error, Data: result})
+	}
+}
+
+// submitBulk reads every line before storing anything so an oversized or
+// unreadable body is rejected as a whole.
+func (h *IngestHandler) submitBulk(c *gin.Context, deviceID string, body io.Reader) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxIngestLineBytes)
+
+	type item struct {
+		index int
+		raw   []byte
+	}
+	var items []item
+	line := 0
+	for scanner.Scan() {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		line++
+		if len(raw) == 0 {
+			continue
+		}
+		if len(items) == maxBulkItems {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("At most %d analyses per request", maxBulkItems))
+			return
+		}
+		items = append(items, item{index: line - 1, raw: append([]byte(nil), raw...)})
+	}
+	if err := scanner.Err(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Failed to read NDJSON body after line %d: %v", line, err))
+		return
+	}
+
+	results := make([]ingest.Result, 0, len(items))
+	counts := map[string]int{}
+	for _, it := range items {
+		result := h.ingestService.Ingest(c.Request.Context(), deviceID, it.index, it.raw)
+		results = append(results, result)
+		counts[result.Status]++
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, fmt.Sprintf("Processed %d video analyses", len(results)), gin.H{
+		"results": results,
+		"summary": counts,
+	})
+}
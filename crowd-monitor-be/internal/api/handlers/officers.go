@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/officers"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
+)
+
+// maxNearbyOfficers caps the nearest-officer query.
+const maxNearbyOfficers = 50
+
+type OfficerHandler struct {
+	tracker *officers.Tracker
+}
+
+func NewOfficerHandler(tracker *officers.Tracker) *OfficerHandler {
+	return &OfficerHandler{
+		tracker: tracker,
+	}
+}
+
+type locationPingRequest struct {
+	Latitude   *float64  `json:"latitude" binding:"required"`
+	Longitude  *float64  `json:"longitude" binding:"required"`
+	AccuracyM  float64   `json:"accuracy_m"`
+	Available  *bool     `json:"available"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// RecordLocation stores a location ping for the authenticated officer.
+// available defaults to true.
+func (h *OfficerHandler) RecordLocation(c *gin.Context) {
+	var req locationPingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: latitude and longitude are required")
+		return
+	}
+	if *req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180 || req.AccuracyM < 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Location is out of range")
+		return
+	}
+
+	location, err := h.tracker.RecordPing(c, c.GetString("phone_number"), officers.Ping{
+		Latitude:   *req.Latitude,
+		Longitude:  *req.Longitude,
+		AccuracyM:  req.AccuracyM,
+		Available:  req.Available == nil || *req.Available,
+		RecordedAt: req.RecordedAt,
+	})
+	if errors.Is(err, officers.ErrUnknownOfficer) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Only registered officers can report a location")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Location recorded", location)
+}
+
+func (h *OfficerHandler) GetOfficerLocation(c *gin.Context) {
+	location, err := h.tracker.Location(c, c.Param("phoneNumber"))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "No location reported for this officer")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Location retrieved successfully", location)
+}
+
+// GetNearestOfficers lists available officers with a recent location nearest
+// to latitude/longitude, optionally within radius_km and of department, up
+// to limit (default 10).
+func (h *OfficerHandler) GetNearestOfficers(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("latitude"), 64)
+	lon, errLon := strconv.ParseFloat(c.Query("longitude"), 64)
+	if errLat != nil || errLon != nil || !geo.ValidPoint(lat, lon) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Valid latitude and longitude are required")
+		return
+	}
+
+	radius := 0.0
+	if v := c.Query("radius_km"); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(r > 0) || math.IsInf(r, 1) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "radius_km must be a positive number")
+			return
+		}
+		radius = r
+	}
+
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxNearbyOfficers)
+	}
+
+	nearby, err := h.tracker.Nearest(c, lat, lon, radius, c.Query("department"), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Nearest officers retrieved successfully", nearby)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/metrics"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/events"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
+	"golang.org/x/net/websocket"
+)
+
+var streamSubscribers = metrics.NewGaugeVec("crowd_monitor_stream_subscribers",
+	"Clients connected to the live analysis stream by transport (sse or websocket).",
+	"transport")
+
+type StreamHandler struct {
+	bus       *events.Bus
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(bus *events.Bus, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		bus:       bus,
+		heartbeat: heartbeat,
+	}
+}
+
+// parseStreamFilter reads the subscriber filter: latitude, longitude and
+// radius_km for a radius, bbox for an area, crowd_level as a comma-separated
+// list, camera_id and zone_id. The caller's department scope always
+// applies.
+func parseStreamFilter(c *gin.Context) (events.Filter, error) {
+	f := events.Filter{Scope: repository.ScopeFromContext(c.Request.Context())}
+
+	if v := c.Query("radius_km"); v != "" {
+		radius, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(radius > 0) || math.IsInf(radius, 1) {
+			return f, fmt.Errorf("radius_km must be a positive number")
+		}
+		lat, errLat := strconv.ParseFloat(c.Query("latitude"), 64)
+		lon, errLon := strconv.ParseFloat(c.Query("longitude"), 64)
+		if errLat != nil || errLon != nil || !geo.ValidPoint(lat, lon) {
+			return f, fmt.Errorf("valid latitude and longitude are required with radius_km")
+		}
+		f.Latitude, f.Longitude, f.RadiusKm = lat, lon, radius
+	}
+
+	if v := c.Query("bbox"); v != "" {
+		box, err := geo.ParseBoundingBox(v)
+		if err != nil {
+			return f, err
+		}
+		f.BoundingBox = &box
+	}
+
+	if v := c.Query("crowd_level"); v != "" {
+		f.CrowdLevels = make(map[string]bool)
+		for _, level := range strings.Split(v, ",") {
+			f.CrowdLevels[strings.TrimSpace(level)] = true
+		}
+	}
+
+	f.CameraID = c.Query("camera_id")
+	f.ZoneID = c.Query("zone_id")
+	return f, nil
+}
+
+// lastEventID honours the standard SSE Last-Event-ID header and a
+// last_event_id query parameter for clients that cannot set headers.
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
+
+// StreamSSE pushes new and updated analyses as Server-Sent Events. Each
+// event carries its ID for resumption; a "reset" event tells the client the
+// requested ID could not be replayed and it should refetch. Comment pings
+// keep idle connections open through proxies.
+func (h *StreamHandler) StreamSSE(c *gin.Context) {
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, reset := h.bus.Subscribe(filter, lastEventID(c))
+	defer sub.Close()
+	streamSubscribers.Inc("sse")
+	defer streamSubscribers.Dec("sse")
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if reset {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind; the client reconnects with
+				// Last-Event-ID and is replayed what it missed.
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.ErrorContext(c, "Failed to encode event", "event_id", event.ID, "error", err)
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			c.Writer.Flush()
+		case t := <-ticker.C:
+			fmt.Fprintf(c.Writer, ": ping %s\n\n", t.UTC().Format(time.RFC3339))
+			c.Writer.Flush()
+		}
+	}
+}
+
+// streamMessage is the WebSocket envelope for control messages; analysis
+// events are sent as events.Event.
+type streamMessage struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+}
+
+// StreamWebSocket offers the same stream over a WebSocket. Events and pings
+// are JSON text messages; anything the client sends is ignored.
+func (h *StreamHandler) StreamWebSocket(c *gin.Context) {
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	resumeFrom := lastEventID(c)
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		sub, reset := h.bus.Subscribe(filter, resumeFrom)
+		defer sub.Close()
+		streamSubscribers.Inc("websocket")
+		defer streamSubscribers.Dec("websocket")
+
+		if reset {
+			if err := websocket.JSON.Send(ws, streamMessage{Type: "reset", Time: time.Now().UTC()}); err != nil {
+				return
+			}
+		}
+
+		// Reads only detect the client going away.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var discard string
+			for websocket.Message.Receive(ws, &discard) == nil {
+			}
+		}()
+
+		ticker := time.NewTicker(h.heartbeat)
+		defer ticker.Stop()
+
+		for {
+			var msg interface{}
+			select {
+			case <-closed:
+				return
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				msg = event
+			case t := <-ticker.C:
+				msg = streamMessage{Type: "ping", Time: t.UTC()}
+			}
+			ws.SetWriteDeadline(time.Now().Add(h.heartbeat))
+			if err := websocket.JSON.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -1,26 +1,105 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/access"
+	"github.com/jimil-28/crowd-monitor/internal/api/middleware"
 	"github.com/jimil-28/crowd-monitor/internal/models"
-	"github.com/jimil-28/crowd-monitor/internal/services/firebase"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/roster"
+	"github.com/jimil-28/crowd-monitor/internal/services/sessions"
 	"github.com/jimil-28/crowd-monitor/internal/utils"
 )
 
+// maxRosterSize bounds an uploaded roster file.
+const maxRosterSize = 5 << 20
+
 type UserHandler struct {
-	firebaseClient *firebase.Client
+	repo           repository.UserRepository
+	sessionService *sessions.Service
+	rosterService  *roster.Service
 }
 
-func NewUserHandler(firebaseClient *firebase.Client) *UserHandler {
+func NewUserHandler(repo repository.UserRepository, sessionService *sessions.Service, rosterService *roster.Service) *UserHandler {
 	return &UserHandler{
-		firebaseClient: firebaseClient,
+		repo:           repo,
+		sessionService: sessionService,
+		rosterService:  rosterService,
 	}
 }
 
+// userError is a rejected user change, returned from inside UpdateUser so
+// the check runs against the stored user.
+type userError struct {
+	status  int
+	message string
+}
+
+func (e *userError) Error() string {
+	return e.message
+}
+
+// respondUser maps the outcome of a user change to a response.
+func respondUser(c *gin.Context, status int, message string, data interface{}, err error) {
+	var rejected *userError
+	switch {
+	case err == nil:
+		utils.SuccessResponse(c, status, message, data)
+	case errors.As(err, &rejected):
+		utils.ErrorResponse(c, rejected.status, rejected.message)
+	case errors.Is(err, repository.ErrNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+	case errors.Is(err, repository.ErrAlreadyExists):
+		utils.ErrorResponse(c, http.StatusConflict, "A user with this phone number already exists")
+	case errors.Is(err, repository.ErrIDCardInUse):
+		utils.ErrorResponse(c, http.StatusConflict, "ID card number is already assigned to another user")
+	case errors.Is(err, repository.ErrOutOfScope):
+		utils.ErrorResponse(c, http.StatusForbidden, "Cannot move users to another department")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// userFilter reads ?name= (substring), ?rank=, ?department=,
+// ?id_card_number= and ?status=active|deactivated.
+func userFilter(c *gin.Context) (repository.UserFilter, error) {
+	filter := repository.UserFilter{
+		Name:         c.Query("name"),
+		Rank:         c.Query("rank"),
+		Department:   c.Query("department"),
+		IDCardNumber: c.Query("id_card_number"),
+	}
+	switch c.Query("status") {
+	case "":
+	case "active":
+		filter.Deactivated = new(bool)
+	case "deactivated":
+		deactivated := true
+		filter.Deactivated = &deactivated
+	default:
+		return filter, fmt.Errorf("status must be active or deactivated")
+	}
+	return filter, nil
+}
+
+// GetAllUsers lists the users matching the userFilter query parameters.
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	users, err := h.firebaseClient.GetAllUsers(c)
+	filter, err := userFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, err := h.repo.SearchUsers(c, filter)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -29,17 +108,233 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Users retrieved successfully", users)
 }
 
+func (h *UserHandler) GetUser(c *gin.Context) {
+	user, err := h.repo.GetUserByPhoneNumber(c, c.Param("phoneNumber"))
+	respondUser(c, http.StatusOK, "User retrieved successfully", user, err)
+}
+
 func (h *UserHandler) AddUser(c *gin.Context) {
 	var user models.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	user.Deactivated, user.DeactivatedAt = false, nil
+
+	if !models.IsE164(user.PhoneNumber) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "phone_number must be in E.164 format, e.g. +919876543210")
+		return
+	}
+	if err := validateUser(user); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkGrant(middleware.Principal(c), user); err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	err := h.repo.CreateUser(c, user)
+	if errors.Is(err, repository.ErrOutOfScope) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Cannot add users to another department")
+		return
+	}
+	respondUser(c, http.StatusCreated, "User added successfully", user, err)
+}
+
+// UpdateUser applies a partial update. Officers cannot edit users they
+// could not have created, nor grant more than they could at creation.
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	var update models.UserUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	principal := middleware.Principal(c)
+	user, err := h.repo.UpdateUser(c, c.Param("phoneNumber"), func(user *models.User) error {
+		if err := checkGrant(principal, *user); err != nil {
+			return &userError{http.StatusForbidden, "cannot edit a user you could not create"}
+		}
+		update.Apply(user)
+		if err := validateUser(*user); err != nil {
+			return &userError{http.StatusBadRequest, err.Error()}
+		}
+		if err := checkGrant(principal, *user); err != nil {
+			return &userError{http.StatusForbidden, err.Error()}
+		}
+		return nil
+	})
+	respondUser(c, http.StatusOK, "User updated successfully", user, err)
+}
+
+// DeactivateUser blocks the user from logging in and ends their sessions.
+// The record is kept and can be reactivated.
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	h.setDeactivated(c, true, "User deactivated successfully")
+}
+
+func (h *UserHandler) ReactivateUser(c *gin.Context) {
+	h.setDeactivated(c, false, "User reactivated successfully")
+}
 
-	if err := h.firebaseClient.SaveUser(c, user); err != nil {
+func (h *UserHandler) setDeactivated(c *gin.Context, deactivated bool, message string) {
+	principal := middleware.Principal(c)
+	phoneNumber := c.Param("phoneNumber")
+	if phoneNumber == principal.PhoneNumber {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Cannot change your own account status")
+		return
+	}
+
+	user, err := h.repo.UpdateUser(c, phoneNumber, func(user *models.User) error {
+		if err := checkGrant(principal, *user); err != nil {
+			return &userError{http.StatusForbidden, "cannot edit a user you could not create"}
+		}
+		if user.Deactivated == deactivated {
+			return nil
+		}
+		user.Deactivated, user.DeactivatedAt = deactivated, nil
+		if deactivated {
+			now := time.Now().UTC()
+			user.DeactivatedAt = &now
+		}
+		return nil
+	})
+	if err == nil && deactivated {
+		_, err = h.sessionService.RevokeAll(c, phoneNumber)
+	}
+	respondUser(c, http.StatusOK, message, user, err)
+}
+
+// DeleteUser removes the user for good and ends their sessions.
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	principal := middleware.Principal(c)
+	phoneNumber := c.Param("phoneNumber")
+	if phoneNumber == principal.PhoneNumber {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Cannot delete your own account")
+		return
+	}
+
+	user, err := h.repo.GetUserByPhoneNumber(c, phoneNumber)
+	if err != nil {
+		respondUser(c, http.StatusOK, "", nil, err)
+		return
+	}
+	if err := checkGrant(principal, *user); err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, "cannot delete a user you could not create")
+		return
+	}
+
+	err = h.repo.DeleteUser(c, phoneNumber)
+	if err == nil {
+		_, err = h.sessionService.RevokeAll(c, phoneNumber)
+	}
+	respondUser(c, http.StatusOK, "User deleted successfully", nil, err)
+}
+
+// ImportUsers creates and updates users from a CSV roster, sent as the
+// request body or as the "file" field of a multipart form. Every row is
+// validated first and nothing is written if any fails; ?dry_run=true only
+// validates. The response reports each row.
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "dry_run must be true or false")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRosterSize)
+	var file io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Upload the roster as the \"file\" form field")
+			return
+		}
+		upload, err := header.Open()
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer upload.Close()
+		file = upload
+	}
+
+	principal := middleware.Principal(c)
+	report, err := h.rosterService.Import(c, file, dryRun, func(user models.User) error {
+		if err := validateUser(user); err != nil {
+			return err
+		}
+		return checkGrant(principal, user)
+	})
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Roster files are limited to %d MB", maxRosterSize>>20))
+	case errors.Is(err, roster.ErrInvalidFile):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repository.ErrOutOfScope):
+		utils.ErrorResponse(c, http.StatusForbidden, "Cannot import users into another department")
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	case report.Invalid > 0:
+		c.JSON(http.StatusUnprocessableEntity, utils.Response{
+			Success: false,
+			Error:   "Roster has invalid rows; nothing was imported",
+			Data:    report,
+		})
+	case dryRun:
+		utils.SuccessResponse(c, http.StatusOK, "Roster is valid", report)
+	default:
+		utils.SuccessResponse(c, http.StatusOK, "Roster imported successfully", report)
+	}
+}
+
+// ExportUsers streams the users matching the userFilter query parameters
+// as CSV in the layout ImportUsers reads.
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	filter, err := userFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, err := h.repo.SearchUsers(c, filter)
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "User added successfully", user)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.csv"`, time.Now().Format("2006-01-02")))
+	c.Status(http.StatusOK)
+	if err := roster.WriteCSV(c.Writer, users); err != nil {
+		slog.ErrorContext(c, "Failed to export users", "error", err)
+	}
+}
+
+func validateUser(user models.User) error {
+	if !access.IsRank(user.Rank) {
+		return fmt.Errorf("rank must be one of %s, %s, %s, %s",
+			access.RankASI, access.RankSI, access.RankPI, access.RankDYSP)
+	}
+	if user.Role != "" && !access.IsRole(user.Role) {
+		return fmt.Errorf("role must be %s or %s", access.RoleAdmin, access.RoleControlRoom)
+	}
+	return nil
+}
+
+// checkGrant stops officers from creating or editing accounts more powerful
+// than their own: only admins may assign roles or ranks above their own.
+func checkGrant(creator access.Principal, user models.User) error {
+	if creator.Role == access.RoleAdmin {
+		return nil
+	}
+	if access.RankLevel(user.Rank) > access.RankLevel(creator.Rank) {
+		return fmt.Errorf("cannot grant a rank above your own")
+	}
+	if user.Role != "" {
+		return fmt.Errorf("only admins can assign roles")
+	}
+	return nil
 }
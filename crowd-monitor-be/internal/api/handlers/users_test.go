@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/jimil-28/crowd-monitor/internal/access"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+func TestCheckGrant(t *testing.T) {
+	tests := []struct {
+		name    string
+		creator access.Principal
+		user    models.User
+		ok      bool
+	}{
+		{"same rank", access.Principal{Rank: access.RankPI}, models.User{Rank: access.RankPI}, true},
+		{"lower rank", access.Principal{Rank: access.RankDYSP}, models.User{Rank: access.RankASI}, true},
+		{"higher rank", access.Principal{Rank: access.RankPI}, models.User{Rank: access.RankDYSP}, false},
+		{"role", access.Principal{Rank: access.RankDYSP}, models.User{Rank: access.RankASI, Role: access.RoleControlRoom}, false},
+		{"role the creator holds", access.Principal{Rank: access.RankDYSP, Role: access.RoleControlRoom}, models.User{Rank: access.RankASI, Role: access.RoleControlRoom}, false},
+		{"admin grants any rank", access.Principal{Role: access.RoleAdmin}, models.User{Rank: access.RankDYSP}, true},
+		{"admin grants roles", access.Principal{Role: access.RoleAdmin}, models.User{Rank: access.RankASI, Role: access.RoleAdmin}, true},
+		{"creator without a rank", access.Principal{}, models.User{Rank: access.RankASI}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkGrant(tt.creator, tt.user); (err == nil) != tt.ok {
+				t.Errorf("checkGrant = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateUser(t *testing.T) {
+	tests := []struct {
+		user models.User
+		ok   bool
+	}{
+		{models.User{Rank: access.RankSI}, true},
+		{models.User{Rank: access.RankSI, Role: access.RoleControlRoom}, true},
+		{models.User{Rank: "si"}, false},
+		{models.User{}, false},
+		{models.User{Rank: access.RankSI, Role: "auditor"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateUser(tt.user); (err == nil) != tt.ok {
+			t.Errorf("validateUser(%+v) = %v, want ok %v", tt.user, err, tt.ok)
+		}
+	}
+}
@@ -1,102 +1,356 @@
 package handlers
 
 import (
-    "log"
-    "net/http"
-    "strconv"
-    "github.com/gin-gonic/gin"
-    "github.com/jimil-28/crowd-monitor/internal/services/firebase"
-    "github.com/jimil-28/crowd-monitor/internal/models"
-    "github.com/jimil-28/crowd-monitor/internal/utils"  // Add this import
-    "fmt"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/schema"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
 )
 
+// defaultRadiusKm is used by the nearby search when radius_km is omitted.
+const defaultRadiusKm = 10.0
+
 type VideoAnalysisHandler struct {
-    firebaseClient *firebase.Client
+	repo        repository.VideoAnalysisRepository
+	maxRadiusKm float64
+}
+
+// NewVideoAnalysisHandler creates the handler. maxRadiusKm caps the nearby
+// search radius and half the diagonal of bounding-box and polygon queries.
+func NewVideoAnalysisHandler(repo repository.VideoAnalysisRepository, maxRadiusKm float64) *VideoAnalysisHandler {
+	return &VideoAnalysisHandler{
+		repo:        repo,
+		maxRadiusKm: maxRadiusKm,
+	}
+}
+
+// withDecoder attaches a schema decoder to the request context. Invalid
+// documents are skipped unless the client asks for ?strict=true, in which
+// case the first one fails the request.
+func withDecoder(c *gin.Context) (context.Context, *schema.Decoder) {
+	mode := schema.SkipInvalid
+	if strict, _ := strconv.ParseBool(c.Query("strict")); strict {
+		mode = schema.FailOnInvalid
+	}
+	decoder := schema.NewDecoder(mode)
+	return schema.WithDecoder(c.Request.Context(), decoder), decoder
+}
+
+// respondDecodeError reports err as an invalid stored document when it is
+// one, and returns false otherwise so the caller can handle it.
+func respondDecodeError(c *gin.Context, err error) bool {
+	var docErr *schema.DocumentError
+	if !errors.As(err, &docErr) {
+		return false
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":            "stored video analysis failed validation",
+		"invalid_document": docErr,
+	})
+	return true
+}
+
+// reportSkipped logs the documents the decoder dropped and exposes their
+// count to the client.
+func reportSkipped(c *gin.Context, decoder *schema.Decoder) {
+	for _, docErr := range decoder.Skipped {
+		slog.WarnContext(c, "Skipped invalid video analysis", "error", docErr)
+	}
+	c.Header("X-Skipped-Documents", strconv.Itoa(len(decoder.Skipped)))
 }
 
-func NewVideoAnalysisHandler(firebaseClient *firebase.Client) *VideoAnalysisHandler {
-    return &VideoAnalysisHandler{
-        firebaseClient: firebaseClient,
-    }
+// parseListQuery builds a repository query from the pagination and filter
+// query parameters of GET /video-analyses.
+func parseListQuery(c *gin.Context) (repository.VideoAnalysisQuery, error) {
+	var q repository.VideoAnalysisQuery
+
+	if v := c.Query("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > repository.MaxPageSize {
+			return q, fmt.Errorf("page_size must be between 1 and %d", repository.MaxPageSize)
+		}
+		q.PageSize = n
+	}
+	q.Cursor = c.Query("cursor")
+
+	switch field := c.DefaultQuery("time_field", "timestamp"); field {
+	case "timestamp", "created_at":
+		q.Filter.TimeField = field
+	default:
+		return q, fmt.Errorf("time_field must be timestamp or created_at")
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &q.Filter.From}, {"to", &q.Filter.To}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an RFC3339 timestamp", p.name)
+			}
+			*p.dst = t
+		}
+	}
+	if !q.Filter.From.IsZero() && !q.Filter.To.IsZero() && !q.Filter.From.Before(q.Filter.To) {
+		return q, fmt.Errorf("from must be before to")
+	}
+
+	q.Filter.CrowdLevel = c.Query("crowd_level")
+	q.Filter.PoliceInterventionRequired = c.Query("police_intervention_required")
+	q.Filter.IsPeakHour = c.Query("is_peak_hour")
+	q.Filter.ZoneID = c.Query("zone_id")
+
+	if v := c.Query("bbox"); v != "" {
+		box, err := geo.ParseBoundingBox(v)
+		if err != nil {
+			return q, err
+		}
+		q.Filter.BoundingBox = &box
+	}
+	return q, nil
 }
 
 func (h *VideoAnalysisHandler) GetAllVideoAnalyses(c *gin.Context) {
-    log.Println("Fetching video analyses...")
-    analyses, err := h.firebaseClient.GetAllVideoAnalyses(c)
-    if (err != nil) {
-        log.Printf("Error fetching analyses: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    log.Printf("Found %d analyses", len(analyses))
-    c.JSON(http.StatusOK, analyses)
+	query, err := parseListQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, decoder := withDecoder(c)
+	page, err := h.repo.ListVideoAnalyses(ctx, query)
+	if err != nil {
+		slog.ErrorContext(c, "Failed to list video analyses", "error", err)
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if respondDecodeError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reportSkipped(c, decoder)
+	slog.DebugContext(c, "Listed video analyses", "count", len(page.Items))
+	utils.SuccessResponse(c, http.StatusOK, "Video analyses retrieved successfully", page)
 }
 
 func (h *VideoAnalysisHandler) GetVideoAnalysisByID(c *gin.Context) {
-    videoID := c.Param("videoId")
-    if videoID == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "videoId is required"})
-        return
-    }
-
-    analysis, err := h.firebaseClient.GetVideoAnalysisByID(c, videoID)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-
-    c.JSON(http.StatusOK, analysis)
+	videoID := c.Param("videoId")
+	if videoID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "videoId is required"})
+		return
+	}
+
+	analysis, err := h.repo.GetVideoAnalysisByID(c, videoID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "video analysis not found"})
+		return
+	}
+	if err != nil {
+		if respondDecodeError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis)
 }
 
 func (h *VideoAnalysisHandler) GetNearbyVideoAnalyses(c *gin.Context) {
-    utils.Logger.Printf("Starting nearby video analyses search...")
-    
-    lat, err := strconv.ParseFloat(c.Query("latitude"), 64)
-    if err != nil {
-        utils.Logger.Printf("Invalid latitude parameter: %v", err)
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "error": "invalid latitude parameter",
-            "details": err.Error(),
-        })
-        return
-    }
-
-    lon, err := strconv.ParseFloat(c.Query("longitude"), 64)
-    if err != nil {
-        utils.Logger.Printf("Invalid longitude parameter: %v", err)
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "error": "invalid longitude parameter",
-            "details": err.Error(),
-        })
-        return
-    }
-
-    utils.Logger.Printf("Searching for videos near coordinates: %f, %f", lat, lon)
-    const radiusKm = 10.0
-
-    analyses, err := h.firebaseClient.GetVideoAnalysesNearby(c, lat, lon, radiusKm)
-    if err != nil {
-        utils.Logger.Printf("Error fetching nearby analyses: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "error": "failed to fetch nearby video analyses",
-            "details": err.Error(),
-        })
-        return
-    }
-
-    // Return empty array instead of null when no results found
-    if analyses == nil {
-        analyses = []models.VideoAnalysis{}
-    }
-
-    utils.Logger.Printf("Found %d video analyses within %0.1f km", len(analyses), radiusKm)
-    c.JSON(http.StatusOK, gin.H{
-        "success": true,
-        "data": analyses,
-        "message": fmt.Sprintf("Found %d video analyses within %0.1f km", len(analyses), radiusKm),
-    })
-}
\ No newline at end of file
+	lat, err := strconv.ParseFloat(c.Query("latitude"), 64)
+	if err != nil || !geo.ValidPoint(lat, 0) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid latitude parameter",
+			"details": "latitude must be a number between -90 and 90",
+		})
+		return
+	}
+
+	lon, err := strconv.ParseFloat(c.Query("longitude"), 64)
+	if err != nil || !geo.ValidPoint(0, lon) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid longitude parameter",
+			"details": "longitude must be a number between -180 and 180",
+		})
+		return
+	}
+
+	radiusKm := defaultRadiusKm
+	if v := c.Query("radius_km"); v != "" {
+		radiusKm, err = strconv.ParseFloat(v, 64)
+		// Written so that NaN fails the check
+		if err != nil || !(radiusKm > 0 && radiusKm <= h.maxRadiusKm) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "invalid radius_km parameter",
+				"details": fmt.Sprintf("radius_km must be greater than 0 and at most %g", h.maxRadiusKm),
+			})
+			return
+		}
+	}
+
+	slog.DebugContext(c, "Searching nearby video analyses", "latitude", lat, "longitude", lon, "radius_km", radiusKm)
+
+	ctx, decoder := withDecoder(c)
+	analyses, err := h.repo.GetVideoAnalysesNearby(ctx, lat, lon, radiusKm)
+	if err != nil {
+		slog.ErrorContext(c, "Failed to fetch nearby video analyses", "error", err)
+		if respondDecodeError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "failed to fetch nearby video analyses",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	reportSkipped(c, decoder)
+
+	// Return empty array instead of null when no results found
+	if analyses == nil {
+		analyses = []models.VideoAnalysis{}
+	}
+
+	slog.DebugContext(c, "Found nearby video analyses", "count", len(analyses), "radius_km", radiusKm)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    analyses,
+		"message": fmt.Sprintf("Found %d video analyses within %0.1f km", len(analyses), radiusKm),
+	})
+}
+
+// checkSpan rejects areas larger than the nearby search allows, so a box or
+// polygon cannot be used to scan the whole collection.
+func (h *VideoAnalysisHandler) checkSpan(box geo.BoundingBox) error {
+	if box.DiagonalKm() > 2*h.maxRadiusKm {
+		return fmt.Errorf("area is too large: its diagonal must be at most %g km", 2*h.maxRadiusKm)
+	}
+	return nil
+}
+
+// parseReferencePoint reads ref_latitude/ref_longitude, falling back to the
+// given point when neither is set.
+func parseReferencePoint(c *gin.Context, defLat, defLon float64) (float64, float64, error) {
+	latStr, lonStr := c.Query("ref_latitude"), c.Query("ref_longitude")
+	if latStr == "" && lonStr == "" {
+		return defLat, defLon, nil
+	}
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil || !geo.ValidPoint(lat, 0) {
+		return 0, 0, fmt.Errorf("invalid ref_latitude parameter")
+	}
+	lon, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil || !geo.ValidPoint(0, lon) {
+		return 0, 0, fmt.Errorf("invalid ref_longitude parameter")
+	}
+	return lat, lon, nil
+}
+
+// GetVideoAnalysesInBoundingBox returns analyses inside ?bbox=minLon,minLat,maxLon,maxLat
+// sorted by distance from the reference point (default: the box center).
+func (h *VideoAnalysisHandler) GetVideoAnalysesInBoundingBox(c *gin.Context) {
+	box, err := geo.ParseBoundingBox(c.Query("bbox"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.checkSpan(box); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	centerLat, centerLon := box.Center()
+	refLat, refLon, err := parseReferencePoint(c, centerLat, centerLon)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, decoder := withDecoder(c)
+	analyses, err := h.repo.GetVideoAnalysesInBoundingBox(ctx, box)
+	if err != nil {
+		slog.ErrorContext(c, "Failed to fetch video analyses in bounding box", "error", err)
+		if respondDecodeError(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	reportSkipped(c, decoder)
+
+	if analyses == nil {
+		analyses = []models.VideoAnalysis{}
+	}
+	repository.SortByDistance(analyses, refLat, refLon)
+	utils.SuccessResponse(c, http.StatusOK, fmt.Sprintf("Found %d video analyses in bounding box", len(analyses)), analyses)
+}
+
+type polygonQueryRequest struct {
+	// Geometry is a GeoJSON Polygon, MultiPolygon or Feature wrapping one.
+	Geometry json.RawMessage `json:"geometry" binding:"required"`
+}
+
+// GetVideoAnalysesInPolygon returns analyses inside the posted GeoJSON
+// geometry sorted by distance from the reference point (default: the
+// polygon's vertex centroid).
+func (h *VideoAnalysisHandler) GetVideoAnalysesInPolygon(c *gin.Context) {
+	var req polygonQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	shape, err := geo.ParseGeometry(req.Geometry)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	box := shape.BoundingBox()
+	if err := h.checkSpan(box); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	centroidLat, centroidLon := shape.Centroid()
+	refLat, refLon, err := parseReferencePoint(c, centroidLat, centroidLon)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, decoder := withDecoder(c)
+	candidates, err := h.repo.GetVideoAnalysesInBoundingBox(ctx, box)
+	if err != nil {
+		slog.ErrorContext(c, "Failed to fetch video analyses in polygon", "error", err)
+		if respondDecodeError(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	reportSkipped(c, decoder)
+
+	analyses := []models.VideoAnalysis{}
+	for _, a := range candidates {
+		if shape.Contains(a.Location.Latitude, a.Location.Longitude) {
+			analyses = append(analyses, a)
+		}
+	}
+	repository.SortByDistance(analyses, refLat, refLon)
+	utils.SuccessResponse(c, http.StatusOK, fmt.Sprintf("Found %d video analyses in polygon", len(analyses)), analyses)
+}
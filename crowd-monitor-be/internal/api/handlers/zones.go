@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/zones"
+	"github.com/jimil-28/crowd-monitor/internal/utils"
+)
+
+type ZoneHandler struct {
+	repo        repository.ZoneRepository
+	zoneService *zones.Service
+}
+
+func NewZoneHandler(repo repository.ZoneRepository, zoneService *zones.Service) *ZoneHandler {
+	return &ZoneHandler{
+		repo:        repo,
+		zoneService: zoneService,
+	}
+}
+
+type zoneRequest struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name" binding:"required"`
+	Department      string          `json:"department" binding:"required"`
+	Geometry        json.RawMessage `json:"geometry" binding:"required"`
+	WarningCapacity int             `json:"warning_capacity"`
+	SafeCapacity    int             `json:"safe_capacity"`
+}
+
+func (r zoneRequest) input() zones.Input {
+	return zones.Input{
+		Name:            r.Name,
+		Department:      r.Department,
+		Geometry:        r.Geometry,
+		WarningCapacity: r.WarningCapacity,
+		SafeCapacity:    r.SafeCapacity,
+	}
+}
+
+// respondZone maps the outcome of a zone change to a response.
+func respondZone(c *gin.Context, status int, message string, zone *models.Zone, err error) {
+	switch {
+	case err == nil:
+		utils.SuccessResponse(c, status, message, zone)
+	case errors.Is(err, repository.ErrNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "Zone not found")
+	case errors.Is(err, repository.ErrAlreadyExists):
+		utils.ErrorResponse(c, http.StatusConflict, "Zone already exists")
+	case errors.Is(err, repository.ErrOutOfScope):
+		utils.ErrorResponse(c, http.StatusForbidden, "Zone belongs to another department")
+	case errors.Is(err, zones.ErrInvalidZone):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetAllZones lists zones, optionally filtered by ?department=.
+func (h *ZoneHandler) GetAllZones(c *gin.Context) {
+	list, err := h.repo.ListZones(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	department := c.Query("department")
+	filtered := []models.Zone{}
+	for _, zone := range list {
+		if department == "" || zone.Department == department {
+			filtered = append(filtered, zone)
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Zones retrieved successfully", filtered)
+}
+
+func (h *ZoneHandler) GetZone(c *gin.Context) {
+	zone, err := h.repo.GetZone(c, c.Param("zoneId"))
+	respondZone(c, http.StatusOK, "Zone retrieved successfully", zone, err)
+}
+
+// AddZone stores a zone from a GeoJSON Polygon, MultiPolygon or Feature.
+// The area is computed from the geometry.
+func (h *ZoneHandler) AddZone(c *gin.Context) {
+	var req zoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: name, department and geometry are required")
+		return
+	}
+
+	zone, err := h.zoneService.Create(c, req.ID, req.input())
+	respondZone(c, http.StatusCreated, "Zone added successfully", zone, err)
+}
+
+func (h *ZoneHandler) UpdateZone(c *gin.Context) {
+	var req zoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body: name, department and geometry are required")
+		return
+	}
+
+	zone, err := h.zoneService.Update(c, c.Param("zoneId"), req.input())
+	respondZone(c, http.StatusOK, "Zone updated successfully", zone, err)
+}
+
+// DeleteZone removes the zone. Analyses already tagged with it keep the ID.
+func (h *ZoneHandler) DeleteZone(c *gin.Context) {
+	err := h.zoneService.Delete(c, c.Param("zoneId"))
+	respondZone(c, http.StatusOK, "Zone deleted successfully", nil, err)
+}
+
+func (h *ZoneHandler) GetZoneStatus(c *gin.Context) {
+	zone, err := h.repo.GetZone(c, c.Param("zoneId"))
+	if err != nil {
+		respondZone(c, http.StatusOK, "", nil, err)
+		return
+	}
+
+	status, err := h.zoneService.Status(c, *zone)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Zone status retrieved successfully", status)
+}
+
+// GetAllZoneStatuses summarizes every zone, optionally filtered by
+// ?department=.
+func (h *ZoneHandler) GetAllZoneStatuses(c *gin.Context) {
+	statuses, err := h.zoneService.Statuses(c, c.Query("department"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Zone statuses retrieved successfully", statuses)
+}
@@ -6,13 +6,13 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
 )
 
-// Make sure this matches exactly the secret used in auth/otp.go
-var jwtSecret = []byte("08c06a7edfa777158e6024e7c2de3a1851fe29a50e48bfa1c3a126e5eb75a0fc")
-
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware verifies the bearer token and sets "phone_number",
+// "session_id" and the principal for permission checks in the context.
+func AuthMiddleware(verifier tokens.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,42 +28,38 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Parse the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verify signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecret, nil
-		})
-
+		claims, err := verifier.Verify(c.Request.Context(), tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Token parsing error: %v", err)})
 			return
 		}
 
-		// Validate the token
-		if !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			return
-		}
-
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Failed to extract token claims"})
+		principal := claims.Principal()
+		c.Set("phone_number", claims.PhoneNumber)
+		c.Set("session_id", claims.SessionID)
+		c.Set(principalKey, principal)
+		scope := scopeFor(principal)
+		c.Request = c.Request.WithContext(repository.WithScope(c.Request.Context(), scope))
+		// Handlers hand c to repositories, which only reach the request
+		// context through it with ContextWithFallback. Without that every
+		// read would be unscoped, so deny rather than leak other departments.
+		if repository.ScopeFromContext(c) != scope {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Department scope is not available to handlers"})
 			return
 		}
+		c.Next()
+	}
+}
 
-		// Check if "phone_number" claim exists
-		phoneNumber, exists := claims["phone_number"]
-		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token missing required claims"})
-			return
+// TokenFromQuery lets clients that cannot set headers, such as browser
+// EventSource and WebSocket, pass the JWT as ?access_token=. It only fills
+// the Authorization header when it is absent and must run before
+// AuthMiddleware on the routes that need it.
+func TokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
 		}
-
-		// Set phone number in context
-		c.Set("phone_number", phoneNumber)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
+)
+
+// staticVerifier accepts the tokens in its map, which hold the claims
+// they stand for.
+type staticVerifier map[string]tokens.Claims
+
+func (v staticVerifier) Verify(ctx context.Context, token string) (*tokens.Claims, error) {
+	claims, ok := v[token]
+	if !ok {
+		return nil, errors.New("unknown token")
+	}
+	return &claims, nil
+}
+
+func TestAuthMiddlewareScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := staticVerifier{
+		"asi":  {PhoneNumber: "+919405061350", Rank: "ASI", Department: "Mapusa Police Department"},
+		"dysp": {PhoneNumber: "+919405061349", Rank: "DYSP", Department: "Mapusa Police Department"},
+	}
+
+	tests := []struct {
+		name       string
+		fallback   bool
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no token", true, "", http.StatusUnauthorized, ""},
+		{"department scope", true, "asi", http.StatusOK, "Mapusa Police Department"},
+		{"every department", true, "dysp", http.StatusOK, "*"},
+		// Without the fallback handlers would see no scope at all
+		{"department scope without fallback", false, "asi", http.StatusInternalServerError, ""},
+		{"every department without fallback", false, "dysp", http.StatusOK, "*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.ContextWithFallback = tt.fallback
+			router.GET("/scope", AuthMiddleware(verifier), func(c *gin.Context) {
+				scope := repository.ScopeFromContext(c)
+				if !scope.Restricted() {
+					c.String(http.StatusOK, "*")
+					return
+				}
+				c.String(http.StatusOK, scope.Department())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/scope", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("handler saw scope %q, want %q", rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
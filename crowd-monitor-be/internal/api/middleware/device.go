@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/services/devices"
+)
+
+// DeviceAuthMiddleware authenticates edge analyzers by the X-Device-ID and
+// X-Device-Key headers and sets "device_id" in the context.
+func DeviceAuthMiddleware(verifier devices.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.GetHeader("X-Device-ID")
+		key := c.GetHeader("X-Device-Key")
+		if deviceID == "" || key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "X-Device-ID and X-Device-Key headers required"})
+			return
+		}
+
+		ok, err := verifier.VerifyDevice(c.Request.Context(), deviceID, key)
+		if err != nil {
+			slog.ErrorContext(c, "Device verification failed", "device_id", deviceID, "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify device credentials"})
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid device credentials"})
+			return
+		}
+
+		c.Set("device_id", deviceID)
+		c.Next()
+	}
+}
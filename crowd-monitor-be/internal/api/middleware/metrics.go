@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/metrics"
+)
+
+var (
+	requestDuration = metrics.NewHistogramVec("crowd_monitor_http_request_duration_seconds",
+		"Time to handle HTTP requests by method, route and status. Streams are observed when they close.",
+		metrics.DefBuckets, "method", "route", "status")
+	requestsInFlight = metrics.NewGaugeVec("crowd_monitor_http_requests_in_flight",
+		"HTTP requests being handled, open streams included.")
+)
+
+// Metrics records the latency of every request under its route pattern,
+// e.g. /api/v1/cameras/:id, so IDs do not each get their own series.
+// Requests matching no route are recorded as "unmatched".
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		requestDuration.Observe(time.Since(start).Seconds(),
+			c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+	}
+}
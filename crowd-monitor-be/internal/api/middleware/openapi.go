@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/openapi"
+)
+
+// ValidateOpenAPI rejects requests to documented routes that do not match
+// the OpenAPI document. It belongs after authentication on each group.
+// Routes the document does not describe pass through.
+//
+// With checkResponses, meant for tests and staging, JSON responses are
+// buffered and checked too, and one that does not match is logged and
+// replaced with a 500 so the mismatch cannot go unnoticed.
+func ValidateOpenAPI(doc *openapi.Document, checkResponses bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op := doc.Operation(c.Request.Method, c.FullPath())
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+		if err := op.ValidateRequest(c.Request, params); err != nil {
+			var reqErr *openapi.RequestError
+			if !errors.As(err, &reqErr) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+				return
+			}
+			body := gin.H{"success": false, "error": reqErr.Message}
+			if len(reqErr.Fields) > 0 {
+				body["errors"] = reqErr.Fields
+			}
+			c.AbortWithStatusJSON(reqErr.Status, body)
+			return
+		}
+
+		if !checkResponses || !op.JSONResponses() {
+			c.Next()
+			return
+		}
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buffered
+		// Recovery, further out, must write a panic's 500 to the client
+		defer func() { c.Writer = original }()
+		c.Next()
+		c.Writer = original
+
+		if errs := op.ValidateResponse(buffered.status, buffered.Header(), buffered.body.Bytes()); len(errs) > 0 {
+			slog.ErrorContext(c, "Response does not match the API specification",
+				"route", c.FullPath(), "status", buffered.status, "errors", errs)
+			original.Header().Del("Content-Length")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Response does not match the API specification",
+				"errors":  errs,
+			})
+			return
+		}
+		original.Header().Set("Content-Length", strconv.Itoa(buffered.body.Len()))
+		original.WriteHeader(buffered.status)
+		original.Write(buffered.body.Bytes())
+	}
+}
+
+// bufferedWriter holds a response until it has been checked.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op; the response is written once it has been checked.
+func (w *bufferedWriter) Flush() {}
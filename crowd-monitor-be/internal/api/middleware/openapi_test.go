@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/openapi"
+)
+
+const testDoc = `{
+  "paths": {
+    "/cameras/{cameraId}": {
+      "put": {
+        "parameters": [{"name": "cameraId", "in": "path", "required": true, "schema": {"type": "string", "pattern": "^[a-z0-9-]+$"}}],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}}}
+        },
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {
+            "type": "object", "required": ["success"], "additionalProperties": false,
+            "properties": {"success": {"type": "boolean"}, "name": {"type": "string"}}
+          }}}},
+          "default": {"content": {"application/json": {"schema": {"type": "object"}}}}
+        }
+      }
+    }
+  }
+}`
+
+func TestValidateOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc, err := openapi.Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		checkResponses bool
+		path           string
+		contentType    string
+		body           string
+		response       gin.H
+		wantStatus     int
+		wantBody       string
+	}{
+		{"valid", true, "/cameras/cam-1", "application/json", `{"name": "Gate"}`,
+			gin.H{"success": true, "name": "Gate"}, 200, `{"name":"Gate","success":true}`},
+		{"invalid path parameter", false, "/cameras/Cam_1", "application/json", `{"name": "Gate"}`,
+			gin.H{"success": true}, 400, `"path.cameraId"`},
+		{"invalid body", false, "/cameras/cam-1", "application/json", `{}`,
+			gin.H{"success": true}, 400, `"body.name"`},
+		{"unsupported content type", false, "/cameras/cam-1", "text/plain", `name`,
+			gin.H{"success": true}, 415, `Content-Type text/plain is not supported`},
+		{"mismatched response passes when not checked", false, "/cameras/cam-1", "application/json", `{"name": "Gate"}`,
+			gin.H{"success": true, "id": "cam-1"}, 200, `"id":"cam-1"`},
+		{"mismatched response is replaced", true, "/cameras/cam-1", "application/json", `{"name": "Gate"}`,
+			gin.H{"success": true, "id": "cam-1"}, 500, `"response.id"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ValidateOpenAPI(doc, tt.checkResponses))
+			router.PUT("/cameras/:cameraId", func(c *gin.Context) {
+				// The body must still be readable after validation
+				var req struct{ Name string }
+				if err := c.ShouldBindJSON(&req); err != nil || req.Name != "Gate" {
+					c.JSON(http.StatusTeapot, gin.H{"error": "body was consumed"})
+					return
+				}
+				c.JSON(http.StatusOK, tt.response)
+			})
+
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			router.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rec.Code, rec.Body, tt.wantStatus, tt.wantBody)
+			}
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Errorf("body is not a single JSON value: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestValidateOpenAPIUndocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc, err := openapi.Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	router := gin.New()
+	router.Use(ValidateOpenAPI(doc, true))
+	router.GET("/undocumented", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/undocumented", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("undocumented route got %d %s, want it passed through", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/access"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+// principalKey is the context key AuthMiddleware stores the
+// access.Principal under.
+const principalKey = "principal"
+
+// Principal returns the authenticated officer set by AuthMiddleware. Tokens
+// issued before ranks were embedded yield a principal without permissions.
+func Principal(c *gin.Context) access.Principal {
+	p, _ := c.Get(principalKey)
+	principal, _ := p.(access.Principal)
+	return principal
+}
+
+// scopeFor limits the repository reads of a principal without
+// ViewAllDepartments to its own department.
+func scopeFor(p access.Principal) repository.Scope {
+	if p.Can(access.ViewAllDepartments) {
+		return repository.Scope{}
+	}
+	return repository.DepartmentScope(p.Department)
+}
+
+// RequirePermission rejects requests whose principal lacks perm with 403.
+// It must run after AuthMiddleware.
+func RequirePermission(perm access.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Principal(c).Can(perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Missing permission %s", perm)})
+			return
+		}
+		c.Next()
+	}
+}
@@ -2,8 +2,11 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/access"
 	"github.com/jimil-28/crowd-monitor/internal/api/handlers"
 	"github.com/jimil-28/crowd-monitor/internal/api/middleware"
+	"github.com/jimil-28/crowd-monitor/internal/services/devices"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
 )
 
 func SetupRoutes(
@@ -11,25 +14,123 @@ func SetupRoutes(
 	authHandler *handlers.AuthHandler,
 	videoAnalysisHandler *handlers.VideoAnalysisHandler,
 	userHandler *handlers.UserHandler, // Add this parameter
+	ingestHandler *handlers.IngestHandler,
+	cameraHandler *handlers.CameraHandler,
+	streamHandler *handlers.StreamHandler,
+	alertHandler *handlers.AlertHandler,
+	incidentHandler *handlers.IncidentHandler,
+	officerHandler *handlers.OfficerHandler,
+	zoneHandler *handlers.ZoneHandler,
+	deviceVerifier devices.Verifier,
+	tokenVerifier tokens.Verifier,
+	enableWebSocket bool,
+	validate gin.HandlerFunc, // OpenAPI validation, nil when disabled
 ) {
+	// Requests are checked against the API document after authentication,
+	// so callers without credentials get a 401 rather than schema errors
+	withValidation := func(auth ...gin.HandlerFunc) []gin.HandlerFunc {
+		if validate != nil {
+			auth = append(auth, validate)
+		}
+		return auth
+	}
+
+	// Signing keys for services verifying our tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	// Public routes
 	public := router.Group("/api/v1")
+	public.Use(withValidation()...)
 	{
 		public.POST("/auth/send-otp", authHandler.SendOTP)
 		public.POST("/auth/verify-otp", authHandler.VerifyOTP)
+		public.POST("/auth/refresh", authHandler.Refresh)
+	}
+
+	// Edge analyzer routes, authenticated by device credentials
+	ingestion := router.Group("/api/v1")
+	ingestion.Use(withValidation(middleware.DeviceAuthMiddleware(deviceVerifier))...)
+	{
+		ingestion.POST("/video-analyses", ingestHandler.SubmitVideoAnalyses)
+		ingestion.POST("/cameras/:cameraId/heartbeat", cameraHandler.Heartbeat)
+	}
+
+	// Live streams, which also accept the token as ?access_token=
+	streams := router.Group("/api/v1")
+	streams.Use(withValidation(middleware.TokenFromQuery(), middleware.AuthMiddleware(tokenVerifier))...)
+	{
+		streams.GET("/video-analyses/stream", streamHandler.StreamSSE)
+		if enableWebSocket {
+			streams.GET("/video-analyses/ws", streamHandler.StreamWebSocket)
+		}
 	}
 
 	// Protected routes
 	protected := router.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(withValidation(middleware.AuthMiddleware(tokenVerifier))...)
 	{
+		protected.GET("/auth/me", authHandler.Me)
+		protected.POST("/auth/logout", authHandler.Logout)
+
 		// Existing routes
 		protected.GET("/video-analyses", videoAnalysisHandler.GetAllVideoAnalyses)
 		protected.GET("/video-analyses/:videoId", videoAnalysisHandler.GetVideoAnalysisByID)
 		protected.GET("/video-analyses/nearby", videoAnalysisHandler.GetNearbyVideoAnalyses)
+		protected.GET("/video-analyses/within-bbox", videoAnalysisHandler.GetVideoAnalysesInBoundingBox)
+		protected.POST("/video-analyses/within-polygon", videoAnalysisHandler.GetVideoAnalysesInPolygon)
 
 		// New user routes
-		protected.GET("/users", userHandler.GetAllUsers)
-		protected.POST("/users", userHandler.AddUser)
+		protected.GET("/users", middleware.RequirePermission(access.ViewUsers), userHandler.GetAllUsers)
+		protected.POST("/users", middleware.RequirePermission(access.ManageUsers), userHandler.AddUser)
+		protected.POST("/users/import", middleware.RequirePermission(access.ManageUsers), userHandler.ImportUsers)
+		protected.GET("/users/export", middleware.RequirePermission(access.ViewUsers), userHandler.ExportUsers)
+		protected.GET("/users/:phoneNumber", middleware.RequirePermission(access.ViewUsers), userHandler.GetUser)
+		protected.PATCH("/users/:phoneNumber", middleware.RequirePermission(access.ManageUsers), userHandler.UpdateUser)
+		protected.DELETE("/users/:phoneNumber", middleware.RequirePermission(access.ManageUsers), userHandler.DeleteUser)
+		protected.POST("/users/:phoneNumber/deactivate", middleware.RequirePermission(access.ManageUsers), userHandler.DeactivateUser)
+		protected.POST("/users/:phoneNumber/reactivate", middleware.RequirePermission(access.ManageUsers), userHandler.ReactivateUser)
+		protected.DELETE("/users/:phoneNumber/sessions", middleware.RequirePermission(access.ManageUsers), authHandler.RevokeSessions)
+
+		// Camera registry
+		protected.GET("/cameras", cameraHandler.GetAllCameras)
+		protected.POST("/cameras", middleware.RequirePermission(access.ManageCameras), cameraHandler.AddCamera)
+		protected.GET("/cameras/:cameraId", cameraHandler.GetCamera)
+		protected.PUT("/cameras/:cameraId", middleware.RequirePermission(access.ManageCameras), cameraHandler.UpdateCamera)
+		protected.DELETE("/cameras/:cameraId", middleware.RequirePermission(access.ManageCameras), cameraHandler.DeleteCamera)
+
+		// Alerting
+		protected.GET("/alert-rules", alertHandler.GetAllAlertRules)
+		protected.POST("/alert-rules", middleware.RequirePermission(access.ManageAlertRules), alertHandler.AddAlertRule)
+		protected.GET("/alert-rules/:ruleId", alertHandler.GetAlertRule)
+		protected.PUT("/alert-rules/:ruleId", middleware.RequirePermission(access.ManageAlertRules), alertHandler.UpdateAlertRule)
+		protected.DELETE("/alert-rules/:ruleId", middleware.RequirePermission(access.ManageAlertRules), alertHandler.DeleteAlertRule)
+		protected.GET("/alerts", alertHandler.GetAllAlerts)
+		protected.GET("/alerts/:alertId", alertHandler.GetAlert)
+		protected.POST("/alerts/:alertId/acknowledge", middleware.RequirePermission(access.HandleAlerts), alertHandler.AcknowledgeAlert)
+		protected.POST("/alerts/:alertId/resolve", middleware.RequirePermission(access.HandleAlerts), alertHandler.ResolveAlert)
+
+		// Incidents
+		protected.GET("/incidents", incidentHandler.GetAllIncidents)
+		protected.POST("/incidents", middleware.RequirePermission(access.ReportIncidents), incidentHandler.CreateIncident)
+		protected.GET("/incidents/:incidentId", incidentHandler.GetIncident)
+		protected.POST("/incidents/:incidentId/transition", middleware.RequirePermission(access.ManageIncidents), incidentHandler.TransitionIncident)
+		protected.POST("/incidents/:incidentId/close", middleware.RequirePermission(access.CloseIncidents), incidentHandler.CloseIncident)
+		protected.POST("/incidents/:incidentId/assign", middleware.RequirePermission(access.ManageIncidents), incidentHandler.AssignIncident)
+		protected.POST("/incidents/:incidentId/notes", middleware.RequirePermission(access.ReportIncidents), incidentHandler.AddIncidentNote)
+		protected.POST("/incidents/:incidentId/analyses", middleware.RequirePermission(access.ReportIncidents), incidentHandler.LinkIncidentAnalyses)
+
+		// Officer locations
+		protected.POST("/officers/location", officerHandler.RecordLocation)
+		protected.GET("/officers/nearest", officerHandler.GetNearestOfficers)
+		protected.GET("/officers/:phoneNumber/location", officerHandler.GetOfficerLocation)
+
+		// Zones
+		protected.GET("/zones", zoneHandler.GetAllZones)
+		protected.POST("/zones", middleware.RequirePermission(access.ManageZones), zoneHandler.AddZone)
+		protected.GET("/zones/status", zoneHandler.GetAllZoneStatuses)
+		protected.GET("/zones/:zoneId", zoneHandler.GetZone)
+		protected.PUT("/zones/:zoneId", middleware.RequirePermission(access.ManageZones), zoneHandler.UpdateZone)
+		protected.DELETE("/zones/:zoneId", middleware.RequirePermission(access.ManageZones), zoneHandler.DeleteZone)
+		protected.GET("/zones/:zoneId/status", zoneHandler.GetZoneStatus)
 	}
 }
@@ -0,0 +1,852 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimil-28/crowd-monitor/internal/access"
+	"github.com/jimil-28/crowd-monitor/internal/api/handlers"
+	"github.com/jimil-28/crowd-monitor/internal/api/middleware"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/openapi"
+	"github.com/jimil-28/crowd-monitor/internal/services/auth"
+	"github.com/jimil-28/crowd-monitor/internal/services/devices"
+	"github.com/jimil-28/crowd-monitor/internal/services/events"
+	"github.com/jimil-28/crowd-monitor/internal/services/incidents"
+	"github.com/jimil-28/crowd-monitor/internal/services/ingest"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+	"github.com/jimil-28/crowd-monitor/internal/services/officers"
+	"github.com/jimil-28/crowd-monitor/internal/services/roster"
+	"github.com/jimil-28/crowd-monitor/internal/services/sessions"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
+	"github.com/jimil-28/crowd-monitor/internal/services/zones"
+)
+
+const (
+	mapusa  = "Mapusa Police Department"
+	madgaon = "Madgaon Police Department"
+
+	// Seeded officers
+	mapusaDYSP = "+919405061349"
+	mapusaASI  = "+919405061350"
+	madgaonASI = "+919175045787"
+	madgaonPI  = "+919175045788"
+
+	// The seeded camera is also the device that submits its analyses
+	cameraID  = "cam1"
+	deviceKey = "secret"
+)
+
+// testServer is the API wired as in cmd/server against the memory backend,
+// with responses checked against the OpenAPI document as in test mode.
+type testServer struct {
+	t        *testing.T
+	store    *memory.Client
+	sessions *sessions.Service
+	router   *gin.Engine
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := memory.NewMemoryClient()
+	ctx := context.Background()
+	for _, user := range []models.User{
+		{PhoneNumber: mapusaDYSP, Name: "Kavita Naik", Rank: "DYSP", Department: mapusa, IDCardNumber: "321"},
+		{PhoneNumber: mapusaASI, Name: "Anil Gaonkar", Rank: "ASI", Department: mapusa, IDCardNumber: "322"},
+		{PhoneNumber: madgaonASI, Name: "Rajesh Kumar", Rank: "ASI", Department: madgaon, IDCardNumber: "123"},
+		{PhoneNumber: madgaonPI, Name: "Sunita Desai", Rank: "PI", Department: madgaon, IDCardNumber: "124"},
+	} {
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	err := store.CreateCamera(ctx, models.Camera{
+		ID:         cameraID,
+		Name:       "Calangute 1",
+		Location:   models.GeoPoint{Latitude: 15.5439, Longitude: 73.7553},
+		Department: mapusa,
+	})
+	if err != nil {
+		t.Fatalf("CreateCamera: %v", err)
+	}
+
+	keys, err := tokens.LoadKeySet("", "")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	if err := keys.GenerateEphemeral(); err != nil {
+		t.Fatalf("GenerateEphemeral: %v", err)
+	}
+	tokenService, err := tokens.NewTokenService(keys, "crowd-monitor-test", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenService: %v", err)
+	}
+	sessionService := sessions.NewSessionService(tokenService, store, store, time.Hour)
+	authService := auth.NewAuthService(auth.NewLocalProvider(store, auth.ConsoleSender{}, 5*time.Minute),
+		store, sessionService, auth.Limits{})
+
+	digest := sha256.Sum256([]byte(deviceKey))
+	deviceCredentials, err := devices.ParseCredentials(cameraID + ":" + hex.EncodeToString(digest[:]))
+	if err != nil {
+		t.Fatalf("ParseCredentials: %v", err)
+	}
+
+	zoneService := zones.NewZoneService(store, store, time.Hour)
+	bus := events.NewBus(16, 16)
+	t.Cleanup(bus.Close)
+	tracker := officers.NewTracker(store, store, time.Hour)
+	incidentService := incidents.NewIncidentService(store, store, store, tracker)
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("openapi.Load: %v", err)
+	}
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	SetupRoutes(router,
+		handlers.NewAuthHandler(authService, tokenService, sessionService, store),
+		handlers.NewVideoAnalysisHandler(store, 50),
+		handlers.NewUserHandler(store, sessionService, roster.NewRosterService(store)),
+		handlers.NewIngestHandler(ingest.NewIngestService(store, store, zoneService)),
+		handlers.NewCameraHandler(store),
+		handlers.NewStreamHandler(bus, time.Minute),
+		handlers.NewAlertHandler(store, store),
+		handlers.NewIncidentHandler(store, incidentService),
+		handlers.NewOfficerHandler(tracker),
+		handlers.NewZoneHandler(store, zoneService),
+		deviceCredentials, sessionService, false,
+		middleware.ValidateOpenAPI(doc, true),
+	)
+
+	return &testServer{t: t, store: store, sessions: sessionService, router: router}
+}
+
+// login starts a session for a seeded officer and returns its access token.
+func (s *testServer) login(phoneNumber string) string {
+	s.t.Helper()
+	user, err := s.store.GetUserByPhoneNumber(context.Background(), phoneNumber)
+	if err != nil {
+		s.t.Fatalf("GetUserByPhoneNumber(%s): %v", phoneNumber, err)
+	}
+	issued, err := s.sessions.Start(context.Background(), *user)
+	if err != nil {
+		s.t.Fatalf("Start: %v", err)
+	}
+	return issued.AccessToken
+}
+
+// do sends body, when not nil, as JSON with token as the bearer token.
+func (s *testServer) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := s.request(method, path, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return s.serve(req)
+}
+
+// submit posts body to the ingestion endpoint with a device's credentials.
+func (s *testServer) submit(deviceID, key string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := s.request(http.MethodPost, "/api/v1/video-analyses", body)
+	if deviceID != "" {
+		req.Header.Set("X-Device-ID", deviceID)
+		req.Header.Set("X-Device-Key", key)
+	}
+	return s.serve(req)
+}
+
+func (s *testServer) request(method, path string, body interface{}) *http.Request {
+	s.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+func (s *testServer) serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// response is the envelope of utils.Response with the data left raw.
+type response struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// expect checks the status and decodes the envelope, and data into into
+// when it is not nil.
+func expect(t *testing.T, rec *httptest.ResponseRecorder, status int, into interface{}) response {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body.String())
+	}
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+	}
+	if into != nil {
+		if err := json.Unmarshal(resp.Data, into); err != nil {
+			t.Fatalf("decode data %s: %v", resp.Data, err)
+		}
+	}
+	return resp
+}
+
+func TestAuthentication(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"not bearer", "Basic abc", http.StatusUnauthorized},
+		{"garbage token", "Bearer abc", http.StatusUnauthorized},
+		{"valid token", "Bearer " + s.login(mapusaDYSP), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := s.request(http.MethodGet, "/api/v1/auth/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if rec := s.serve(req); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserRoutes(t *testing.T) {
+	s := newTestServer(t)
+	dysp := s.login(mapusaDYSP)
+	asi := s.login(mapusaASI)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		want   int
+	}{
+		{"list", http.MethodGet, "/api/v1/users", dysp, nil, http.StatusOK},
+		{"list without permission", http.MethodGet, "/api/v1/users", asi, nil, http.StatusForbidden},
+		{"get", http.MethodGet, "/api/v1/users/" + mapusaASI, dysp, nil, http.StatusOK},
+		{"get missing", http.MethodGet, "/api/v1/users/+919000000000", dysp, nil, http.StatusNotFound},
+		{"add", http.MethodPost, "/api/v1/users", dysp, models.User{
+			PhoneNumber: "+919000000001", Name: "New Officer", Rank: "SI", Department: mapusa,
+		}, http.StatusCreated},
+		{"add duplicate", http.MethodPost, "/api/v1/users", dysp, models.User{
+			PhoneNumber: mapusaASI, Name: "Duplicate", Rank: "ASI", Department: mapusa,
+		}, http.StatusConflict},
+		{"add with a bad phone number", http.MethodPost, "/api/v1/users", dysp, models.User{
+			PhoneNumber: "9000000002", Name: "Bad Number", Rank: "ASI", Department: mapusa,
+		}, http.StatusBadRequest},
+		{"add without permission", http.MethodPost, "/api/v1/users", asi, models.User{
+			PhoneNumber: "+919000000003", Name: "Unauthorized", Rank: "ASI", Department: mapusa,
+		}, http.StatusForbidden},
+		{"update", http.MethodPatch, "/api/v1/users/" + mapusaASI, dysp, map[string]string{"name": "Anil G."}, http.StatusOK},
+		{"deactivate yourself", http.MethodPost, "/api/v1/users/" + mapusaDYSP + "/deactivate", dysp, nil, http.StatusBadRequest},
+		{"delete", http.MethodDelete, "/api/v1/users/+919000000001", dysp, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt.method, tt.path, tt.token, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	var user models.User
+	expect(t, s.do(http.MethodGet, "/api/v1/users/"+mapusaASI, dysp, nil), http.StatusOK, &user)
+	if user.Name != "Anil G." {
+		t.Errorf("name after update = %q, want %q", user.Name, "Anil G.")
+	}
+}
+
+func TestVideoAnalysisRoutes(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, a := range []models.VideoAnalysis{
+		{VideoID: "a1", Location: models.Location{Latitude: 15.5439, Longitude: 73.7553}},
+		{VideoID: "a2", Location: models.Location{Latitude: 15.5500, Longitude: 73.7600}},
+		{VideoID: "a3", Location: models.Location{Latitude: 15.9000, Longitude: 73.9000}},
+	} {
+		a.Timestamp = now.Add(-time.Duration(i) * time.Minute)
+		a.CreatedAt = a.Timestamp
+		a.Department = mapusa
+		a.Analysis = models.Analysis{CrowdCount: "40", CrowdLevel: "medium", CrowdPresent: "yes",
+			IsPeakHour: "no", PoliceInterventionRequired: "no", PoliceInterventionSuggestions: []string{}}
+		a.FrameURLs = []string{}
+		if err := s.store.CreateVideoAnalysis(context.Background(), a); err != nil {
+			t.Fatalf("CreateVideoAnalysis: %v", err)
+		}
+	}
+	token := s.login(mapusaDYSP)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+		ids  []string
+	}{
+		{"nearby", "/api/v1/video-analyses/nearby?latitude=15.5439&longitude=73.7553&radius_km=5", http.StatusOK, []string{"a1", "a2"}},
+		{"nearby with a small radius", "/api/v1/video-analyses/nearby?latitude=15.5439&longitude=73.7553&radius_km=0.1", http.StatusOK, []string{"a1"}},
+		{"within bbox", "/api/v1/video-analyses/within-bbox?bbox=73.7,15.5,73.8,15.6", http.StatusOK, []string{"a1", "a2"}},
+		{"nearby without latitude", "/api/v1/video-analyses/nearby?longitude=73.7553", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(http.MethodGet, tt.path, token, nil)
+			if tt.want != http.StatusOK {
+				if rec.Code != tt.want {
+					t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+				}
+				return
+			}
+			var analyses []models.VideoAnalysis
+			expect(t, rec, http.StatusOK, &analyses)
+			if got := videoIDs(analyses); !sameSet(got, tt.ids) {
+				t.Errorf("got %v, want %v", got, tt.ids)
+			}
+		})
+	}
+
+	var page struct {
+		Items []models.VideoAnalysis `json:"items"`
+	}
+	expect(t, s.do(http.MethodGet, "/api/v1/video-analyses", token, nil), http.StatusOK, &page)
+	if got, want := videoIDs(page.Items), []string{"a1", "a2", "a3"}; !sameSet(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+
+	rec := s.do(http.MethodGet, "/api/v1/video-analyses/a2", token, nil)
+	var analysis models.VideoAnalysis
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); rec.Code != http.StatusOK || err != nil || analysis.VideoID != "a2" {
+		t.Errorf("GET a2: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if rec := s.do(http.MethodGet, "/api/v1/video-analyses/missing", token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("missing analysis: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestVideoAnalysisListQuery(t *testing.T) {
+	s := newTestServer(t)
+	base := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		err := s.store.CreateVideoAnalysis(context.Background(), models.VideoAnalysis{
+			VideoID:    fmt.Sprintf("v%d", i),
+			Timestamp:  base.Add(time.Duration(i) * time.Minute),
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+			Department: mapusa,
+			Analysis:   models.Analysis{CrowdLevel: "low", PoliceInterventionSuggestions: []string{}},
+			FrameURLs:  []string{},
+		})
+		if err != nil {
+			t.Fatalf("CreateVideoAnalysis: %v", err)
+		}
+	}
+	token := s.login(mapusaDYSP)
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"page size", "page_size=2", http.StatusOK},
+		{"page size too large", "page_size=201", http.StatusBadRequest},
+		{"page size zero", "page_size=0", http.StatusBadRequest},
+		{"bad time field", "time_field=updated_at", http.StatusBadRequest},
+		{"bad from", "from=yesterday", http.StatusBadRequest},
+		{"from after to", "from=2026-10-18T10:00:00Z&to=2026-10-18T09:00:00Z", http.StatusBadRequest},
+		{"bad cursor", "cursor=nope", http.StatusBadRequest},
+		{"bad bbox", "bbox=1,2,3", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(http.MethodGet, "/api/v1/video-analyses?"+tt.query, token, nil)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	var got []string
+	path := "/api/v1/video-analyses?page_size=2&from=2026-10-18T09:01:00Z"
+	for pages := 0; pages < 5; pages++ {
+		var page struct {
+			Items      []models.VideoAnalysis `json:"items"`
+			NextCursor string                 `json:"next_cursor"`
+		}
+		expect(t, s.do(http.MethodGet, path, token, nil), http.StatusOK, &page)
+		got = append(got, videoIDs(page.Items)...)
+		if page.NextCursor == "" {
+			break
+		}
+		path = "/api/v1/video-analyses?page_size=2&from=2026-10-18T09:01:00Z&cursor=" + page.NextCursor
+	}
+	if want := []string{"v4", "v3", "v2", "v1"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paged through %v, want %v", got, want)
+	}
+}
+
+func TestGeoQueryParameters(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(mapusaDYSP)
+	near := "/api/v1/video-analyses/nearby?"
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"nearby", near + "latitude=15.5&longitude=73.8", http.StatusOK},
+		{"nearby at the maximum radius", near + "latitude=15.5&longitude=73.8&radius_km=50", http.StatusOK},
+		{"latitude NaN", near + "latitude=NaN&longitude=73.8", http.StatusBadRequest},
+		{"latitude out of range", near + "latitude=90.5&longitude=73.8", http.StatusBadRequest},
+		{"longitude infinite", near + "latitude=15.5&longitude=Inf", http.StatusBadRequest},
+		{"radius NaN", near + "latitude=15.5&longitude=73.8&radius_km=NaN", http.StatusBadRequest},
+		{"radius zero", near + "latitude=15.5&longitude=73.8&radius_km=0", http.StatusBadRequest},
+		{"radius above the maximum", near + "latitude=15.5&longitude=73.8&radius_km=50.1", http.StatusBadRequest},
+		{"bbox", "/api/v1/video-analyses/within-bbox?bbox=73.7,15.5,73.8,15.6", http.StatusOK},
+		{"bbox with NaN", "/api/v1/video-analyses/within-bbox?bbox=NaN,15.5,73.8,15.6", http.StatusBadRequest},
+		{"bbox too large", "/api/v1/video-analyses/within-bbox?bbox=73,15,75,17", http.StatusBadRequest},
+		{"bbox reference point NaN", "/api/v1/video-analyses/within-bbox?bbox=73.7,15.5,73.8,15.6&ref_latitude=NaN&ref_longitude=73.8", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(http.MethodGet, tt.path, token, nil)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	polygons := []struct {
+		name     string
+		geometry string
+		want     int
+	}{
+		{"polygon", `{"type":"Polygon","coordinates":[[[73.7,15.5],[73.8,15.5],[73.8,15.6],[73.7,15.5]]]}`, http.StatusOK},
+		{"open ring", `{"type":"Polygon","coordinates":[[[73.7,15.5],[73.8,15.5],[73.8,15.6],[73.7,15.6]]]}`, http.StatusBadRequest},
+		{"too large", `{"type":"Polygon","coordinates":[[[73,15],[75,15],[75,17],[73,15]]]}`, http.StatusBadRequest},
+	}
+	for _, tt := range polygons {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]json.RawMessage{"geometry": json.RawMessage(tt.geometry)}
+			rec := s.do(http.MethodPost, "/api/v1/video-analyses/within-polygon", token, body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestIngestRoute(t *testing.T) {
+	s := newTestServer(t)
+	analysis := func(id string) map[string]interface{} {
+		return map[string]interface{}{
+			"video_id":  id,
+			"timestamp": "2026-10-18T09:00:00Z",
+			"location":  map[string]float64{"latitude": 15.5439, "longitude": 73.7553},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		deviceID string
+		key      string
+		body     interface{}
+		want     int
+	}{
+		{"no credentials", "", "", analysis("v1"), http.StatusUnauthorized},
+		{"wrong key", cameraID, "wrong", analysis("v1"), http.StatusUnauthorized},
+		{"unknown device", "cam2", deviceKey, analysis("v1"), http.StatusUnauthorized},
+		{"created", cameraID, deviceKey, analysis("v1"), http.StatusCreated},
+		{"duplicate", cameraID, deviceKey, analysis("v1"), http.StatusConflict},
+		{"invalid", cameraID, deviceKey, map[string]interface{}{"video_id": "v2"}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.submit(tt.deviceID, tt.key, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	stored, err := s.store.GetVideoAnalysisByID(context.Background(), "v1")
+	if err != nil {
+		t.Fatalf("GetVideoAnalysisByID: %v", err)
+	}
+	if stored.CameraID != cameraID || stored.Department != mapusa {
+		t.Errorf("stored camera %q in %q, want %q in %q", stored.CameraID, stored.Department, cameraID, mapusa)
+	}
+
+	var lines bytes.Buffer
+	for _, id := range []string{"v1", "v3", "v4"} {
+		raw, _ := json.Marshal(analysis(id))
+		lines.Write(append(raw, '\n'))
+	}
+	lines.WriteString("{}\n")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/video-analyses", &lines)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Device-ID", cameraID)
+	req.Header.Set("X-Device-Key", deviceKey)
+	var bulk struct {
+		Summary map[string]int `json:"summary"`
+	}
+	expect(t, s.serve(req), http.StatusOK, &bulk)
+	want := map[string]int{"created": 2, "duplicate": 1, "invalid": 1}
+	if fmt.Sprint(bulk.Summary) != fmt.Sprint(want) {
+		t.Errorf("summary = %v, want %v", bulk.Summary, want)
+	}
+}
+
+func TestDeviceOwnsItsCamera(t *testing.T) {
+	s := newTestServer(t)
+	err := s.store.CreateCamera(context.Background(), models.Camera{ID: "cam2", Department: mapusa})
+	if err != nil {
+		t.Fatalf("CreateCamera: %v", err)
+	}
+
+	heartbeat := func(camera string) *httptest.ResponseRecorder {
+		req := s.request(http.MethodPost, "/api/v1/cameras/"+camera+"/heartbeat", nil)
+		req.Header.Set("X-Device-ID", cameraID)
+		req.Header.Set("X-Device-Key", deviceKey)
+		return s.serve(req)
+	}
+	if rec := heartbeat(cameraID); rec.Code != http.StatusOK {
+		t.Errorf("own heartbeat: status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	if rec := heartbeat("cam2"); rec.Code != http.StatusForbidden {
+		t.Errorf("heartbeat for another camera: status = %d, want 403", rec.Code)
+	}
+
+	rec := s.submit(cameraID, deviceKey, map[string]interface{}{
+		"video_id":  "v1",
+		"camera_id": "cam2",
+		"timestamp": "2026-10-18T09:00:00Z",
+		"location":  map[string]float64{"latitude": 15.5439, "longitude": 73.7553},
+	})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("analysis for another camera: status = %d, want 422; body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStreamRejectsBadFilters(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(mapusaDYSP)
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"radius NaN", "radius_km=NaN&latitude=15.5&longitude=73.8"},
+		{"radius infinite", "radius_km=Inf&latitude=15.5&longitude=73.8"},
+		{"radius negative", "radius_km=-1&latitude=15.5&longitude=73.8"},
+		{"radius without a point", "radius_km=5"},
+		{"latitude NaN", "radius_km=5&latitude=NaN&longitude=73.8"},
+		{"longitude out of range", "radius_km=5&latitude=15.5&longitude=181"},
+		{"bad bbox", "bbox=73.8,15.5,73.7,15.6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(http.MethodGet, "/api/v1/video-analyses/stream?"+tt.query, token, nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400; body: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPermissionsByRank(t *testing.T) {
+	s := newTestServer(t)
+	asi := s.login(madgaonASI)
+	pi := s.login(madgaonPI)
+
+	var me struct {
+		Principal   access.Principal    `json:"principal"`
+		Permissions []access.Permission `json:"permissions"`
+	}
+	expect(t, s.do(http.MethodGet, "/api/v1/auth/me", pi, nil), http.StatusOK, &me)
+	if me.Principal.Rank != "PI" || me.Principal.Department != madgaon || len(me.Permissions) != 8 {
+		t.Errorf("me = %+v, want a Madgaon PI with 8 permissions", me)
+	}
+
+	// Missing records show the request got past RequirePermission
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"ASI acknowledging an alert", http.MethodPost, "/api/v1/alerts/missing/acknowledge", asi, http.StatusForbidden},
+		{"PI acknowledging an alert", http.MethodPost, "/api/v1/alerts/missing/acknowledge", pi, http.StatusNotFound},
+		{"ASI deleting a camera", http.MethodDelete, "/api/v1/cameras/missing", asi, http.StatusForbidden},
+		{"PI deleting a camera", http.MethodDelete, "/api/v1/cameras/missing", pi, http.StatusNotFound},
+		{"ASI deleting a zone", http.MethodDelete, "/api/v1/zones/missing", asi, http.StatusForbidden},
+		{"PI deleting a zone", http.MethodDelete, "/api/v1/zones/missing", pi, http.StatusNotFound},
+		{"PI deleting a user", http.MethodDelete, "/api/v1/users/" + madgaonASI, pi, http.StatusForbidden},
+		{"PI revoking sessions", http.MethodDelete, "/api/v1/users/" + madgaonASI + "/sessions", pi, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := s.do(tt.method, tt.path, tt.token, nil); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestOfficerLocationScope(t *testing.T) {
+	s := newTestServer(t)
+	ping := map[string]float64{"latitude": 15.5439, "longitude": 73.7553}
+	for _, phone := range []string{mapusaASI, madgaonASI} {
+		expect(t, s.do(http.MethodPost, "/api/v1/officers/location", s.login(phone), ping), http.StatusOK, nil)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		want  int
+	}{
+		{"own department", s.login(madgaonPI), "/api/v1/officers/" + madgaonASI + "/location", http.StatusOK},
+		{"other department", s.login(madgaonPI), "/api/v1/officers/" + mapusaASI + "/location", http.StatusNotFound},
+		{"all departments", s.login(mapusaDYSP), "/api/v1/officers/" + madgaonASI + "/location", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := s.do(http.MethodGet, tt.path, tt.token, nil); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	var nearby []officers.NearbyOfficer
+	expect(t, s.do(http.MethodGet, "/api/v1/officers/nearest?latitude=15.5439&longitude=73.7553", s.login(madgaonPI), nil), http.StatusOK, &nearby)
+	if len(nearby) != 1 || nearby[0].Location.PhoneNumber != madgaonASI {
+		t.Errorf("nearest for a Madgaon officer = %+v, want only %s", nearby, madgaonASI)
+	}
+}
+
+func TestSessionRoutes(t *testing.T) {
+	s := newTestServer(t)
+	user, err := s.store.GetUserByPhoneNumber(context.Background(), mapusaASI)
+	if err != nil {
+		t.Fatalf("GetUserByPhoneNumber: %v", err)
+	}
+	pair, err := s.sessions.Start(context.Background(), *user)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		return s.do(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: token})
+	}
+	var refreshed models.AuthResponse
+	rec := refresh(pair.RefreshToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &refreshed); err != nil {
+		t.Fatalf("decode refresh response: %v", err)
+	}
+	if refreshed.User.PhoneNumber != mapusaASI || refreshed.RefreshToken == pair.RefreshToken {
+		t.Errorf("refresh response = %+v, want a rotated token for %s", refreshed, mapusaASI)
+	}
+	if rec := refresh(pair.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token status = %d, want 401", rec.Code)
+	}
+	if rec := s.do(http.MethodGet, "/api/v1/auth/me", refreshed.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token of a reused session status = %d, want 401", rec.Code)
+	}
+
+	token := s.login(mapusaASI)
+	expect(t, s.do(http.MethodPost, "/api/v1/auth/logout", token, nil), http.StatusOK, nil)
+	if rec := s.do(http.MethodGet, "/api/v1/auth/me", token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token after logout status = %d, want 401", rec.Code)
+	}
+}
+
+func TestRevokeSessionsRoute(t *testing.T) {
+	s := newTestServer(t)
+	err := s.store.CreateUser(context.Background(), models.User{
+		PhoneNumber: "+919405061351", Name: "Control Room", Rank: "ASI", Department: mapusa, Role: access.RoleControlRoom,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	dysp := s.login(mapusaDYSP)
+	asi := s.login(mapusaASI)
+
+	tests := []struct {
+		name  string
+		token string
+		phone string
+		want  int
+	}{
+		{"without permission", s.login(madgaonPI), mapusaASI, http.StatusForbidden},
+		{"officer with a role", dysp, "+919405061351", http.StatusForbidden},
+		{"unknown officer", dysp, "+919000000000", http.StatusNotFound},
+		{"junior officer", dysp, mapusaASI, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(http.MethodDelete, "/api/v1/users/"+tt.phone+"/sessions", tt.token, nil)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+	if rec := s.do(http.MethodGet, "/api/v1/auth/me", asi, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token after revocation status = %d, want 401", rec.Code)
+	}
+}
+
+func TestUserGrantRoutes(t *testing.T) {
+	s := newTestServer(t)
+	err := s.store.CreateUser(context.Background(), models.User{
+		PhoneNumber: "+919405061351", Name: "Control Room", Rank: "ASI", Department: mapusa, Role: access.RoleControlRoom,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	pi := s.login(madgaonPI)
+	dysp := s.login(mapusaDYSP)
+	asi := s.login(mapusaASI)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		want   int
+	}{
+		{"promote above your own rank", http.MethodPatch, "/api/v1/users/" + mapusaASI, dysp, map[string]string{"role": access.RoleAdmin}, http.StatusForbidden},
+		{"edit an officer with a role", http.MethodPatch, "/api/v1/users/+919405061351", dysp, map[string]string{"name": "CR"}, http.StatusForbidden},
+		{"deactivate an officer with a role", http.MethodPost, "/api/v1/users/+919405061351/deactivate", dysp, nil, http.StatusForbidden},
+		{"delete an officer with a role", http.MethodDelete, "/api/v1/users/+919405061351", dysp, nil, http.StatusForbidden},
+		{"add an officer with a role", http.MethodPost, "/api/v1/users", dysp, models.User{
+			PhoneNumber: "+919000000001", Name: "New", Rank: "ASI", Department: mapusa, Role: access.RoleControlRoom,
+		}, http.StatusForbidden},
+		{"filter by an unknown status", http.MethodGet, "/api/v1/users?status=retired", dysp, nil, http.StatusBadRequest},
+		{"deactivate", http.MethodPost, "/api/v1/users/" + mapusaASI + "/deactivate", dysp, nil, http.StatusOK},
+		{"deactivated officer is logged out", http.MethodGet, "/api/v1/auth/me", asi, nil, http.StatusUnauthorized},
+		{"reactivate", http.MethodPost, "/api/v1/users/" + mapusaASI + "/reactivate", dysp, nil, http.StatusOK},
+		{"no permission to edit", http.MethodPatch, "/api/v1/users/" + madgaonASI, pi, map[string]string{"name": "R"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt.method, tt.path, tt.token, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	var active []models.User
+	expect(t, s.do(http.MethodGet, "/api/v1/users?status=active&department="+url.QueryEscape(mapusa), dysp, nil), http.StatusOK, &active)
+	if len(active) != 3 {
+		t.Errorf("active Mapusa officers = %d, want 3", len(active))
+	}
+}
+
+func TestRosterRoutes(t *testing.T) {
+	s := newTestServer(t)
+	dysp := s.login(mapusaDYSP)
+	upload := func(path, csv string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(csv))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("Authorization", "Bearer "+dysp)
+		return s.serve(req)
+	}
+
+	valid := "phone_number,name,rank,department\n+919000000001,New Officer,ASI," + mapusa + "\n"
+	tests := []struct {
+		name string
+		path string
+		csv  string
+		want int
+	}{
+		{"unknown column", "/api/v1/users/import", "phone,name\n", http.StatusBadRequest},
+		{"invalid row", "/api/v1/users/import", "phone_number,name,rank,department\n+919000000001,,ASI," + mapusa + "\n", http.StatusUnprocessableEntity},
+		{"bad dry_run", "/api/v1/users/import?dry_run=maybe", valid, http.StatusBadRequest},
+		{"dry run", "/api/v1/users/import?dry_run=true", valid, http.StatusOK},
+		{"import", "/api/v1/users/import", valid, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := upload(tt.path, tt.csv); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	rec := s.do(http.MethodGet, "/api/v1/users/export?department="+url.QueryEscape(mapusa), dysp, nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("export status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "+919000000001,New Officer,ASI") {
+		t.Errorf("export does not list the imported officer:\n%s", rec.Body.String())
+	}
+}
+
+func videoIDs(analyses []models.VideoAnalysis) []string {
+	ids := make([]string, len(analyses))
+	for i, a := range analyses {
+		ids[i] = a.VideoID
+	}
+	return ids
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]int{}
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		if seen[s] == 0 {
+			return false
+		}
+		seen[s]--
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package geo
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// AreaSquareMeters returns the polygon's area on a spherical Earth: the
+// outer ring minus its holes.
+func (p Polygon) AreaSquareMeters() float64 {
+	if len(p) == 0 {
+		return 0
+	}
+	area := ringArea(p[0])
+	for _, hole := range p[1:] {
+		area -= ringArea(hole)
+	}
+	return math.Max(area, 0)
+}
+
+func (m MultiPolygon) AreaSquareMeters() float64 {
+	total := 0.0
+	for _, p := range m {
+		total += p.AreaSquareMeters()
+	}
+	return total
+}
+
+// ringArea is the spherical excess approximation from Chamberlain and
+// Duquette, "Some Algorithms for Polygons on a Sphere" (2007). It is exact
+// enough for areas up to a few thousand km².
+func ringArea(ring []Position) float64 {
+	const radius = EarthRadiusKm * 1000
+	sum := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		lon1, lat1 := ring[i].Lon()*math.Pi/180, ring[i].Lat()*math.Pi/180
+		lon2, lat2 := ring[i+1].Lon()*math.Pi/180, ring[i+1].Lat()*math.Pi/180
+		sum += (lon2 - lon1) * (2 + math.Sin(lat1) + math.Sin(lat2))
+	}
+	return math.Abs(sum * radius * radius / 2)
+}
+
+// GeoJSON returns the shape as a GeoJSON MultiPolygon geometry.
+func (m MultiPolygon) GeoJSON() Geometry {
+	coordinates, _ := json.Marshal(m)
+	return Geometry{Type: "MultiPolygon", Coordinates: coordinates}
+}
@@ -0,0 +1,63 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoundingBox is an axis-aligned latitude/longitude rectangle. It does not
+// wrap across the antimeridian.
+type BoundingBox struct {
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+// ParseBoundingBox parses "minLon,minLat,maxLon,maxLat", the GeoJSON bbox
+// order.
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+
+	var vals [4]float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("invalid bbox coordinate %q", p)
+		}
+		vals[i] = v
+	}
+
+	box := BoundingBox{MinLon: vals[0], MinLat: vals[1], MaxLon: vals[2], MaxLat: vals[3]}
+	if err := box.Validate(); err != nil {
+		return BoundingBox{}, err
+	}
+	return box, nil
+}
+
+func (b BoundingBox) Validate() error {
+	if !ValidPoint(b.MinLat, b.MinLon) || !ValidPoint(b.MaxLat, b.MaxLon) {
+		return fmt.Errorf("bbox coordinates out of range")
+	}
+	if b.MinLat > b.MaxLat || b.MinLon > b.MaxLon {
+		return fmt.Errorf("bbox minimum must not exceed maximum")
+	}
+	return nil
+}
+
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+func (b BoundingBox) Center() (lat, lon float64) {
+	return (b.MinLat + b.MaxLat) / 2, (b.MinLon + b.MaxLon) / 2
+}
+
+// DiagonalKm is the distance between opposite corners of the box.
+func (b BoundingBox) DiagonalKm() float64 {
+	return Distance(b.MinLat, b.MinLon, b.MaxLat, b.MaxLon)
+}
@@ -0,0 +1,114 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseBoundingBox(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    BoundingBox
+		wantErr bool
+	}{
+		{"valid", "73.7,15.5,73.8,15.6", BoundingBox{MinLat: 15.5, MinLon: 73.7, MaxLat: 15.6, MaxLon: 73.8}, false},
+		{"spaces", " 73.7, 15.5 ,73.8,15.6", BoundingBox{MinLat: 15.5, MinLon: 73.7, MaxLat: 15.6, MaxLon: 73.8}, false},
+		{"degenerate", "73.7,15.5,73.7,15.5", BoundingBox{MinLat: 15.5, MinLon: 73.7, MaxLat: 15.5, MaxLon: 73.7}, false},
+		{"whole world", "-180,-90,180,90", BoundingBox{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}, false},
+		{"too few values", "73.7,15.5,73.8", BoundingBox{}, true},
+		{"not a number", "73.7,abc,73.8,15.6", BoundingBox{}, true},
+		{"latitude out of range", "73.7,-91,73.8,15.6", BoundingBox{}, true},
+		{"longitude out of range", "73.7,15.5,181,15.6", BoundingBox{}, true},
+		{"minimum above maximum", "73.8,15.5,73.7,15.6", BoundingBox{}, true},
+		{"NaN", "NaN,15.5,73.8,15.6", BoundingBox{}, true},
+		{"infinity", "73.7,15.5,Inf,15.6", BoundingBox{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBoundingBox(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBoundingBox(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBoundingBox(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxValidateRejectsNaN(t *testing.T) {
+	nan := math.NaN()
+	for _, box := range []BoundingBox{
+		{MinLat: nan, MinLon: 0, MaxLat: 1, MaxLon: 1},
+		{MinLat: 0, MinLon: nan, MaxLat: 1, MaxLon: 1},
+		{MinLat: 0, MinLon: 0, MaxLat: nan, MaxLon: 1},
+		{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: nan},
+	} {
+		if err := box.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted NaN", box)
+		}
+	}
+}
+
+func TestValidPoint(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		want     bool
+	}{
+		{15.5, 73.8, true},
+		{-90, -180, true},
+		{90, 180, true},
+		{90.01, 0, false},
+		{0, -180.01, false},
+		{math.NaN(), 0, false},
+		{0, math.NaN(), false},
+		{math.Inf(1), 0, false},
+		{0, math.Inf(-1), false},
+	}
+	for _, tt := range tests {
+		if got := ValidPoint(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("ValidPoint(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestBoundingBoxContains(t *testing.T) {
+	box := BoundingBox{MinLat: 15.5, MinLon: 73.7, MaxLat: 15.6, MaxLon: 73.8}
+	tests := []struct {
+		lat, lon float64
+		want     bool
+	}{
+		{15.55, 73.75, true},
+		{15.5, 73.7, true},
+		{15.6, 73.8, true},
+		{15.49, 73.75, false},
+		{15.55, 73.81, false},
+	}
+	for _, tt := range tests {
+		if got := box.Contains(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		wantKm                 float64
+	}{
+		{"same point", 15.5, 73.8, 15.5, 73.8, 0},
+		{"one degree of latitude", 0, 0, 1, 0, 111.19},
+		{"one degree of longitude at the equator", 0, 0, 0, 1, 111.19},
+		{"Panaji to Margao", 15.4909, 73.8278, 15.2832, 73.9862, 28.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.wantKm) > 0.5 {
+				t.Errorf("Distance = %.2f km, want %.2f km", got, tt.wantKm)
+			}
+		})
+	}
+}
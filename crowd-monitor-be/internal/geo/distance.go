@@ -0,0 +1,31 @@
+// Package geo holds the geographic helpers shared by the storage backends.
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean Earth radius used by the Haversine formula.
+const EarthRadiusKm = 6371
+
+// ValidPoint reports whether lat and lon are finite and within range. The
+// comparisons are written so that NaN fails them.
+func ValidPoint(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// Distance returns distance in kilometers between two points using Haversine formula
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	lon1Rad := lon1 * math.Pi / 180
+	lon2Rad := lon2 * math.Pi / 180
+
+	dlat := lat2Rad - lat1Rad
+	dlon := lon2Rad - lon1Rad
+
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(dlon/2)*math.Sin(dlon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return EarthRadiusKm * c
+}
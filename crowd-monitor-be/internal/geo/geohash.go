@@ -0,0 +1,148 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// GeohashPrecision is the length of the geohash stored on each document,
+// roughly a 5m x 5m cell.
+const GeohashPrecision = 9
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// kmPerDegree is the length of one degree of latitude.
+const kmPerDegree = 111.32
+
+// EncodeGeohash returns the geohash of the point at the given precision.
+func EncodeGeohash(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+
+	var sb strings.Builder
+	bit, ch := 0, 0
+	even := true
+	for sb.Len() < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch |= 1 << (4 - bit)
+				minLon = mid
+			} else {
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+		} else {
+			sb.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// geohashCellSize returns the height and width in degrees of a cell at the
+// given precision.
+func geohashCellSize(precision int) (latDeg, lonDeg float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lonBits))
+}
+
+// GeohashPrefixes returns the geohash cells whose union covers the circle of
+// radiusKm around the point: the cell containing the point plus its eight
+// neighbours, at the finest precision whose cells are at least radiusKm
+// across. Documents inside the circle always have a geohash starting with
+// one of the returned prefixes; callers must still post-filter by Distance.
+func GeohashPrefixes(lat, lon, radiusKm float64) []string {
+	precision := 1
+	for p := GeohashPrecision; p >= 1; p-- {
+		latDeg, lonDeg := geohashCellSize(p)
+		heightKm := latDeg * kmPerDegree
+		widthKm := lonDeg * kmPerDegree * math.Cos(lat*math.Pi/180)
+		if heightKm >= radiusKm && widthKm >= radiusKm {
+			precision = p
+			break
+		}
+	}
+
+	latDeg, lonDeg := geohashCellSize(precision)
+	seen := make(map[string]bool, 9)
+	var prefixes []string
+	for _, dLat := range []float64{-latDeg, 0, latDeg} {
+		for _, dLon := range []float64{-lonDeg, 0, lonDeg} {
+			nLat := math.Max(-90, math.Min(90, lat+dLat))
+			nLon := lon + dLon
+			if nLon > 180 {
+				nLon -= 360
+			} else if nLon < -180 {
+				nLon += 360
+			}
+			hash := EncodeGeohash(nLat, nLon, precision)
+			if !seen[hash] {
+				seen[hash] = true
+				prefixes = append(prefixes, hash)
+			}
+		}
+	}
+	return prefixes
+}
+
+// GeohashPrefixEnd returns the smallest string greater than every geohash
+// that starts with prefix, for use as an exclusive range upper bound.
+func GeohashPrefixEnd(prefix string) string {
+	return prefix + "~"
+}
+
+// maxCoverCells bounds the number of range queries issued for one box.
+const maxCoverCells = 32
+
+// GeohashCover returns geohash cells whose union covers the box, at the
+// finest precision that needs no more than maxCoverCells cells.
+func GeohashCover(box BoundingBox) []string {
+	for p := GeohashPrecision; p >= 1; p-- {
+		latDeg, lonDeg := geohashCellSize(p)
+		n := (math.Floor((box.MaxLat-box.MinLat)/latDeg) + 2) * (math.Floor((box.MaxLon-box.MinLon)/lonDeg) + 2)
+		if p > 1 && n > maxCoverCells {
+			continue
+		}
+		lats := steps(box.MinLat, box.MaxLat, latDeg)
+		lons := steps(box.MinLon, box.MaxLon, lonDeg)
+
+		seen := make(map[string]bool)
+		var cells []string
+		for _, lat := range lats {
+			for _, lon := range lons {
+				hash := EncodeGeohash(lat, lon, p)
+				if !seen[hash] {
+					seen[hash] = true
+					cells = append(cells, hash)
+				}
+			}
+		}
+		return cells
+	}
+	return nil
+}
+
+// steps samples [min, max] every step, always including max, so that every
+// cell of width step overlapping the interval contains a sample.
+func steps(min, max, step float64) []float64 {
+	var out []float64
+	for v := min; v < max; v += step {
+		out = append(out, v)
+	}
+	return append(out, max)
+}
@@ -0,0 +1,126 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	tests := []struct {
+		name      string
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{"reference point", 57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{"truncated", 57.64911, 10.40744, 5, "u4pru"},
+		{"origin", 0, 0, 4, "s000"},
+		{"south west corner", -90, -180, 3, "000"},
+		{"goa", 15.5439, 73.7553, 6, "tdu2qm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodeGeohash(tt.lat, tt.lon, tt.precision); got != tt.want {
+				t.Errorf("EncodeGeohash(%v, %v, %d) = %q, want %q", tt.lat, tt.lon, tt.precision, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeohashPrefixesCoverRadius(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		radiusKm float64
+	}{
+		{"city block", 15.5439, 73.7553, 0.2},
+		{"default radius", 15.5439, 73.7553, 10},
+		{"max radius", 15.5439, 73.7553, 50},
+		{"near the antimeridian", -17.7, 179.99, 5},
+		{"high latitude", 69.6, 18.9, 5},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes := GeohashPrefixes(tt.lat, tt.lon, tt.radiusKm)
+			if len(prefixes) == 0 || len(prefixes) > 9 {
+				t.Fatalf("got %d prefixes, want 1-9", len(prefixes))
+			}
+			for i := 0; i < 500; i++ {
+				lat, lon := randomPointWithin(rng, tt.lat, tt.lon, tt.radiusKm)
+				hash := EncodeGeohash(lat, lon, GeohashPrecision)
+				if !hasAnyPrefix(hash, prefixes) {
+					t.Fatalf("point (%v, %v) %.3f km away has geohash %s outside %v",
+						lat, lon, Distance(tt.lat, tt.lon, lat, lon), hash, prefixes)
+				}
+			}
+		})
+	}
+}
+
+func TestGeohashCoverContainsBox(t *testing.T) {
+	tests := []struct {
+		name string
+		box  BoundingBox
+	}{
+		{"small box", BoundingBox{MinLat: 15.54, MinLon: 73.75, MaxLat: 15.55, MaxLon: 73.76}},
+		{"city", BoundingBox{MinLat: 15.2, MinLon: 73.7, MaxLat: 15.7, MaxLon: 74.1}},
+		{"degenerate point", BoundingBox{MinLat: 15.5, MinLon: 73.8, MaxLat: 15.5, MaxLon: 73.8}},
+		{"across the equator", BoundingBox{MinLat: -0.3, MinLon: 32.4, MaxLat: 0.3, MaxLon: 32.9}},
+	}
+	rng := rand.New(rand.NewSource(2))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cells := GeohashCover(tt.box)
+			if len(cells) == 0 {
+				t.Fatal("no cells")
+			}
+			for i := 0; i < 500; i++ {
+				lat := tt.box.MinLat + rng.Float64()*(tt.box.MaxLat-tt.box.MinLat)
+				lon := tt.box.MinLon + rng.Float64()*(tt.box.MaxLon-tt.box.MinLon)
+				hash := EncodeGeohash(lat, lon, GeohashPrecision)
+				if !hasAnyPrefix(hash, cells) {
+					t.Fatalf("point (%v, %v) has geohash %s outside %v", lat, lon, hash, cells)
+				}
+			}
+		})
+	}
+}
+
+func TestGeohashPrefixEnd(t *testing.T) {
+	end := GeohashPrefixEnd("tdu2")
+	for _, hash := range []string{"tdu2", "tdu20", "tdu2zzzzz"} {
+		if hash >= end {
+			t.Errorf("%q is not below the range end %q", hash, end)
+		}
+	}
+	if "tdu3" < end {
+		t.Errorf("%q is inside the range ending at %q", "tdu3", end)
+	}
+}
+
+// randomPointWithin returns a point at most radiusKm from the center.
+func randomPointWithin(rng *rand.Rand, lat, lon, radiusKm float64) (float64, float64) {
+	for {
+		dLat := (rng.Float64()*2 - 1) * radiusKm / kmPerDegree
+		dLon := (rng.Float64()*2 - 1) * radiusKm / (kmPerDegree * math.Cos(lat*math.Pi/180))
+		pLat, pLon := lat+dLat, lon+dLon
+		if pLon > 180 {
+			pLon -= 360
+		}
+		if Distance(lat, lon, pLat, pLon) <= radiusKm {
+			return pLat, pLon
+		}
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
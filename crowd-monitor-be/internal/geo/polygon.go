@@ -0,0 +1,167 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Position is a GeoJSON position: [longitude, latitude].
+type Position [2]float64
+
+func (p Position) Lon() float64 { return p[0] }
+func (p Position) Lat() float64 { return p[1] }
+
+// Polygon is a GeoJSON polygon: an outer ring followed by optional holes.
+// Each ring is closed (first position equals the last).
+type Polygon [][]Position
+
+// MultiPolygon is a set of polygons; a point is inside when any contains it.
+type MultiPolygon []Polygon
+
+// Geometry is the subset of GeoJSON geometries accepted by the area queries.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ParseGeometry decodes a GeoJSON Polygon or MultiPolygon, or a Feature
+// wrapping one, and validates its rings.
+func ParseGeometry(raw []byte) (MultiPolygon, error) {
+	var probe struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    *Geometry       `json:"geometry"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+
+	g := Geometry{Type: probe.Type, Coordinates: probe.Coordinates}
+	if probe.Type == "Feature" {
+		if probe.Geometry == nil {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		g = *probe.Geometry
+	}
+
+	var shape MultiPolygon
+	switch g.Type {
+	case "Polygon":
+		var p Polygon
+		if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("invalid polygon coordinates: %v", err)
+		}
+		shape = MultiPolygon{p}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &shape); err != nil {
+			return nil, fmt.Errorf("invalid multipolygon coordinates: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q, expected Polygon or MultiPolygon", g.Type)
+	}
+
+	if err := shape.Validate(); err != nil {
+		return nil, err
+	}
+	return shape, nil
+}
+
+func (m MultiPolygon) Validate() error {
+	if len(m) == 0 {
+		return fmt.Errorf("geometry has no polygons")
+	}
+	for i, p := range m {
+		if len(p) == 0 {
+			return fmt.Errorf("polygon %d has no rings", i)
+		}
+		for j, ring := range p {
+			if len(ring) < 4 {
+				return fmt.Errorf("polygon %d ring %d needs at least 4 positions", i, j)
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return fmt.Errorf("polygon %d ring %d is not closed", i, j)
+			}
+			for _, pos := range ring {
+				if !ValidPoint(pos.Lat(), pos.Lon()) {
+					return fmt.Errorf("polygon %d ring %d has a position out of range", i, j)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Contains reports whether the point lies inside the polygon's outer ring
+// and outside all of its holes.
+func (p Polygon) Contains(lat, lon float64) bool {
+	if len(p) == 0 || !ringContains(p[0], lat, lon) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, lat, lon) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m MultiPolygon) Contains(lat, lon float64) bool {
+	for _, p := range m {
+		if p.Contains(lat, lon) {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains is the even-odd ray casting test, treating coordinates as
+// planar. That is accurate enough for the city-scale areas we work with.
+func ringContains(ring []Position, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		yi, yj := ring[i].Lat(), ring[j].Lat()
+		xi, xj := ring[i].Lon(), ring[j].Lon()
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func (m MultiPolygon) BoundingBox() BoundingBox {
+	box := BoundingBox{MinLat: 90, MinLon: 180, MaxLat: -90, MaxLon: -180}
+	for _, p := range m {
+		if len(p) == 0 {
+			continue
+		}
+		for _, pos := range p[0] {
+			box.MinLat = math.Min(box.MinLat, pos.Lat())
+			box.MaxLat = math.Max(box.MaxLat, pos.Lat())
+			box.MinLon = math.Min(box.MinLon, pos.Lon())
+			box.MaxLon = math.Max(box.MaxLon, pos.Lon())
+		}
+	}
+	return box
+}
+
+// Centroid returns the average of the outer ring vertices, used as the
+// default reference point when sorting results by distance.
+func (m MultiPolygon) Centroid() (lat, lon float64) {
+	n := 0
+	for _, p := range m {
+		if len(p) == 0 {
+			continue
+		}
+		ring := p[0][:len(p[0])-1]
+		for _, pos := range ring {
+			lat += pos.Lat()
+			lon += pos.Lon()
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return lat / float64(n), lon / float64(n)
+}
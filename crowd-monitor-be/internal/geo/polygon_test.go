@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"testing"
+)
+
+// square is a closed ring around (lat, lon) with the given half-size in
+// degrees.
+func square(lat, lon, half float64) []Position {
+	return []Position{
+		{lon - half, lat - half},
+		{lon + half, lat - half},
+		{lon + half, lat + half},
+		{lon - half, lat + half},
+		{lon - half, lat - half},
+	}
+}
+
+func TestParseGeometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		polygons int
+		wantErr  bool
+	}{
+		{"polygon", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`, 1, false},
+		{"multipolygon", `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[2,2],[3,2],[3,3],[2,2]]]]}`, 2, false},
+		{"feature", `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}}`, 1, false},
+		{"feature without geometry", `{"type":"Feature"}`, 0, true},
+		{"point", `{"type":"Point","coordinates":[0,0]}`, 0, true},
+		{"open ring", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, 0, true},
+		{"too few positions", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`, 0, true},
+		{"out of range", `{"type":"Polygon","coordinates":[[[0,0],[181,0],[1,1],[0,0]]]}`, 0, true},
+		{"no polygons", `{"type":"MultiPolygon","coordinates":[]}`, 0, true},
+		{"not JSON", `{`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGeometry([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGeometry error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.polygons {
+				t.Errorf("got %d polygons, want %d", len(got), tt.polygons)
+			}
+		})
+	}
+}
+
+func TestMultiPolygonContains(t *testing.T) {
+	withHole := Polygon{square(15.5, 73.8, 0.1), square(15.5, 73.8, 0.02)}
+	shape := MultiPolygon{withHole, {square(16, 74, 0.05)}}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"inside the ring", 15.45, 73.75, true},
+		{"inside the hole", 15.5, 73.8, false},
+		{"outside", 15.7, 73.8, false},
+		{"inside the second polygon", 16.01, 74.01, true},
+		{"between the polygons", 15.8, 73.9, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shape.Contains(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPolygonBoundingBoxAndCentroid(t *testing.T) {
+	shape := MultiPolygon{{square(15.5, 73.8, 0.1)}}
+
+	box := shape.BoundingBox()
+	want := BoundingBox{MinLat: 15.4, MinLon: 73.7, MaxLat: 15.6, MaxLon: 73.9}
+	if !near(box.MinLat, want.MinLat) || !near(box.MinLon, want.MinLon) || !near(box.MaxLat, want.MaxLat) || !near(box.MaxLon, want.MaxLon) {
+		t.Errorf("BoundingBox() = %+v, want %+v", box, want)
+	}
+
+	lat, lon := shape.Centroid()
+	if !near(lat, 15.5) || !near(lon, 73.8) {
+		t.Errorf("Centroid() = (%v, %v), want (15.5, 73.8)", lat, lon)
+	}
+}
+
+func near(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
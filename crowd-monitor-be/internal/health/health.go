@@ -0,0 +1,178 @@
+// Package health runs the dependency checks behind the readiness probe.
+// Results are cached so frequent probes from several load balancers do not
+// turn into a query per probe against Firestore or Twilio.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/logging"
+)
+
+// Overall and per-check statuses.
+const (
+	StatusOK          = "ok"
+	StatusFailing     = "failing"
+	StatusDegraded    = "degraded"    // a non-critical check is failing
+	StatusUnavailable = "unavailable" // a critical check is failing
+)
+
+// CheckFunc returns nil when the dependency is usable.
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs float64   `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of every check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+
+	mu      sync.Mutex
+	result  Result
+	pending chan struct{} // closed when the running check finishes
+}
+
+// Checker runs registered checks, reusing a result for ttl after it was
+// taken. Checks run concurrently and each is bounded by timeout.
+type Checker struct {
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+
+	mu     sync.Mutex
+	checks []*check
+}
+
+func NewChecker(ttl, timeout time.Duration) *Checker {
+	return &Checker{ttl: ttl, timeout: timeout, now: time.Now}
+}
+
+// Add registers a check. A failing critical check makes the instance
+// unavailable; a failing non-critical one only degrades it, for
+// dependencies such as Twilio that every instance shares, where taking
+// instances out of rotation would not help.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, &check{name: name, critical: critical, fn: fn})
+}
+
+// Check returns the current report, running the checks whose cached
+// result has expired. Concurrent callers share a running check.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]*check(nil), c.checks...)
+	c.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, chk := range checks {
+		result := results[i]
+		report.Checks[chk.name] = result
+		switch {
+		case result.Status == StatusOK:
+		case chk.critical:
+			report.Status = StatusUnavailable
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, chk *check) Result {
+	chk.mu.Lock()
+	if !chk.result.CheckedAt.IsZero() && c.now().Sub(chk.result.CheckedAt) < c.ttl {
+		result := chk.result
+		chk.mu.Unlock()
+		return result
+	}
+	if pending := chk.pending; pending != nil {
+		chk.mu.Unlock()
+		select {
+		case <-pending:
+		case <-ctx.Done():
+			return Result{Status: StatusFailing, Critical: chk.critical, Error: ctx.Err().Error(), CheckedAt: c.now()}
+		}
+		chk.mu.Lock()
+		defer chk.mu.Unlock()
+		return chk.result
+	}
+	pending := make(chan struct{})
+	chk.pending = pending
+	chk.mu.Unlock()
+
+	// The check outlives a probe that gives up early so its result can
+	// still be cached for the next one.
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+	start := c.now()
+	// Clients that take no context, like Twilio's, cannot be cancelled;
+	// such a call is abandoned rather than waited for.
+	errc := make(chan error, 1)
+	go func() { errc <- chk.fn(checkCtx) }()
+	var err error
+	select {
+	case err = <-errc:
+	case <-checkCtx.Done():
+		err = checkCtx.Err()
+	}
+	result := Result{
+		Status:    StatusOK,
+		Critical:  chk.critical,
+		LatencyMs: float64(c.now().Sub(start).Microseconds()) / 1000,
+		CheckedAt: c.now(),
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("timed out after " + c.timeout.String())
+		}
+		result.Status = StatusFailing
+		result.Error = logging.Mask(err.Error())
+	}
+
+	chk.mu.Lock()
+	chk.result, chk.pending = result, nil
+	chk.mu.Unlock()
+	close(pending)
+	return result
+}
+
+// Running fails once done is closed, for background goroutines that should
+// run until shutdown.
+func Running(done <-chan struct{}) CheckFunc {
+	return func(context.Context) error {
+		select {
+		case <-done:
+			return errors.New("stopped")
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckStatus(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("unreachable +919405061349") }
+
+	tests := []struct {
+		name        string
+		critical    CheckFunc
+		noncritical CheckFunc
+		want        string
+	}{
+		{"all ok", ok, ok, StatusOK},
+		{"non-critical failing", ok, fail, StatusDegraded},
+		{"critical failing", fail, ok, StatusUnavailable},
+		{"both failing", fail, fail, StatusUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(time.Minute, time.Second)
+			c.Add("firestore", true, tt.critical)
+			c.Add("twilio", false, tt.noncritical)
+			report := c.Check(context.Background())
+			if report.Status != tt.want || len(report.Checks) != 2 {
+				t.Errorf("report = %+v, want status %s", report, tt.want)
+			}
+			if r := report.Checks["firestore"]; !r.Critical || (r.Status == StatusFailing) != (r.Error != "") {
+				t.Errorf("firestore result = %+v", r)
+			}
+		})
+	}
+
+	c := NewChecker(time.Minute, time.Second)
+	c.Add("firestore", true, fail)
+	if got := c.Check(context.Background()).Checks["firestore"].Error; got != "unreachable +91******1349" {
+		t.Errorf("error = %q, want the phone number masked", got)
+	}
+}
+
+func TestCheckCaches(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	c := NewChecker(10*time.Second, time.Second)
+	c.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	var calls atomic.Int32
+	c.Add("firestore", true, func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	for _, step := range []struct {
+		after time.Duration
+		calls int32
+	}{
+		{0, 1},
+		{5 * time.Second, 1},
+		{5 * time.Second, 2},
+	} {
+		mu.Lock()
+		now = now.Add(step.after)
+		mu.Unlock()
+		c.Check(context.Background())
+		if got := calls.Load(); got != step.calls {
+			t.Errorf("after %s: %d calls, want %d", step.after, got, step.calls)
+		}
+	}
+}
+
+func TestCheckSharesRunningCheck(t *testing.T) {
+	c := NewChecker(time.Minute, time.Second)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	c.Add("firestore", true, func(context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Check(context.Background())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("concurrent probes ran the check %d times, want once", n)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	c := NewChecker(time.Minute, 20*time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	c.Add("twilio", false, func(context.Context) error {
+		<-block // ignores its context like the Twilio client
+		return nil
+	})
+
+	report := c.Check(context.Background())
+	if r := report.Checks["twilio"]; r.Status != StatusFailing || r.Error != "timed out after 20ms" {
+		t.Errorf("result = %+v, want a timeout", r)
+	}
+	if report.Status != StatusDegraded {
+		t.Errorf("status = %s, want degraded", report.Status)
+	}
+}
+
+func TestRunning(t *testing.T) {
+	done := make(chan struct{})
+	check := Running(done)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("Running before done = %v", err)
+	}
+	close(done)
+	if err := check(context.Background()); err == nil {
+		t.Error("Running after done succeeded")
+	}
+}
@@ -0,0 +1,181 @@
+// Package logging configures the process-wide slog logger. Lines are
+// leveled JSON (or text), carry the request and trace IDs of the context
+// they were logged with, have secrets redacted and phone numbers masked.
+// Output from the standard log package goes through the same logger.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configure Setup.
+type Options struct {
+	Level  string // debug, info, warn or error
+	Format string // FormatJSON or FormatText
+	// File, when set, receives the same lines as stdout and is rotated
+	// once it reaches MaxSize bytes or MaxAge. Rotated files are removed
+	// after Retention; zero keeps them.
+	File      string
+	MaxSize   int64
+	MaxAge    time.Duration
+	Retention time.Duration
+}
+
+// Setup installs the logger as slog's and the log package's default. The
+// returned closer flushes and closes the log file.
+func Setup(opts Options) (io.Closer, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", opts.Level)
+	}
+
+	var out io.Writer = os.Stdout
+	var closer io.Closer = nopCloser{}
+	if opts.File != "" {
+		file, err := OpenRotatingFile(opts.File, opts.MaxSize, opts.MaxAge, opts.Retention)
+		if err != nil {
+			return nil, err
+		}
+		out, closer = io.MultiWriter(os.Stdout, file), file
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+	var handler slog.Handler
+	switch opts.Format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	case FormatText:
+		handler = slog.NewTextHandler(out, handlerOpts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected %s or %s", opts.Format, FormatJSON, FormatText)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log lines carry id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and the current trace and span IDs of
+// the context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+const redacted = "[REDACTED]"
+
+var (
+	// secretKeys are attribute keys whose values are never logged.
+	secretKeys = map[string]bool{
+		"otp": true, "otp_code": true, "code": true, "api_key": true,
+		"device_key": true, "private_key": true, "cookie": true,
+	}
+	// secretKeyParts redact any key containing them.
+	secretKeyParts = []string{"token", "secret", "password", "authorization", "auth_token"}
+
+	phoneNumber = regexp.MustCompile(`\+[1-9][0-9]{6,14}`)
+	jwtToken    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearer      = regexp.MustCompile(`(?i)bearer\s+\S+`)
+)
+
+// redact is the ReplaceAttr hook: it blanks secret attributes and masks
+// phone numbers and tokens inside every string, the message included.
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if isSecretKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Mask(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, Mask(v.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, Mask(v.String()))
+		}
+	}
+	return a
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if secretKeys[key] {
+		return true
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask hides tokens in s and all but the last four digits of phone
+// numbers, e.g. +91******1349.
+func Mask(s string) string {
+	if !strings.ContainsAny(s, "+eEbB") {
+		return s
+	}
+	s = bearer.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtToken.ReplaceAllString(s, redacted)
+	return phoneNumber.ReplaceAllStringFunc(s, MaskPhone)
+}
+
+// MaskPhone keeps the country code prefix and last four digits of an E.164
+// number.
+func MaskPhone(phone string) string {
+	if len(phone) < 8 {
+		return phone
+	}
+	return phone[:3] + strings.Repeat("*", len(phone)-7) + phone[len(phone)-4:]
+}
@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files, e.g. api-2025-06-01T10-30-00.000.log.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile appends to a log file and moves it aside, with the rotation
+// time in its name, once it would grow past maxSize bytes or has been open
+// for maxAge. Rotated files older than retention are deleted. Zero values
+// disable the respective limit.
+type RotatingFile struct {
+	path      string
+	maxSize   int64
+	maxAge    time.Duration
+	retention time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// OpenRotatingFile opens path for appending, creating it and its directory
+// when missing.
+func OpenRotatingFile(path string, maxSize int64, maxAge, retention time.Duration) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:      path,
+		maxSize:   maxSize,
+		maxAge:    maxAge,
+		retention: retention,
+		now:       time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	f.removeExpired()
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	tooLarge := f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize
+	tooOld := f.maxAge > 0 && f.now().Sub(f.openedAt) >= f.maxAge
+	if tooLarge || tooOld {
+		if err := f.rotate(); err != nil {
+			// Keep logging to the current file rather than losing lines.
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %v", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.openedAt = file, info.Size(), f.now()
+	return nil
+}
+
+// rotate renames the current file and opens a fresh one. f.mu must be held.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(f.path)
+	backup := strings.TrimSuffix(f.path, ext) + "-" + f.now().Format(backupTimeFormat) + ext
+	renameErr := os.Rename(f.path, backup)
+	if err := f.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	go f.removeExpired()
+	return nil
+}
+
+// removeExpired deletes rotated files older than the retention period.
+func (f *RotatingFile) removeExpired() {
+	if f.retention <= 0 {
+		return
+	}
+	ext := filepath.Ext(f.path)
+	backups, err := filepath.Glob(strings.TrimSuffix(f.path, ext) + "-*" + ext)
+	if err != nil {
+		return
+	}
+	cutoff := f.now().Add(-f.retention)
+	for _, backup := range backups {
+		info, err := os.Stat(backup)
+		if err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(backup)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "api.log")
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	// A backup older than the retention period is removed on open
+	old := filepath.Join(dir, "logs", "api-2026-01-01T00-00-00.000.log")
+	if err := os.MkdirAll(filepath.Dir(old), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Chtimes(old, now.AddDate(0, -1, 0), now.AddDate(0, -1, 0)); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	f, err := OpenRotatingFile(path, 0, 0, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	f.Close()
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expired backup still exists: %v", err)
+	}
+
+	// Without retention rotation does not start a cleanup that would read
+	// the test clock concurrently
+	f, err = OpenRotatingFile(path, 11, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer f.Close()
+	f.now = func() time.Time { return now }
+	f.openedAt = now
+
+	write := func(s string) {
+		t.Helper()
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	write("12345\n")
+	write("1234\n") // fills the file to its limit
+	now = now.Add(time.Second)
+	write("next\n") // rotates by size
+	now = now.Add(time.Hour)
+	write("later\n") // rotates by age
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "logs", "api-*.log"))
+	var names []string
+	for _, backup := range backups {
+		names = append(names, filepath.Base(backup))
+	}
+	want := []string{"api-2026-10-18T09-00-01.000.log", "api-2026-10-18T10-00-01.000.log"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("backups = %v, want %v", names, want)
+	}
+	for file, content := range map[string]string{
+		want[0]:   "12345\n1234\n",
+		want[1]:   "next\n",
+		"api.log": "later\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, "logs", file))
+		if err != nil || string(got) != content {
+			t.Errorf("%s = %q, %v, want %q", file, got, err, content)
+		}
+	}
+
+	f.Close()
+	if _, err := f.Write([]byte("closed\n")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the Prometheus text exposition format, version 0.0.4.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteText writes every registered metric in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	out := bufio.NewWriter(w)
+	for _, f := range families {
+		name, help, kind := f.describe()
+		out.WriteString("# HELP " + name + " " + helpEscaper.Replace(help) + "\n")
+		out.WriteString("# TYPE " + name + " " + kind + "\n")
+		for _, s := range f.collect() {
+			out.WriteString(name + s.suffix)
+			if len(s.labels) > 0 {
+				out.WriteByte('{')
+				for i, label := range s.labels {
+					if i > 0 {
+						out.WriteByte(',')
+					}
+					out.WriteString(label + `="` + labelEscaper.Replace(s.values[i]) + `"`)
+				}
+				out.WriteByte('}')
+			}
+			out.WriteString(" " + formatFloat(s.value) + "\n")
+		}
+	}
+	return out.Flush()
+}
+
+// Handler serves the registry. When token is set, requests must carry it
+// as a bearer token.
+func Handler(r *Registry, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" {
+			given, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,276 @@
+// Package metrics keeps counters, gauges and histograms in process memory
+// and serves them in the Prometheus text exposition format. Metrics are
+// declared as package variables next to the code they measure and register
+// themselves with Default.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are histogram bounds in seconds suited to request latencies.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default is the registry the New* functions register with.
+var Default = NewRegistry()
+
+var validName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Registry holds metric families in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// family is one named metric with its series.
+type family interface {
+	describe() (name, help, kind string)
+	collect() []sample
+}
+
+type sample struct {
+	suffix string // appended to the family name, e.g. "_bucket"
+	labels []string
+	values []string
+	value  float64
+}
+
+// register panics on an invalid or duplicate name; metrics are declared at
+// init time, so this is a programming error.
+func (r *Registry) register(f family) {
+	name, _, _ := f.describe()
+	if !validName.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// vec keeps one series per combination of label values.
+type vec struct {
+	name, help string
+	labels     []string
+	buckets    int // histogram buckets per series, excluding +Inf
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is a counter or gauge value, or a histogram.
+type series struct {
+	values []string
+	value  float64
+	counts []uint64 // per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+func newVec(name, help string, labels []string) vec {
+	for _, label := range labels {
+		if !validName.MatchString(label) || label == "le" {
+			panic(fmt.Sprintf("metrics: invalid label %q on %s", label, name))
+		}
+	}
+	return vec{name: name, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+// with returns the series for values, creating it on first use. v.mu must
+// be held.
+func (v *vec) with(values []string) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if v.buckets > 0 {
+			s.counts = make([]uint64, v.buckets+1)
+		}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by label values so output is stable.
+// v.mu must be held.
+func (v *vec) sorted() []*series {
+	all := make([]*series, 0, len(v.series))
+	for _, s := range v.series {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return strings.Join(all[i].values, "\xff") < strings.Join(all[j].values, "\xff")
+	})
+	return all
+}
+
+// samples returns one sample per series. v.mu must be held.
+func (v *vec) samples() []sample {
+	var samples []sample
+	for _, s := range v.sorted() {
+		samples = append(samples, sample{labels: v.labels, values: s.values, value: s.value})
+	}
+	return samples
+}
+
+// CounterVec counts events, partitioned by label values.
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec registers a counter with Default. Counter names end in
+// _total by convention.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, labels)}
+	Default.register(c)
+	return c
+}
+
+// Inc adds one to the series with the given label values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds delta, which must not be negative.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: %s cannot decrease", c.name))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.with(values).value += delta
+}
+
+func (c *CounterVec) describe() (string, string, string) { return c.name, c.help, "counter" }
+
+func (c *CounterVec) collect() []sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.samples()
+}
+
+// GaugeVec holds values that go up and down, partitioned by label values.
+type GaugeVec struct {
+	vec
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, labels)}
+	Default.register(g)
+	return g
+}
+
+func (g *GaugeVec) Set(value float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.with(values).value = value
+}
+
+func (g *GaugeVec) Add(delta float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.with(values).value += delta
+}
+
+func (g *GaugeVec) Inc(values ...string) { g.Add(1, values...) }
+
+func (g *GaugeVec) Dec(values ...string) { g.Add(-1, values...) }
+
+func (g *GaugeVec) describe() (string, string, string) { return g.name, g.help, "gauge" }
+
+func (g *GaugeVec) collect() []sample {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.samples()
+}
+
+// GaugeFunc is a gauge whose value is computed on every scrape.
+type GaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+// NewGaugeFunc registers a gauge read from fn, which is called
+// concurrently by scrapes and should return quickly.
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, fn: fn}
+	Default.register(g)
+	return g
+}
+
+func (g *GaugeFunc) describe() (string, string, string) { return g.name, g.help, "gauge" }
+
+func (g *GaugeFunc) collect() []sample {
+	return []sample{{value: g.fn()}}
+}
+
+// HistogramVec counts observations into cumulative buckets, partitioned by
+// label values.
+type HistogramVec struct {
+	vec
+	bounds []float64
+}
+
+// NewHistogramVec registers a histogram with the given upper bounds, which
+// must be sorted ascending. DefBuckets suits durations in seconds.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 || !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: buckets of %s are empty or not sorted", name))
+	}
+	h := &HistogramVec{vec: newVec(name, help, labels), bounds: append([]float64(nil), buckets...)}
+	h.vec.buckets = len(buckets)
+	Default.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	i := sort.SearchFloat64s(h.bounds, value) // first bound >= value
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.with(values)
+	s.counts[i]++
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) describe() (string, string, string) { return h.name, h.help, "histogram" }
+
+func (h *HistogramVec) collect() []sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	labels := append(append([]string(nil), h.labels...), "le")
+	var samples []sample
+	for _, s := range h.sorted() {
+		var cumulative uint64
+		for i, count := range s.counts {
+			cumulative += count
+			bound := math.Inf(1)
+			if i < len(h.bounds) {
+				bound = h.bounds[i]
+			}
+			values := append(append([]string(nil), s.values...), formatFloat(bound))
+			samples = append(samples, sample{suffix: "_bucket", labels: labels, values: values, value: float64(cumulative)})
+		}
+		samples = append(samples,
+			sample{suffix: "_sum", labels: h.labels, values: s.values, value: s.sum},
+			sample{suffix: "_count", labels: h.labels, values: s.values, value: float64(s.count)},
+		)
+	}
+	return samples
+}
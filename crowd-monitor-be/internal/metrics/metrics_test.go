@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := &CounterVec{newVec("http_requests_total", "Requests by method\nand status.", []string{"method", "status"})}
+	r.register(requests)
+	inFlight := &GaugeVec{newVec("in_flight", `Requests in "flight".`, nil)}
+	r.register(inFlight)
+	uptime := &GaugeFunc{name: "uptime_seconds", help: "Uptime.", fn: func() float64 { return math.Inf(1) }}
+	r.register(uptime)
+	latency := &HistogramVec{vec: newVec("latency_seconds", "Latency.", []string{"route"}), bounds: []float64{0.1, 1}}
+	latency.vec.buckets = 2
+	r.register(latency)
+
+	requests.Inc("POST", "201")
+	requests.Add(2, "GET", "200")
+	requests.Inc("GET", `a"b\c`)
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+	latency.Observe(0.05, "/x")
+	latency.Observe(0.1, "/x")
+	latency.Observe(3, "/x")
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	want := `# HELP http_requests_total Requests by method\nand status.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",status="200"} 2
+http_requests_total{method="GET",status="a\"b\\c"} 1
+http_requests_total{method="POST",status="201"} 1
+# HELP in_flight Requests in "flight".
+# TYPE in_flight gauge
+in_flight 1
+# HELP uptime_seconds Uptime.
+# TYPE uptime_seconds gauge
+uptime_seconds +Inf
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/x",le="0.1"} 2
+latency_seconds_bucket{route="/x",le="1"} 2
+latency_seconds_bucket{route="/x",le="+Inf"} 3
+latency_seconds_sum{route="/x"} 3.15
+latency_seconds_count{route="/x"} 3
+`
+	if out.String() != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRegisterRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(r *Registry)
+	}{
+		{"invalid name", func(r *Registry) { r.register(&CounterVec{newVec("bad-name", "", nil)}) }},
+		{"duplicate name", func(r *Registry) {
+			r.register(&CounterVec{newVec("dup_total", "", nil)})
+			r.register(&GaugeVec{newVec("dup_total", "", nil)})
+		}},
+		{"reserved label", func(r *Registry) { newVec("x", "", []string{"le"}) }},
+		{"wrong label count", func(r *Registry) {
+			c := &CounterVec{newVec("x_total", "", []string{"a"})}
+			c.Inc()
+		}},
+		{"negative counter", func(r *Registry) {
+			c := &CounterVec{newVec("x_total", "", nil)}
+			c.Add(-1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			tt.fn(NewRegistry())
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"open", "", "", http.StatusOK},
+		{"no token", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer guess", http.StatusUnauthorized},
+		{"not bearer", "s3cret", "s3cret", http.StatusUnauthorized},
+		{"token", "s3cret", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			Handler(r, tt.token).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Code == http.StatusOK && rec.Header().Get("Content-Type") != ContentType {
+				t.Errorf("Content-Type = %q", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Alert statuses.
+const (
+	AlertStatusOpen         = "open"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// AlertRule fires when an analysis meets every condition that is set.
+type AlertRule struct {
+	ID      string `json:"id" firestore:"id"`
+	Name    string `json:"name" firestore:"name"`
+	Enabled bool   `json:"enabled" firestore:"enabled"`
+
+	// Conditions; zero values are ignored.
+	CrowdLevels               []string   `json:"crowd_levels" firestore:"crowd_levels"`
+	MinCrowdCount             float64    `json:"min_crowd_count" firestore:"min_crowd_count"`
+	RequirePeakHour           bool       `json:"require_peak_hour" firestore:"require_peak_hour"`
+	RequirePoliceIntervention bool       `json:"require_police_intervention" firestore:"require_police_intervention"`
+	Area                      *AlertArea `json:"area,omitempty" firestore:"area"`
+	// ZoneIDs matches analyses inside any of the zones.
+	ZoneIDs []string `json:"zone_ids,omitempty" firestore:"zone_ids"`
+
+	// Department whose officers are alerted, within NotifyRadiusKm of the
+	// analysis when officer locations are known.
+	Department      string  `json:"department" firestore:"department"`
+	NotifyRadiusKm  float64 `json:"notify_radius_km" firestore:"notify_radius_km"`
+	CooldownSeconds int     `json:"cooldown_seconds" firestore:"cooldown_seconds"`
+
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// AlertArea restricts a rule to analyses within RadiusKm of a point.
+type AlertArea struct {
+	Latitude  float64 `json:"latitude" firestore:"latitude"`
+	Longitude float64 `json:"longitude" firestore:"longitude"`
+	RadiusKm  float64 `json:"radius_km" firestore:"radius_km"`
+}
+
+// Alert is one hotspot flagged by a rule. Repeated matches at the same
+// hotspot are folded into it until it is resolved.
+type Alert struct {
+	ID         string   `json:"id" firestore:"id"`
+	RuleID     string   `json:"rule_id" firestore:"rule_id"`
+	RuleName   string   `json:"rule_name" firestore:"rule_name"`
+	DedupKey   string   `json:"dedup_key" firestore:"dedup_key"`
+	Status     string   `json:"status" firestore:"status"`
+	Department string   `json:"department" firestore:"department"`
+	Location   GeoPoint `json:"location" firestore:"location"`
+	CameraID   string   `json:"camera_id,omitempty" firestore:"camera_id"`
+	CrowdLevel string   `json:"crowd_level" firestore:"crowd_level"`
+	CrowdCount string   `json:"crowd_count" firestore:"crowd_count"`
+	// VideoIDs holds the most recent matching analyses, newest last.
+	VideoIDs       []string  `json:"video_ids" firestore:"video_ids"`
+	Occurrences    int       `json:"occurrences" firestore:"occurrences"`
+	NotifiedPhones []string  `json:"notified_phones" firestore:"notified_phones"`
+	IncidentID     string    `json:"incident_id,omitempty" firestore:"incident_id"`
+	CreatedAt      time.Time `json:"created_at" firestore:"created_at"`
+	LastTriggered  time.Time `json:"last_triggered_at" firestore:"last_triggered_at"`
+	LastNotified   time.Time `json:"last_notified_at" firestore:"last_notified_at"`
+	UpdatedBy      string    `json:"updated_by,omitempty" firestore:"updated_by"`
+	UpdatedAt      time.Time `json:"updated_at" firestore:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Camera administrative statuses. Only active cameras are checked for
+// staleness.
+const (
+	CameraStatusActive         = "active"
+	CameraStatusMaintenance    = "maintenance"
+	CameraStatusDecommissioned = "decommissioned"
+)
+
+// Camera health as last set by the stale-camera checker or a heartbeat.
+const (
+	CameraHealthOnline = "online"
+	CameraHealthStale  = "stale"
+)
+
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude" firestore:"latitude"`
+	Longitude float64 `json:"longitude" firestore:"longitude"`
+}
+
+type Camera struct {
+	ID              string    `json:"id" firestore:"id"`
+	Name            string    `json:"name" firestore:"name"`
+	Location        GeoPoint  `json:"location" firestore:"location"`
+	Department      string    `json:"department" firestore:"department"`
+	Status          string    `json:"status" firestore:"status"`
+	Health          string    `json:"health" firestore:"health"`
+	LastHeartbeatAt time.Time `json:"last_heartbeat_at" firestore:"last_heartbeat_at"`
+	LastAnalysisAt  time.Time `json:"last_analysis_at" firestore:"last_analysis_at"`
+	CreatedAt       time.Time `json:"created_at" firestore:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// LastSeen is the later of the last heartbeat and the last analysis.
+func (c Camera) LastSeen() time.Time {
+	if c.LastAnalysisAt.After(c.LastHeartbeatAt) {
+		return c.LastAnalysisAt
+	}
+	return c.LastHeartbeatAt
+}
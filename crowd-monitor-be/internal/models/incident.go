@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// Incident statuses, in lifecycle order.
+const (
+	IncidentStatusOpen         = "open"
+	IncidentStatusAcknowledged = "acknowledged"
+	IncidentStatusDispatched   = "dispatched"
+	IncidentStatusResolved     = "resolved"
+	IncidentStatusClosed       = "closed"
+)
+
+// Incident timeline event types.
+const (
+	IncidentEventCreated        = "created"
+	IncidentEventStatusChanged  = "status_changed"
+	IncidentEventAssigned       = "assigned"
+	IncidentEventNote           = "note"
+	IncidentEventAnalysesLinked = "analyses_linked"
+)
+
+// incidentTransitions lists the statuses each status may move to. Active
+// incidents can be resolved directly (false alarms) and a resolved incident
+// can be reopened until it is closed.
+var incidentTransitions = map[string][]string{
+	IncidentStatusOpen:         {IncidentStatusAcknowledged, IncidentStatusResolved},
+	IncidentStatusAcknowledged: {IncidentStatusDispatched, IncidentStatusResolved},
+	IncidentStatusDispatched:   {IncidentStatusResolved},
+	IncidentStatusResolved:     {IncidentStatusClosed, IncidentStatusOpen},
+	IncidentStatusClosed:       nil,
+}
+
+// CanTransitionIncident reports whether an incident may move from one
+// status to another.
+func CanTransitionIncident(from, to string) bool {
+	for _, next := range incidentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIncidentStatus reports whether s is a known incident status.
+func IsIncidentStatus(s string) bool {
+	_, ok := incidentTransitions[s]
+	return ok
+}
+
+type Incident struct {
+	ID          string    `json:"id" firestore:"id"`
+	Title       string    `json:"title" firestore:"title"`
+	Description string    `json:"description" firestore:"description"`
+	Status      string    `json:"status" firestore:"status"`
+	Department  string    `json:"department" firestore:"department"`
+	Location    *GeoPoint `json:"location,omitempty" firestore:"location"`
+	VideoIDs    []string  `json:"video_ids" firestore:"video_ids"`
+	// AlertID is set for incidents opened automatically by an alert.
+	AlertID    string `json:"alert_id,omitempty" firestore:"alert_id"`
+	AssignedTo *User  `json:"assigned_to,omitempty" firestore:"assigned_to"`
+	// Timeline records notes and every change, oldest first.
+	Timeline   []IncidentEvent `json:"timeline" firestore:"timeline"`
+	CreatedBy  string          `json:"created_by" firestore:"created_by"`
+	CreatedAt  time.Time       `json:"created_at" firestore:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" firestore:"updated_at"`
+	ResolvedAt time.Time       `json:"resolved_at" firestore:"resolved_at"`
+	ClosedAt   time.Time       `json:"closed_at" firestore:"closed_at"`
+}
+
+// IncidentEvent is one timeline entry. Actor is the phone number of the
+// officer responsible, or "system" for automatic changes.
+type IncidentEvent struct {
+	Time       time.Time `json:"time" firestore:"time"`
+	Actor      string    `json:"actor" firestore:"actor"`
+	Type       string    `json:"type" firestore:"type"`
+	Message    string    `json:"message,omitempty" firestore:"message"`
+	FromStatus string    `json:"from_status,omitempty" firestore:"from_status"`
+	ToStatus   string    `json:"to_status,omitempty" firestore:"to_status"`
+	VideoIDs   []string  `json:"video_ids,omitempty" firestore:"video_ids"`
+	AssignedTo string    `json:"assigned_to,omitempty" firestore:"assigned_to"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestCanTransitionIncident(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{IncidentStatusOpen, IncidentStatusAcknowledged}:       true,
+		{IncidentStatusOpen, IncidentStatusResolved}:           true,
+		{IncidentStatusAcknowledged, IncidentStatusDispatched}: true,
+		{IncidentStatusAcknowledged, IncidentStatusResolved}:   true,
+		{IncidentStatusDispatched, IncidentStatusResolved}:     true,
+		{IncidentStatusResolved, IncidentStatusClosed}:         true,
+		{IncidentStatusResolved, IncidentStatusOpen}:           true,
+	}
+	statuses := []string{IncidentStatusOpen, IncidentStatusAcknowledged, IncidentStatusDispatched,
+		IncidentStatusResolved, IncidentStatusClosed, "unknown"}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransitionIncident(from, to); got != want {
+				t.Errorf("CanTransitionIncident(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestIsIncidentStatus(t *testing.T) {
+	for _, s := range []string{IncidentStatusOpen, IncidentStatusClosed} {
+		if !IsIncidentStatus(s) {
+			t.Errorf("IsIncidentStatus(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "Open", "cancelled"} {
+		if IsIncidentStatus(s) {
+			t.Errorf("IsIncidentStatus(%q) = true", s)
+		}
+	}
+}
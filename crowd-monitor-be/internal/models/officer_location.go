@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// OfficerLocation is the latest location ping from an officer's app.
+type OfficerLocation struct {
+	PhoneNumber string  `json:"phone_number" firestore:"phone_number"`
+	Name        string  `json:"name" firestore:"name"`
+	Rank        string  `json:"rank" firestore:"rank"`
+	Department  string  `json:"department" firestore:"department"`
+	Latitude    float64 `json:"latitude" firestore:"latitude"`
+	Longitude   float64 `json:"longitude" firestore:"longitude"`
+	AccuracyM   float64 `json:"accuracy_m,omitempty" firestore:"accuracy_m"`
+	// Available is false while the officer is off duty or busy.
+	Available bool `json:"available" firestore:"available"`
+	// RecordedAt is the device's fix time and ReceivedAt when the server
+	// stored the ping; freshness is judged on ReceivedAt.
+	RecordedAt time.Time `json:"recorded_at" firestore:"recorded_at"`
+	ReceivedAt time.Time `json:"received_at" firestore:"received_at"`
+}
+
+// Officer returns the user snapshot carried by the ping.
+func (l OfficerLocation) Officer() User {
+	return User{
+		PhoneNumber: l.PhoneNumber,
+		Name:        l.Name,
+		Rank:        l.Rank,
+		Department:  l.Department,
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// OTPCode is a one-time login code issued by the local OTP provider. Only a
+// salted SHA-256 digest of the code is stored.
+type OTPCode struct {
+	PhoneNumber string    `json:"phone_number" firestore:"phone_number"`
+	Hash        string    `json:"-" firestore:"hash"`
+	Salt        string    `json:"-" firestore:"salt"`
+	CreatedAt   time.Time `json:"created_at" firestore:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" firestore:"expires_at"`
+}
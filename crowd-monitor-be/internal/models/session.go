@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Session is one login of an officer, kept alive by rotating refresh
+// tokens. Only SHA-256 digests of the refresh tokens are stored.
+type Session struct {
+	ID          string `json:"id" firestore:"id"`
+	PhoneNumber string `json:"phone_number" firestore:"phone_number"`
+	TokenHash   string `json:"-" firestore:"token_hash"`
+	// PreviousHash is the digest of the refresh token rotated out last;
+	// presenting it again means the token was copied.
+	PreviousHash string    `json:"-" firestore:"previous_hash"`
+	CreatedAt    time.Time `json:"created_at" firestore:"created_at"`
+	RefreshedAt  time.Time `json:"refreshed_at" firestore:"refreshed_at"`
+	ExpiresAt    time.Time `json:"expires_at" firestore:"expires_at"`
+	Revoked      bool      `json:"revoked" firestore:"revoked"`
+	RevokedAt    time.Time `json:"revoked_at" firestore:"revoked_at"`
+}
+
+// Active reports whether the session can still be refreshed at now.
+func (s Session) Active(now time.Time) bool {
+	return !s.Revoked && now.Before(s.ExpiresAt)
+}
+
+// Revocation kinds.
+const (
+	// RevocationSession rejects the access tokens of one session.
+	RevocationSession = "session"
+	// RevocationUser rejects every access token of an officer issued
+	// before the second of RevokedAt.
+	RevocationUser = "user"
+)
+
+// Revocation is an entry in the list of rejected access tokens. It is kept
+// until ExpiresAt, by when every token it covers has expired anyway.
+type Revocation struct {
+	ID        string    `json:"id" firestore:"id"`
+	Kind      string    `json:"kind" firestore:"kind"`
+	Subject   string    `json:"subject" firestore:"subject"` // session ID or phone number
+	RevokedAt time.Time `json:"revoked_at" firestore:"revoked_at"`
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"`
+}
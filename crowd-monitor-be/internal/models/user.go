@@ -1,11 +1,57 @@
 package models
 
+import (
+	"regexp"
+	"time"
+)
+
 type User struct {
-	PhoneNumber string `json:"phone_number" firestore:"phone_number"`
-	Name        string `json:"name" firestore:"name"`
-	Rank        string `json:"rank" firestore:"rank"`
-	Department  string `json:"department" firestore:"department"`
-	IDCardNumber string `json:"id_card_number" firestore:"id_card_number"`
+	PhoneNumber  string `json:"phone_number" firestore:"phone_number"` // E.164, e.g. +919876543210
+	Name         string `json:"name" firestore:"name"`
+	Rank         string `json:"rank" firestore:"rank"`
+	Department   string `json:"department" firestore:"department"`
+	IDCardNumber string `json:"id_card_number" firestore:"id_card_number"` // unique when set
+	Role         string `json:"role,omitempty" firestore:"role"`           // optional, see access.Role*
+	// Deactivated officers keep their record but cannot log in.
+	Deactivated   bool       `json:"deactivated" firestore:"deactivated"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty" firestore:"deactivated_at,omitempty"`
+}
+
+// UserUpdate is a partial update of a user; nil fields are left unchanged.
+// The phone number identifies the user and cannot be changed.
+type UserUpdate struct {
+	Name         *string `json:"name"`
+	Rank         *string `json:"rank"`
+	Department   *string `json:"department"`
+	IDCardNumber *string `json:"id_card_number"`
+	Role         *string `json:"role"`
+}
+
+// Apply copies the set fields onto user.
+func (u UserUpdate) Apply(user *User) {
+	if u.Name != nil {
+		user.Name = *u.Name
+	}
+	if u.Rank != nil {
+		user.Rank = *u.Rank
+	}
+	if u.Department != nil {
+		user.Department = *u.Department
+	}
+	if u.IDCardNumber != nil {
+		user.IDCardNumber = *u.IDCardNumber
+	}
+	if u.Role != nil {
+		user.Role = *u.Role
+	}
+}
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// IsE164 reports whether phoneNumber is in E.164 form: a plus sign and up
+// to 15 digits, the first of them not zero.
+func IsE164(phoneNumber string) bool {
+	return e164.MatchString(phoneNumber)
 }
 
 type OTPRequest struct {
@@ -17,7 +63,16 @@ type OTPVerifyRequest struct {
 	OTPCode     string `json:"otp_code" binding:"required"`
 }
 
+// AuthResponse is returned by login and refresh. Token is the access
+// token; RefreshToken is single use and replaced on every refresh.
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
-}
\ No newline at end of file
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
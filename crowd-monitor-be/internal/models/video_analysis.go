@@ -1,11 +1,16 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
 
 type Location struct {
-	Latitude  float64 `firestore:"latitude"`
-	Longitude float64 `firestore:"longitude"`
-	Timestamp string  `firestore:"timestamp"` // Change to string
+	Latitude  float64 `json:"latitude" firestore:"latitude"`
+	Longitude float64 `json:"longitude" firestore:"longitude"`
+	Timestamp string  `json:"timestamp" firestore:"timestamp"` // Change to string
 }
 
 type Analysis struct {
@@ -18,11 +23,51 @@ type Analysis struct {
 }
 
 type VideoAnalysis struct {
-	VideoID       string    `firestore:"video_id"`
+	VideoID       string    `json:"video_id" firestore:"video_id"`
 	VideoDuration float64   `json:"video_duration"`
-	Timestamp     time.Time `firestore:"timestamp"` // Change to time.Time
-	CreatedAt     time.Time `json:"created_at"`     // Change to time.Time
-	Location      Location  `firestore:"location"`
-	Analysis      Analysis  `firestore:"analysis"`
-	FrameURLs     []string  `firestore:"frame_urls"`
+	Timestamp     time.Time `json:"timestamp" firestore:"timestamp"` // Change to time.Time
+	CreatedAt     time.Time `json:"created_at"`                      // Change to time.Time
+	Location      Location  `json:"location" firestore:"location"`
+	Analysis      Analysis  `json:"analysis" firestore:"analysis"`
+	FrameURLs     []string  `json:"frame_urls" firestore:"frame_urls"`
+	Geohash       string    `json:"geohash,omitempty" firestore:"geohash"` // derived from Location at write time
+	CameraID      string    `json:"camera_id,omitempty" firestore:"camera_id"`
+	ZoneIDs       []string  `json:"zone_ids,omitempty" firestore:"zone_ids"`     // zones containing Location, set at ingestion
+	Department    string    `json:"department,omitempty" firestore:"department"` // owning department, set at ingestion
+}
+
+// isAffirmative interprets the free-text yes/no values the analyzer writes.
+func isAffirmative(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y", "true", "1":
+		return true
+	}
+	return false
+}
+
+func (a Analysis) InterventionRequired() bool {
+	return isAffirmative(a.PoliceInterventionRequired)
+}
+
+func (a Analysis) PeakHour() bool {
+	return isAffirmative(a.IsPeakHour)
+}
+
+// Count extracts the first number in CrowdCount, which the analyzer may
+// write as "40", "40.0" or "approximately 40 people".
+func (a Analysis) Count() (float64, bool) {
+	s := a.CrowdCount
+	start := strings.IndexFunc(s, unicode.IsDigit)
+	if start < 0 {
+		return 0, false
+	}
+	end := start
+	for end < len(s) && (unicode.IsDigit(rune(s[end])) || s[end] == '.') {
+		end++
+	}
+	n, err := strconv.ParseFloat(strings.TrimSuffix(s[start:end], "."), 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
 }
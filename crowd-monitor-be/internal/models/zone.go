@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+)
+
+// Zone crowd levels reported by zone status.
+const (
+	ZoneLevelUnknown  = "unknown"
+	ZoneLevelNormal   = "normal"
+	ZoneLevelWarning  = "warning"
+	ZoneLevelCritical = "critical"
+)
+
+// Zone is a named place, such as a beach or market, that analyses are
+// grouped under.
+type Zone struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Department string `json:"department"`
+	// Geometry is a GeoJSON MultiPolygon.
+	Geometry         geo.Geometry `json:"geometry"`
+	AreaSquareMeters float64      `json:"area_m2"`
+	// WarningCapacity and SafeCapacity are headcounts: the zone is at
+	// warning level from WarningCapacity people and critical above
+	// SafeCapacity. Zero disables the threshold.
+	WarningCapacity int       `json:"warning_capacity"`
+	SafeCapacity    int       `json:"safe_capacity"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Shape parses the zone's geometry.
+func (z Zone) Shape() (geo.MultiPolygon, error) {
+	raw, err := json.Marshal(z.Geometry)
+	if err != nil {
+		return nil, err
+	}
+	return geo.ParseGeometry(raw)
+}
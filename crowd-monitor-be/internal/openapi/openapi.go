@@ -0,0 +1,200 @@
+// Package openapi holds the OpenAPI 3 description of the /api/v1 routes and
+// validates requests and responses against it. The document in
+// openapi.json is maintained by hand alongside the handlers and served
+// as-is at /openapi.json.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Validation modes selectable through OPENAPI_VALIDATION.
+const (
+	ValidationOff      = "off"
+	ValidationRequests = "requests" // reject requests that do not match with 400 or 415
+	ValidationTest     = "test"     // also check responses, answering 500 when one does not match
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Spec returns the embedded document.
+func Spec() []byte {
+	return spec
+}
+
+// Document is the part of an OpenAPI 3.0 document the validator reads.
+// Schemas, parameters and responses may be $refs into the components.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components struct {
+		Schemas    map[string]*Schema    `json:"schemas"`
+		Parameters map[string]*Parameter `json:"parameters"`
+		Responses  map[string]*Response  `json:"responses"`
+	} `json:"components"`
+
+	operations map[string]*Operation // by method and Gin route, see Operation
+}
+
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Delete     *Operation   `json:"delete"`
+	Patch      *Operation   `json:"patch"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path, query or header
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+	// ValidatedByHandler leaves the body to the handler, for ingestion,
+	// which reports invalid analyses per item with its own status codes.
+	ValidatedByHandler bool `json:"x-validated-by-handler"`
+}
+
+type Response struct {
+	Ref     string                `json:"$ref"`
+	Content map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Load parses the embedded document.
+func Load() (*Document, error) {
+	return Parse(spec)
+}
+
+// Parse reads an OpenAPI document and resolves its $refs.
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %v", err)
+	}
+
+	for name, s := range doc.Components.Schemas {
+		if err := doc.resolveSchema(s); err != nil {
+			return nil, fmt.Errorf("schema %s: %v", name, err)
+		}
+	}
+	doc.operations = make(map[string]*Operation)
+	for path, item := range doc.Paths {
+		for method, op := range map[string]*Operation{
+			"GET": item.Get, "PUT": item.Put, "POST": item.Post, "DELETE": item.Delete, "PATCH": item.Patch,
+		} {
+			if op == nil {
+				continue
+			}
+			if err := doc.resolveOperation(op, item.Parameters); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", method, path, err)
+			}
+			doc.operations[method+" "+ginRoute(path)] = op
+		}
+	}
+	return &doc, nil
+}
+
+// Operation returns the operation for a Gin route such as
+// /api/v1/cameras/:cameraId, or nil when the document does not describe it.
+func (d *Document) Operation(method, route string) *Operation {
+	return d.operations[method+" "+route]
+}
+
+var templateParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// ginRoute turns /cameras/{cameraId} into /cameras/:cameraId.
+func ginRoute(path string) string {
+	return templateParam.ReplaceAllString(path, ":$1")
+}
+
+// resolveOperation resolves the operation's refs and adds the path-level
+// parameters it does not override.
+func (d *Document) resolveOperation(op *Operation, shared []*Parameter) error {
+	params := make([]*Parameter, 0, len(shared)+len(op.Parameters))
+	seen := make(map[string]bool)
+	for _, list := range [][]*Parameter{op.Parameters, shared} {
+		for _, p := range list {
+			p, err := d.resolveParameter(p)
+			if err != nil {
+				return err
+			}
+			if key := p.In + " " + p.Name; !seen[key] {
+				seen[key] = true
+				params = append(params, p)
+			}
+		}
+	}
+	op.Parameters = params
+
+	if op.RequestBody != nil {
+		for _, media := range op.RequestBody.Content {
+			if err := d.resolveSchema(media.Schema); err != nil {
+				return err
+			}
+		}
+	}
+	for status, r := range op.Responses {
+		if r.Ref != "" {
+			name, err := componentName(r.Ref, "responses")
+			if err != nil {
+				return err
+			}
+			if r = d.Components.Responses[name]; r == nil {
+				return fmt.Errorf("unknown response %s", name)
+			}
+			op.Responses[status] = r
+		}
+		for _, media := range r.Content {
+			if err := d.resolveSchema(media.Schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Document) resolveParameter(p *Parameter) (*Parameter, error) {
+	if p.Ref != "" {
+		name, err := componentName(p.Ref, "parameters")
+		if err != nil {
+			return nil, err
+		}
+		if p = d.Components.Parameters[name]; p == nil {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+	if p.Schema == nil {
+		p.Schema = &Schema{Type: "string"}
+	}
+	return p, d.resolveSchema(p.Schema)
+}
+
+func componentName(ref, kind string) (string, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if !ok {
+		return "", fmt.Errorf("unsupported $ref %q, expected #/components/%s/...", ref, kind)
+	}
+	return name, nil
+}
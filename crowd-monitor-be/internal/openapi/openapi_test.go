@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testDoc describes one route with a shared path parameter, a referenced
+// query parameter, a JSON body and a referenced error response.
+const testDoc = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/api/v1/cameras/{cameraId}": {
+      "parameters": [{"$ref": "#/components/parameters/cameraId"}],
+      "put": {
+        "operationId": "updateCamera",
+        "parameters": [
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 100}},
+          {"name": "active", "in": "query", "schema": {"type": "boolean"}}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Camera"}}}
+        },
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Camera"}}}},
+          "204": {},
+          "4XX": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "get": {
+        "operationId": "getCameraSnapshot",
+        "responses": {"200": {"content": {"image/jpeg": {}}}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "cameraId": {"name": "cameraId", "in": "path", "required": true, "schema": {"type": "string", "pattern": "^[a-z0-9-]+$"}}
+    },
+    "responses": {
+      "Error": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+    },
+    "schemas": {
+      "Camera": {
+        "type": "object",
+        "required": ["name"],
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "latitude": {"type": "number", "minimum": -90, "maximum": 90}
+        }
+      },
+      "Error": {
+        "type": "object",
+        "required": ["success", "error"],
+        "properties": {"success": {"type": "boolean"}, "error": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	op := doc.Operation("PUT", "/api/v1/cameras/:cameraId")
+	if op == nil || op.OperationID != "updateCamera" {
+		t.Fatalf("Operation(PUT) = %+v, want updateCamera", op)
+	}
+	if len(op.Parameters) != 3 || op.Parameters[2].Name != "cameraId" {
+		t.Errorf("parameters = %+v, want the shared cameraId after the operation's own", op.Parameters)
+	}
+	if doc.Operation("DELETE", "/api/v1/cameras/:cameraId") != nil {
+		t.Error("Operation found an undocumented method")
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"invalid JSON", `{`, "invalid OpenAPI document"},
+		{"unknown schema", `{"components": {"schemas": {"A": {"$ref": "#/components/schemas/B"}}}}`, "unknown schema B"},
+		{"external ref", `{"components": {"schemas": {"A": {"$ref": "other.json#/B"}}}}`, "unsupported $ref"},
+		{"invalid pattern", `{"components": {"schemas": {"A": {"type": "string", "pattern": "("}}}}`, "invalid pattern"},
+		{"unknown parameter", `{"paths": {"/a": {"get": {"parameters": [{"$ref": "#/components/parameters/x"}]}}}}`, "unknown parameter x"},
+		{"unknown response", `{"paths": {"/a": {"get": {"responses": {"200": {"$ref": "#/components/responses/x"}}}}}}`, "unknown response x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("the embedded document does not parse: %v", err)
+	}
+	if doc.Operation("GET", "/api/v1/cameras/:cameraId") == nil {
+		t.Error("the embedded document does not describe GET /api/v1/cameras/:cameraId")
+	}
+
+	// MAX_QUERY_RADIUS_KM is configurable, so the handler checks the cap
+	nearby := doc.Operation("GET", "/api/v1/video-analyses/nearby")
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/video-analyses/nearby?latitude=15.5&longitude=73.8&radius_km=120", nil)
+	if err := nearby.ValidateRequest(r, nil); err != nil {
+		t.Errorf("a radius above the default cap was rejected: %v", err)
+	}
+}
+
+func TestGinRoute(t *testing.T) {
+	if got := ginRoute("/api/v1/cameras/{cameraId}/heartbeat"); got != "/api/v1/cameras/:cameraId/heartbeat" {
+		t.Errorf("ginRoute = %s", got)
+	}
+}
@@ -0,0 +1,259 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jimil-28/crowd-monitor/internal/schema"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object the document uses.
+// Keywords outside it, such as description and example, are ignored.
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Enum                 []interface{}      `json:"enum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"` // false or a schema
+	Items                *Schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum"`
+	OneOf                []*Schema          `json:"oneOf"`
+	AllOf                []*Schema          `json:"allOf"`
+
+	target     *Schema // what Ref points to
+	additional *Schema
+	closed     bool // additionalProperties: false
+	pattern    *regexp.Regexp
+	resolved   bool
+}
+
+// resolveSchema links $refs and compiles patterns throughout s.
+func (d *Document) resolveSchema(s *Schema) error {
+	if s == nil || s.resolved {
+		return nil
+	}
+	s.resolved = true
+
+	if s.Ref != "" {
+		name, err := componentName(s.Ref, "schemas")
+		if err != nil {
+			return err
+		}
+		if s.target = d.Components.Schemas[name]; s.target == nil {
+			return fmt.Errorf("unknown schema %s", name)
+		}
+		return d.resolveSchema(s.target)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	switch raw := strings.TrimSpace(string(s.AdditionalProperties)); raw {
+	case "", "true":
+	case "false":
+		s.closed = true
+	default:
+		s.additional = &Schema{}
+		if err := json.Unmarshal(s.AdditionalProperties, s.additional); err != nil {
+			return fmt.Errorf("invalid additionalProperties: %v", err)
+		}
+	}
+
+	children := []*Schema{s.Items, s.additional}
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	children = append(children, s.OneOf...)
+	children = append(children, s.AllOf...)
+	for _, child := range children {
+		if err := d.resolveSchema(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks a value decoded by encoding/json against s, naming
+// fields from path, e.g. "body.location.latitude".
+func (s *Schema) Validate(path string, v interface{}) []schema.FieldError {
+	var errs []schema.FieldError
+	s.validate(path, v, &errs)
+	return errs
+}
+
+func (s *Schema) validate(path string, v interface{}, errs *[]schema.FieldError) {
+	if s.target != nil {
+		s.target.validate(path, v, errs)
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, schema.FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		if !s.Nullable && s.Type != "" {
+			fail("must not be null")
+		}
+		return
+	}
+	for _, sub := range s.AllOf {
+		sub.validate(path, v, errs)
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if len(sub.Validate(path, v)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("must match exactly one of %d schemas, matched %d", len(s.OneOf), matched)
+		}
+	}
+	if len(s.Enum) > 0 && !s.allows(v) {
+		fail("must be one of %s", formatEnum(s.Enum))
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		s.validateObject(path, obj, errs)
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		length := utf8.RuneCountInString(str)
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			fail("must match %s", s.Pattern)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				fail("must be an RFC3339 timestamp")
+			}
+		}
+	case "number", "integer":
+		n, ok := v.(float64)
+		if !ok {
+			fail("must be a number")
+			return
+		}
+		if s.Type == "integer" && n != float64(int64(n)) {
+			fail("must be an integer")
+		}
+		if min := s.Minimum; min != nil && (n < *min || s.ExclusiveMinimum && n == *min) {
+			fail("must be %s %g", boundWord("at least", "greater than", s.ExclusiveMinimum), *min)
+		}
+		if max := s.Maximum; max != nil && (n > *max || s.ExclusiveMaximum && n == *max) {
+			fail("must be %s %g", boundWord("at most", "less than", s.ExclusiveMaximum), *max)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("must be a boolean")
+		}
+	}
+}
+
+func (s *Schema) validateObject(path string, obj map[string]interface{}, errs *[]schema.FieldError) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			*errs = append(*errs, schema.FieldError{Field: path + "." + name, Message: "is required"})
+		}
+	}
+	// Sorted so errors come out in a stable order
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch prop, ok := s.Properties[name]; {
+		case ok:
+			prop.validate(path+"."+name, obj[name], errs)
+		case s.closed:
+			*errs = append(*errs, schema.FieldError{Field: path + "." + name, Message: "is not allowed"})
+		case s.additional != nil:
+			s.additional.validate(path+"."+name, obj[name], errs)
+		}
+	}
+}
+
+func (s *Schema) allows(v interface{}) bool {
+	for _, allowed := range s.Enum {
+		if allowed == v {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func boundWord(inclusive, exclusive string, isExclusive bool) string {
+	if isExclusive {
+		return exclusive
+	}
+	return inclusive
+}
+
+// kind is the schema's type with refs followed.
+func (s *Schema) kind() string {
+	if s.target != nil {
+		return s.target.kind()
+	}
+	return s.Type
+}
@@ -0,0 +1,77 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	const schemas = `{
+	  "Zone": {
+	    "type": "object",
+	    "required": ["name", "level"],
+	    "additionalProperties": false,
+	    "properties": {
+	      "name": {"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[a-z]+$"},
+	      "level": {"type": "string", "enum": ["low", "high"]},
+	      "capacity": {"type": "integer", "minimum": 0, "exclusiveMinimum": true},
+	      "density": {"type": "number", "maximum": 1},
+	      "tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}},
+	      "note": {"type": "string", "nullable": true},
+	      "since": {"type": "string", "format": "date-time"},
+	      "open": {"type": "boolean"},
+	      "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+	      "area": {"oneOf": [{"type": "string"}, {"type": "number"}]},
+	      "parent": {"$ref": "#/components/schemas/Ref"}
+	    }
+	  },
+	  "Ref": {"type": "object", "required": ["id"], "properties": {"id": {"type": "string"}}}
+	}`
+	doc, err := Parse([]byte(`{"components": {"schemas": ` + schemas + `}}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	zone := doc.Components.Schemas["Zone"]
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"valid", `{"name": "gate", "level": "high", "capacity": 10, "density": 0.5, "tags": ["a"], "note": null,
+			"since": "2026-10-18T09:00:00Z", "open": true, "labels": {"a": "b"}, "area": 3, "parent": {"id": "z1"}}`, "[]"},
+		{"not an object", `[]`, "[body: must be an object]"},
+		{"missing required", `{}`, "[body.name: is required body.level: is required]"},
+		{"extra property", `{"name": "gate", "level": "low", "x": 1}`, "[body.x: is not allowed]"},
+		{"enum", `{"name": "gate", "level": "Low"}`, "[body.level: must be one of low, high]"},
+		{"string bounds", `{"name": "g", "level": "low"}`, "[body.name: must be at least 2 characters]"},
+		{"pattern", `{"name": "Gate", "level": "low"}`, "[body.name: must match ^[a-z]+$]"},
+		{"exclusive minimum", `{"name": "gate", "level": "low", "capacity": 0}`, "[body.capacity: must be greater than 0]"},
+		{"integer", `{"name": "gate", "level": "low", "capacity": 1.5}`, "[body.capacity: must be an integer]"},
+		{"maximum", `{"name": "gate", "level": "low", "density": 2}`, "[body.density: must be at most 1]"},
+		{"too many items", `{"name": "gate", "level": "low", "tags": ["a", "b", "c"]}`, "[body.tags: must have at most 2 items]"},
+		{"item type", `{"name": "gate", "level": "low", "tags": [1]}`, "[body.tags[0]: must be a string]"},
+		{"null", `{"name": null, "level": "low"}`, "[body.name: must not be null]"},
+		{"date-time", `{"name": "gate", "level": "low", "since": "yesterday"}`, "[body.since: must be an RFC3339 timestamp]"},
+		{"boolean", `{"name": "gate", "level": "low", "open": "yes"}`, "[body.open: must be a boolean]"},
+		{"additional schema", `{"name": "gate", "level": "low", "labels": {"a": 1}}`, "[body.labels.a: must be a string]"},
+		{"one of", `{"name": "gate", "level": "low", "area": true}`, "[body.area: must match exactly one of 2 schemas, matched 0]"},
+		{"ref", `{"name": "gate", "level": "low", "parent": {}}`, "[body.parent.id: is required]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+			var got []string
+			for _, e := range zone.Validate("body", value) {
+				got = append(got, e.Field+": "+e.Message)
+			}
+			if s := fmt.Sprint(got); s != tt.want {
+				t.Errorf("Validate = %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jimil-28/crowd-monitor/internal/schema"
+)
+
+// MaxValidatedBody caps the JSON request bodies read for validation.
+// Larger ones are passed on unchecked for the handler to limit.
+const MaxValidatedBody = 10 << 20
+
+// RequestError is a request the document does not allow.
+type RequestError struct {
+	Status  int // 400, or 415 for an undocumented Content-Type
+	Message string
+	Fields  []schema.FieldError
+}
+
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+// ValidateRequest checks the path, query and header parameters and the
+// JSON body of r. The body is read and replaced so the handler can read it
+// again. It returns a *RequestError when r does not match.
+func (op *Operation) ValidateRequest(r *http.Request, pathParams map[string]string) error {
+	var errs []schema.FieldError
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var raw string
+		switch p.In {
+		case "path":
+			raw = pathParams[p.Name]
+		case "query":
+			raw = query.Get(p.Name)
+		case "header":
+			raw = r.Header.Get(p.Name)
+		default:
+			continue
+		}
+		// Handlers treat empty parameters as absent
+		if raw == "" {
+			if p.Required {
+				errs = append(errs, schema.FieldError{Field: p.In + "." + p.Name, Message: "is required"})
+			}
+			continue
+		}
+		value, err := parseParameter(raw, p.Schema.kind())
+		if err != nil {
+			errs = append(errs, schema.FieldError{Field: p.In + "." + p.Name, Message: err.Error()})
+			continue
+		}
+		errs = append(errs, p.Schema.Validate(p.In+"."+p.Name, value)...)
+	}
+
+	if body := op.RequestBody; body != nil && !body.ValidatedByHandler {
+		bodyErrs, err := body.validate(r)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, bodyErrs...)
+	}
+	if len(errs) > 0 {
+		return &RequestError{Status: http.StatusBadRequest, Message: "Request does not match the API specification", Fields: errs}
+	}
+	return nil
+}
+
+func (b *RequestBody) validate(r *http.Request) ([]schema.FieldError, error) {
+	contentType := mediaType(r.Header.Get("Content-Type"))
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	media, ok := b.Content[contentType]
+	if !ok {
+		if r.ContentLength == 0 && !b.Required {
+			return nil, nil
+		}
+		return nil, &RequestError{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: fmt.Sprintf("Content-Type %s is not supported, expected %s", contentType, strings.Join(sortedKeys(b.Content), " or ")),
+		}
+	}
+	if !isJSON(contentType) || media.Schema == nil {
+		return nil, nil
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(r.Body, MaxValidatedBody+1))
+	if err != nil {
+		return nil, &RequestError{Status: http.StatusBadRequest, Message: fmt.Sprintf("Failed to read request body: %v", err)}
+	}
+	if len(raw) > MaxValidatedBody {
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(raw), r.Body), r.Body}
+		return nil, nil
+	}
+	r.Body = readCloser{bytes.NewReader(raw), r.Body}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		if b.Required {
+			return []schema.FieldError{{Field: "body", Message: "is required"}}, nil
+		}
+		return nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return []schema.FieldError{{Field: "body", Message: fmt.Sprintf("must be valid JSON: %v", err)}}, nil
+	}
+	return media.Schema.Validate("body", value), nil
+}
+
+// ValidateResponse checks a JSON response body against the response
+// documented for its status.
+func (op *Operation) ValidateResponse(status int, header http.Header, body []byte) []schema.FieldError {
+	resp := op.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		resp = op.Responses[fmt.Sprintf("%dXX", status/100)]
+	}
+	if resp == nil {
+		resp = op.Responses["default"]
+	}
+	if resp == nil {
+		return []schema.FieldError{{Field: "response", Message: fmt.Sprintf("status %d is not documented", status)}}
+	}
+	if len(resp.Content) == 0 {
+		return nil
+	}
+
+	contentType := mediaType(header.Get("Content-Type"))
+	media, ok := resp.Content[contentType]
+	if !ok {
+		return []schema.FieldError{{Field: "response", Message: fmt.Sprintf("Content-Type %q is not documented for status %d", contentType, status)}}
+	}
+	if !isJSON(contentType) || media.Schema == nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []schema.FieldError{{Field: "response", Message: fmt.Sprintf("must be valid JSON: %v", err)}}
+	}
+	return media.Schema.Validate("response", value)
+}
+
+// JSONResponses reports whether every successful response is JSON, which
+// rules out streams, WebSocket upgrades and file downloads. Only such
+// responses can be buffered and checked.
+func (op *Operation) JSONResponses() bool {
+	for status, resp := range op.Responses {
+		if status == "default" || status[0] > '2' {
+			continue
+		}
+		if status[0] == '1' {
+			return false
+		}
+		for contentType := range resp.Content {
+			if !isJSON(contentType) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseParameter converts a path, query or header value to the JSON type
+// its schema declares.
+func parseParameter(raw, kind string) (interface{}, error) {
+	switch kind {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		// NaN would pass every minimum and maximum
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+func mediaType(contentType string) string {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.TrimSpace(strings.ToLower(contentType))
+	}
+	return media
+}
+
+func isJSON(media string) bool {
+	return media == "application/json" || strings.HasSuffix(media, "+json")
+}
+
+func sortedKeys(m map[string]*MediaType) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readCloser reads a replayed body and closes the original.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
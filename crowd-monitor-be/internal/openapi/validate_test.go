@@ -0,0 +1,135 @@
+package openapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testOperation(t *testing.T, method string) *Operation {
+	t.Helper()
+	doc, err := Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return doc.Operation(method, "/api/v1/cameras/:cameraId")
+}
+
+func TestValidateRequest(t *testing.T) {
+	op := testOperation(t, "PUT")
+
+	tests := []struct {
+		name        string
+		cameraID    string
+		query       string
+		contentType string
+		body        string
+		wantStatus  int
+		wantField   string
+	}{
+		{"valid", "cam-1", "?limit=10&active=true", "application/json", `{"name": "Gate"}`, 0, ""},
+		{"content type parameters", "cam-1", "", "application/json; charset=utf-8", `{"name": "Gate"}`, 0, ""},
+		{"missing path parameter", "", "", "application/json", `{"name": "Gate"}`, 400, "path.cameraId"},
+		{"path parameter pattern", "Cam 1", "", "application/json", `{"name": "Gate"}`, 400, "path.cameraId"},
+		{"query not a number", "cam-1", "?limit=ten", "application/json", `{"name": "Gate"}`, 400, "query.limit"},
+		{"query NaN", "cam-1", "?limit=NaN", "application/json", `{"name": "Gate"}`, 400, "query.limit"},
+		{"query out of range", "cam-1", "?limit=500", "application/json", `{"name": "Gate"}`, 400, "query.limit"},
+		{"query not a boolean", "cam-1", "?active=maybe", "application/json", `{"name": "Gate"}`, 400, "query.active"},
+		{"empty query is absent", "cam-1", "?limit=", "application/json", `{"name": "Gate"}`, 0, ""},
+		{"unsupported content type", "cam-1", "", "text/plain", `name=Gate`, 415, ""},
+		{"missing body", "cam-1", "", "application/json", ``, 400, "body"},
+		{"invalid JSON", "cam-1", "", "application/json", `{`, 400, "body"},
+		{"missing required field", "cam-1", "", "application/json", `{}`, 400, "body.name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/api/v1/cameras/x"+tt.query, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			err := op.ValidateRequest(r, map[string]string{"cameraId": tt.cameraID})
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("ValidateRequest: %v", err)
+				}
+				// The handler must still be able to read the body
+				if body, _ := io.ReadAll(r.Body); string(body) != tt.body {
+					t.Errorf("body after validation = %q, want %q", body, tt.body)
+				}
+				return
+			}
+			var reqErr *RequestError
+			if !errors.As(err, &reqErr) {
+				t.Fatalf("ValidateRequest error = %v, want a *RequestError", err)
+			}
+			if reqErr.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", reqErr.Status, tt.wantStatus)
+			}
+			if tt.wantField != "" && (len(reqErr.Fields) != 1 || reqErr.Fields[0].Field != tt.wantField) {
+				t.Errorf("fields = %+v, want one error on %s", reqErr.Fields, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidateRequestLargeBody(t *testing.T) {
+	op := testOperation(t, "PUT")
+	body := `{"name": "` + strings.Repeat("a", MaxValidatedBody) + `"`
+	r := httptest.NewRequest(http.MethodPut, "/api/v1/cameras/cam-1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if err := op.ValidateRequest(r, map[string]string{"cameraId": "cam-1"}); err != nil {
+		t.Fatalf("a body over MaxValidatedBody was checked: %v", err)
+	}
+	if got, _ := io.ReadAll(r.Body); len(got) != len(body) {
+		t.Errorf("handler reads %d bytes, want all %d", len(got), len(body))
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	op := testOperation(t, "PUT")
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantField   string
+	}{
+		{"matches", 200, "application/json", `{"name": "Gate"}`, ""},
+		{"no content", 204, "", ``, ""},
+		{"status range", 404, "application/json; charset=utf-8", `{"success": false, "error": "Camera not found"}`, ""},
+		{"undocumented status", 500, "application/json", `{}`, "response"},
+		{"undocumented content type", 200, "text/html", `<p>`, "response"},
+		{"invalid JSON", 200, "application/json", `{`, "response"},
+		{"schema mismatch", 200, "application/json", `{"name": "Gate", "id": "cam-1"}`, "response.id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Content-Type": {tt.contentType}}
+			errs := op.ValidateResponse(tt.status, header, []byte(tt.body))
+			if tt.wantField == "" {
+				if len(errs) > 0 {
+					t.Errorf("ValidateResponse = %+v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField {
+				t.Errorf("ValidateResponse = %+v, want one error on %s", errs, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestJSONResponses(t *testing.T) {
+	if !testOperation(t, "PUT").JSONResponses() {
+		t.Error("JSONResponses = false for an operation answering JSON")
+	}
+	if testOperation(t, "GET").JSONResponses() {
+		t.Error("JSONResponses = true for an image download")
+	}
+	upgrade := &Operation{Responses: map[string]*Response{"101": {}}}
+	if upgrade.JSONResponses() {
+		t.Error("JSONResponses = true for a WebSocket upgrade")
+	}
+}
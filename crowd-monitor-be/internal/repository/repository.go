@@ -0,0 +1,235 @@
+// Package repository declares the storage interfaces the API and services
+// depend on. Firestore (services/firebase) and an in-memory store
+// (services/memory) both implement them. Users, video analyses, zones and
+// incidents are filtered by the department Scope carried in the context.
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when a lookup by key matches no document.
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists is returned by Create methods when the key is taken.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrIDCardInUse is returned when a user would share an ID card number
+	// with another user.
+	ErrIDCardInUse = errors.New("id card number already in use")
+)
+
+type UserRepository interface {
+	GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*models.User, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	// SearchUsers returns the users matching filter ordered by phone number.
+	SearchUsers(ctx context.Context, filter UserFilter) ([]models.User, error)
+	// CreateUser fails with ErrAlreadyExists when the phone number is
+	// registered and with ErrIDCardInUse when the ID card number is taken.
+	CreateUser(ctx context.Context, user models.User) error
+	// UpdateUser applies update to the stored user in a transaction and
+	// returns the result. The phone number cannot change; the ID card number
+	// stays unique.
+	UpdateUser(ctx context.Context, phoneNumber string, update func(*models.User) error) (*models.User, error)
+	DeleteUser(ctx context.Context, phoneNumber string) error
+	// ImportUsers writes users keyed by phone number, replacing registered
+	// ones, in as few writes as the backend allows. Callers check
+	// uniqueness beforehand; it fails with ErrOutOfScope, writing nothing,
+	// if any user is outside the caller's department.
+	ImportUsers(ctx context.Context, users []models.User) error
+}
+
+// UserFilter selects users in SearchUsers. Empty fields match every user.
+type UserFilter struct {
+	Name         string // case-insensitive substring
+	Rank         string
+	Department   string
+	IDCardNumber string
+	Deactivated  *bool
+}
+
+// Matches reports whether user passes every filter.
+func (f UserFilter) Matches(user models.User) bool {
+	switch {
+	case f.Name != "" && !strings.Contains(strings.ToLower(user.Name), strings.ToLower(f.Name)):
+		return false
+	case f.Rank != "" && user.Rank != f.Rank:
+		return false
+	case f.Department != "" && user.Department != f.Department:
+		return false
+	case f.IDCardNumber != "" && user.IDCardNumber != f.IDCardNumber:
+		return false
+	case f.Deactivated != nil && user.Deactivated != *f.Deactivated:
+		return false
+	}
+	return true
+}
+
+type VideoAnalysisRepository interface {
+	// ListVideoAnalyses returns one page of analyses matching q, newest
+	// first by q.Filter.SortField().
+	ListVideoAnalyses(ctx context.Context, q VideoAnalysisQuery) (*VideoAnalysisPage, error)
+	GetVideoAnalysisByID(ctx context.Context, videoID string) (*models.VideoAnalysis, error)
+	// GetVideoAnalysesNearby returns analyses within radiusKm of the point,
+	// nearest first.
+	GetVideoAnalysesNearby(ctx context.Context, lat, lon float64, radiusKm float64) ([]models.VideoAnalysis, error)
+	// GetVideoAnalysesInBoundingBox returns analyses located inside box, in
+	// no particular order.
+	GetVideoAnalysesInBoundingBox(ctx context.Context, box geo.BoundingBox) ([]models.VideoAnalysis, error)
+	// SaveVideoAnalysis upserts the analysis keyed by VideoID, stamping its
+	// geohash from the location.
+	SaveVideoAnalysis(ctx context.Context, analysis models.VideoAnalysis) error
+	// CreateVideoAnalysis stores a new analysis like SaveVideoAnalysis but
+	// returns ErrAlreadyExists if one with the same VideoID is stored.
+	CreateVideoAnalysis(ctx context.Context, analysis models.VideoAnalysis) error
+}
+
+// VideoAnalysisChange is one write observed by a VideoAnalysisWatcher.
+type VideoAnalysisChange struct {
+	Created  bool // false for an update to an existing analysis
+	Analysis models.VideoAnalysis
+}
+
+type VideoAnalysisWatcher interface {
+	// WatchVideoAnalyses calls fn for every analysis created or updated
+	// after the call and blocks until ctx is done.
+	WatchVideoAnalyses(ctx context.Context, fn func(VideoAnalysisChange)) error
+}
+
+type CameraRepository interface {
+	GetCamera(ctx context.Context, cameraID string) (*models.Camera, error)
+	ListCameras(ctx context.Context) ([]models.Camera, error)
+	// CreateCamera returns ErrAlreadyExists if the ID is taken.
+	CreateCamera(ctx context.Context, camera models.Camera) error
+	// UpdateCamera replaces a stored camera and returns ErrNotFound if it
+	// does not exist.
+	UpdateCamera(ctx context.Context, camera models.Camera) error
+	DeleteCamera(ctx context.Context, cameraID string) error
+	// RecordCameraHeartbeat and RecordCameraAnalysis stamp the matching
+	// last-seen time and mark the camera online.
+	RecordCameraHeartbeat(ctx context.Context, cameraID string, at time.Time) error
+	RecordCameraAnalysis(ctx context.Context, cameraID string, at time.Time) error
+	SetCameraHealth(ctx context.Context, cameraID, health string) error
+}
+
+type AlertRuleRepository interface {
+	GetAlertRule(ctx context.Context, ruleID string) (*models.AlertRule, error)
+	ListAlertRules(ctx context.Context) ([]models.AlertRule, error)
+	// CreateAlertRule returns ErrAlreadyExists if the ID is taken.
+	CreateAlertRule(ctx context.Context, rule models.AlertRule) error
+	// UpdateAlertRule replaces a stored rule and returns ErrNotFound if it
+	// does not exist.
+	UpdateAlertRule(ctx context.Context, rule models.AlertRule) error
+	DeleteAlertRule(ctx context.Context, ruleID string) error
+}
+
+type AlertRepository interface {
+	GetAlert(ctx context.Context, alertID string) (*models.Alert, error)
+	// ListAlerts returns up to limit alerts, newest first, optionally only
+	// those with the given status.
+	ListAlerts(ctx context.Context, status string, limit int) ([]models.Alert, error)
+	// CountAlerts returns how many alerts have the given status without
+	// reading them.
+	CountAlerts(ctx context.Context, status string) (int, error)
+	// FindOpenAlert returns the unresolved (open or acknowledged) alert with
+	// the dedup key, or ErrNotFound.
+	FindOpenAlert(ctx context.Context, dedupKey string) (*models.Alert, error)
+	// CreateAlert returns ErrAlreadyExists if the ID is taken.
+	CreateAlert(ctx context.Context, alert models.Alert) error
+	// UpdateAlert replaces a stored alert and returns ErrNotFound if it
+	// does not exist.
+	UpdateAlert(ctx context.Context, alert models.Alert) error
+}
+
+// IncidentFilter selects incidents for ListIncidents. Zero values match
+// everything.
+type IncidentFilter struct {
+	Status     string
+	Department string
+	AssignedTo string // officer phone number
+	VideoID    string
+	Limit      int
+}
+
+func (f IncidentFilter) Matches(incident models.Incident) bool {
+	if f.Status != "" && incident.Status != f.Status {
+		return false
+	}
+	if f.Department != "" && incident.Department != f.Department {
+		return false
+	}
+	if f.AssignedTo != "" && (incident.AssignedTo == nil || incident.AssignedTo.PhoneNumber != f.AssignedTo) {
+		return false
+	}
+	if f.VideoID != "" && !containsString(incident.VideoIDs, f.VideoID) {
+		return false
+	}
+	return true
+}
+
+type IncidentRepository interface {
+	GetIncident(ctx context.Context, incidentID string) (*models.Incident, error)
+	// ListIncidents returns up to filter.Limit matching incidents, newest
+	// first.
+	ListIncidents(ctx context.Context, filter IncidentFilter) ([]models.Incident, error)
+	// CreateIncident returns ErrAlreadyExists if the ID is taken.
+	CreateIncident(ctx context.Context, incident models.Incident) error
+	// UpdateIncident applies update to the stored incident atomically and
+	// returns the result. An error from update aborts the change and is
+	// returned as is; ErrNotFound is returned if the incident does not
+	// exist.
+	UpdateIncident(ctx context.Context, incidentID string, update func(*models.Incident) error) (*models.Incident, error)
+}
+
+// OfficerLocationStore keeps the latest location of each officer. The
+// Firestore backend stores locations in the Realtime Database. Reads are
+// limited to the caller's department scope.
+type OfficerLocationStore interface {
+	// SaveOfficerLocation replaces the officer's previous location.
+	SaveOfficerLocation(ctx context.Context, location models.OfficerLocation) error
+	GetOfficerLocation(ctx context.Context, phoneNumber string) (*models.OfficerLocation, error)
+	// ListOfficerLocations returns the locations received at or after since.
+	ListOfficerLocations(ctx context.Context, since time.Time) ([]models.OfficerLocation, error)
+}
+
+type ZoneRepository interface {
+	GetZone(ctx context.Context, zoneID string) (*models.Zone, error)
+	ListZones(ctx context.Context) ([]models.Zone, error)
+	// CreateZone returns ErrAlreadyExists if the ID is taken.
+	CreateZone(ctx context.Context, zone models.Zone) error
+	// UpdateZone replaces a stored zone and returns ErrNotFound if it does
+	// not exist.
+	UpdateZone(ctx context.Context, zone models.Zone) error
+	DeleteZone(ctx context.Context, zoneID string) error
+}
+
+type SessionRepository interface {
+	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
+	// CreateSession returns ErrAlreadyExists if the ID is taken.
+	CreateSession(ctx context.Context, session models.Session) error
+	// UpdateSession applies update to the stored session atomically and
+	// returns the result. An error from update aborts the change and is
+	// returned as is; ErrNotFound is returned if the session does not
+	// exist.
+	UpdateSession(ctx context.Context, sessionID string, update func(*models.Session) error) (*models.Session, error)
+	// ListSessions returns the officer's sessions, newest first.
+	ListSessions(ctx context.Context, phoneNumber string) ([]models.Session, error)
+	// SaveRevocation upserts the revocation keyed by ID.
+	SaveRevocation(ctx context.Context, revocation models.Revocation) error
+	// ListRevocations returns the revocations expiring after now.
+	ListRevocations(ctx context.Context, now time.Time) ([]models.Revocation, error)
+}
+
+// OTPStore keeps the pending login code of each phone number for the local
+// OTP provider.
+type OTPStore interface {
+	// SaveOTP replaces any pending code for the phone number.
+	SaveOTP(ctx context.Context, code models.OTPCode) error
+	GetOTP(ctx context.Context, phoneNumber string) (*models.OTPCode, error)
+	DeleteOTP(ctx context.Context, phoneNumber string) error
+}
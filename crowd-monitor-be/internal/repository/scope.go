@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOutOfScope is returned when a scoped caller writes a record belonging
+// to another department.
+var ErrOutOfScope = errors.New("outside the caller's department")
+
+// Scope limits the users, video analyses, zones, incidents and officer
+// locations a repository call can see to one department. The API attaches
+// the caller's scope to the request context; background services use
+// contexts without one and see every department. Records outside the scope
+// are reported as ErrNotFound on lookup and left out of listings.
+type Scope struct {
+	restricted bool
+	department string
+}
+
+// DepartmentScope restricts to the records of department. An empty
+// department matches no record.
+func DepartmentScope(department string) Scope {
+	return Scope{restricted: true, department: department}
+}
+
+// Restricted reports whether the scope hides any department.
+func (s Scope) Restricted() bool {
+	return s.restricted
+}
+
+// Department is the only department visible in a restricted scope.
+func (s Scope) Department() string {
+	return s.department
+}
+
+// Allows reports whether a record owned by department is visible.
+func (s Scope) Allows(department string) bool {
+	return !s.restricted || (s.department != "" && department == s.department)
+}
+
+// Narrow combines a requested department filter with the scope. It returns
+// the department to filter by, and false when the request names a
+// department the scope hides so nothing can match.
+func (s Scope) Narrow(department string) (string, bool) {
+	if !s.restricted {
+		return department, true
+	}
+	if s.department == "" || (department != "" && department != s.department) {
+		return "", false
+	}
+	return s.department, true
+}
+
+type scopeKey struct{}
+
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope attached to ctx, or an unrestricted
+// one.
+func ScopeFromContext(ctx context.Context) Scope {
+	scope, _ := ctx.Value(scopeKey{}).(Scope)
+	return scope
+}
+
+// Unscoped lifts any scope from ctx, for reads that feed state shared
+// across callers such as caches.
+func Unscoped(ctx context.Context) context.Context {
+	return WithScope(ctx, Scope{})
+}
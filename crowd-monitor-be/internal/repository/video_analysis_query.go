@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded or was
+// issued for a different sort field.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// VideoAnalysisFilter narrows a ListVideoAnalyses call. Zero values mean
+// "no constraint". String fields are matched exactly against the stored
+// analysis values.
+type VideoAnalysisFilter struct {
+	TimeField                  string // "timestamp" (default) or "created_at"
+	From                       time.Time
+	To                         time.Time // exclusive
+	CrowdLevel                 string
+	PoliceInterventionRequired string
+	IsPeakHour                 string
+	BoundingBox                *geo.BoundingBox
+	ZoneID                     string
+}
+
+func (f VideoAnalysisFilter) SortField() string {
+	if f.TimeField == "" {
+		return "timestamp"
+	}
+	return f.TimeField
+}
+
+// Matches reports whether analysis satisfies every constraint in f. Backends
+// use it for whatever part of the filter they cannot push down.
+func (f VideoAnalysisFilter) Matches(analysis models.VideoAnalysis) bool {
+	t := analysis.Timestamp
+	if f.SortField() == "created_at" {
+		t = analysis.CreatedAt
+	}
+	if !f.From.IsZero() && t.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !t.Before(f.To) {
+		return false
+	}
+	if f.CrowdLevel != "" && analysis.Analysis.CrowdLevel != f.CrowdLevel {
+		return false
+	}
+	if f.PoliceInterventionRequired != "" && analysis.Analysis.PoliceInterventionRequired != f.PoliceInterventionRequired {
+		return false
+	}
+	if f.IsPeakHour != "" && analysis.Analysis.IsPeakHour != f.IsPeakHour {
+		return false
+	}
+	if f.BoundingBox != nil && !f.BoundingBox.Contains(analysis.Location.Latitude, analysis.Location.Longitude) {
+		return false
+	}
+	if f.ZoneID != "" && !containsString(analysis.ZoneIDs, f.ZoneID) {
+		return false
+	}
+	return true
+}
+
+type VideoAnalysisQuery struct {
+	Filter   VideoAnalysisFilter
+	PageSize int
+	Cursor   string
+}
+
+type VideoAnalysisPage struct {
+	Items      []models.VideoAnalysis `json:"items"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// Cursor is the decoded form of the opaque next_cursor token. Results are
+// ordered by (Field desc, document ID desc) and a page starts strictly after
+// the position the cursor names. Value holds the raw stored sort value,
+// which is a time.Time or, for legacy documents, an RFC3339 string.
+type Cursor struct {
+	Field string
+	Value interface{}
+	ID    string
+}
+
+type cursorToken struct {
+	Field     string     `json:"f"`
+	Timestamp *time.Time `json:"t,omitempty"`
+	String    *string    `json:"s,omitempty"`
+	ID        string     `json:"id"`
+}
+
+func EncodeCursor(c Cursor) string {
+	tok := cursorToken{Field: c.Field, ID: c.ID}
+	switch v := c.Value.(type) {
+	case time.Time:
+		tok.Timestamp = &v
+	case string:
+		tok.String = &v
+	}
+	raw, _ := json.Marshal(tok)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor and checks that it was
+// issued for sortField. An empty token decodes to nil.
+func DecodeCursor(token, sortField string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var tok cursorToken
+	if err := json.Unmarshal(raw, &tok); err != nil || tok.ID == "" || tok.Field != sortField {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &Cursor{Field: tok.Field, ID: tok.ID}
+	switch {
+	case tok.Timestamp != nil:
+		c.Value = *tok.Timestamp
+	case tok.String != nil:
+		c.Value = *tok.String
+	}
+	return c, nil
+}
+
+// ClampPageSize applies the default and maximum page sizes.
+func ClampPageSize(n int) int {
+	if n <= 0 {
+		return DefaultPageSize
+	}
+	if n > MaxPageSize {
+		return MaxPageSize
+	}
+	return n
+}
+
+// SortByDistance orders analyses nearest first from the reference point.
+func SortByDistance(analyses []models.VideoAnalysis, lat, lon float64) {
+	distances := make(map[string]float64, len(analyses))
+	for _, a := range analyses {
+		distances[a.VideoID] = geo.Distance(lat, lon, a.Location.Latitude, a.Location.Longitude)
+	}
+	sort.SliceStable(analyses, func(i, j int) bool {
+		return distances[analyses[i].VideoID] < distances[analyses[j].VideoID]
+	})
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 30, 0, 123, time.UTC)
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"timestamp", Cursor{Field: "timestamp", Value: at, ID: "v1"}},
+		{"created_at", Cursor{Field: "created_at", Value: at, ID: "v2"}},
+		{"legacy string value", Cursor{Field: "timestamp", Value: "2026-10-18T09:30:00Z", ID: "v3"}},
+		{"no value", Cursor{Field: "timestamp", ID: "v4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(EncodeCursor(tt.cursor), tt.cursor.Field)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if got.Field != tt.cursor.Field || got.ID != tt.cursor.ID {
+				t.Errorf("DecodeCursor = %+v, want %+v", got, tt.cursor)
+			}
+			switch want := tt.cursor.Value.(type) {
+			case time.Time:
+				if v, ok := got.Value.(time.Time); !ok || !v.Equal(want) {
+					t.Errorf("Value = %v, want %v", got.Value, want)
+				}
+			default:
+				if got.Value != tt.cursor.Value {
+					t.Errorf("Value = %v, want %v", got.Value, tt.cursor.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	valid := EncodeCursor(Cursor{Field: "timestamp", Value: time.Now(), ID: "v1"})
+	tests := []struct {
+		name  string
+		token string
+		field string
+	}{
+		{"not base64", "!!!", "timestamp"},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("nope")), "timestamp"},
+		{"no document ID", base64.RawURLEncoding.EncodeToString([]byte(`{"f":"timestamp"}`)), "timestamp"},
+		{"other sort field", valid, "created_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token, tt.field); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	if c, err := DecodeCursor("", "timestamp"); c != nil || err != nil {
+		t.Errorf("DecodeCursor(\"\") = %v, %v, want nil, nil", c, err)
+	}
+}
+
+func TestClampPageSize(t *testing.T) {
+	for _, tt := range []struct{ in, want int }{
+		{-1, DefaultPageSize},
+		{0, DefaultPageSize},
+		{1, 1},
+		{MaxPageSize, MaxPageSize},
+		{MaxPageSize + 1, MaxPageSize},
+	} {
+		if got := ClampPageSize(tt.in); got != tt.want {
+			t.Errorf("ClampPageSize(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVideoAnalysisFilterMatches(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	analysis := models.VideoAnalysis{
+		Timestamp: at,
+		CreatedAt: at.Add(time.Hour),
+		Location:  models.Location{Latitude: 15.5439, Longitude: 73.7553},
+		Analysis:  models.Analysis{CrowdLevel: "high", PoliceInterventionRequired: "yes", IsPeakHour: "no"},
+		ZoneIDs:   []string{"z1"},
+	}
+	box := geo.BoundingBox{MinLat: 15.5, MinLon: 73.7, MaxLat: 15.6, MaxLon: 73.8}
+	elsewhere := geo.BoundingBox{MinLat: 16, MinLon: 74, MaxLat: 16.1, MaxLon: 74.1}
+
+	tests := []struct {
+		name   string
+		filter VideoAnalysisFilter
+		want   bool
+	}{
+		{"empty", VideoAnalysisFilter{}, true},
+		{"from is inclusive", VideoAnalysisFilter{From: at}, true},
+		{"to is exclusive", VideoAnalysisFilter{To: at}, false},
+		{"created_at window", VideoAnalysisFilter{TimeField: "created_at", From: at.Add(30 * time.Minute)}, true},
+		{"timestamp window", VideoAnalysisFilter{From: at.Add(30 * time.Minute)}, false},
+		{"crowd level", VideoAnalysisFilter{CrowdLevel: "high"}, true},
+		{"other crowd level", VideoAnalysisFilter{CrowdLevel: "low"}, false},
+		{"intervention", VideoAnalysisFilter{PoliceInterventionRequired: "no"}, false},
+		{"peak hour", VideoAnalysisFilter{IsPeakHour: "no"}, true},
+		{"inside the box", VideoAnalysisFilter{BoundingBox: &box}, true},
+		{"outside the box", VideoAnalysisFilter{BoundingBox: &elsewhere}, false},
+		{"zone", VideoAnalysisFilter{ZoneID: "z1"}, true},
+		{"other zone", VideoAnalysisFilter{ZoneID: "z2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(analysis); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
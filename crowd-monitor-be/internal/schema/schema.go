@@ -0,0 +1,212 @@
+// Package schema validates loosely typed documents (Firestore snapshots,
+// decoded JSON) against a declared field list and decodes them into models
+// without unchecked type assertions.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Type int
+
+const (
+	// TypeString accepts strings; numbers, booleans and timestamps are
+	// formatted into their string form.
+	TypeString Type = iota
+	// TypeNumber accepts any integer or float type and json.Number.
+	TypeNumber
+	// TypeTimestamp accepts time.Time (Firestore Timestamp) and RFC3339 strings.
+	TypeTimestamp
+	// TypeStringList accepts []string and []interface{} of strings.
+	TypeStringList
+	// TypeObject accepts a nested map validated against Field.Fields.
+	TypeObject
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeNumber:
+		return "number"
+	case TypeTimestamp:
+		return "timestamp"
+	case TypeStringList:
+		return "string list"
+	case TypeObject:
+		return "object"
+	}
+	return "unknown"
+}
+
+type Field struct {
+	Name     string
+	Type     Type
+	Required bool
+	Fields   []Field // only for TypeObject
+}
+
+// Schema is the declared set of top-level fields of a document.
+type Schema []Field
+
+// FieldError describes one field of a document that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// DocumentError collects every field error found in a single document.
+type DocumentError struct {
+	DocumentID string       `json:"document_id"`
+	Fields     []FieldError `json:"fields"`
+}
+
+func (e *DocumentError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return fmt.Sprintf("document %s is invalid: %s", e.DocumentID, strings.Join(parts, "; "))
+}
+
+// Validate checks data against the schema and returns a copy in which every
+// present field has been normalized to its canonical Go type: string,
+// float64, time.Time, []string or map[string]interface{}. Missing optional
+// fields are left out of the result.
+func (s Schema) Validate(data map[string]interface{}) (map[string]interface{}, []FieldError) {
+	var errs []FieldError
+	out := validateObject("", s, data, &errs)
+	return out, errs
+}
+
+func validateObject(prefix string, fields []Field, data map[string]interface{}, errs *[]FieldError) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		path := f.Name
+		if prefix != "" {
+			path = prefix + "." + f.Name
+		}
+
+		raw, ok := data[f.Name]
+		if !ok || raw == nil {
+			if f.Required {
+				*errs = append(*errs, FieldError{Field: path, Message: "is required"})
+			}
+			continue
+		}
+
+		value, err := normalize(path, f, raw, errs)
+		if err != nil {
+			*errs = append(*errs, FieldError{Field: path, Message: err.Error()})
+			continue
+		}
+		out[f.Name] = value
+	}
+	return out
+}
+
+func normalize(path string, f Field, raw interface{}, errs *[]FieldError) (interface{}, error) {
+	switch f.Type {
+	case TypeString:
+		return toString(raw)
+	case TypeNumber:
+		return toNumber(raw)
+	case TypeTimestamp:
+		return toTimestamp(raw)
+	case TypeStringList:
+		return toStringList(raw)
+	case TypeObject:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, typeError(f.Type, raw)
+		}
+		return validateObject(path, f.Fields, m, errs), nil
+	}
+	return nil, fmt.Errorf("unsupported schema type %d", f.Type)
+}
+
+func typeError(want Type, got interface{}) error {
+	return fmt.Errorf("expected %s, got %T", want, got)
+}
+
+func toString(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case json.Number:
+		return v.String(), nil
+	}
+	if n, err := toNumber(raw); err == nil {
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	}
+	return "", typeError(TypeString, raw)
+}
+
+func toNumber(raw interface{}) (float64, error) {
+	var n float64
+	switch v := raw.(type) {
+	case float64:
+		n = v
+	case float32:
+		n = float64(v)
+	case int:
+		n = float64(v)
+	case int32:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", v.String())
+		}
+		n = f
+	default:
+		return 0, typeError(TypeNumber, raw)
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("number is not finite")
+	}
+	return n, nil
+}
+
+func toTimestamp(raw interface{}) (time.Time, error) {
+	switch v := raw.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid RFC3339 timestamp %q", v)
+		}
+		return t, nil
+	}
+	return time.Time{}, typeError(TypeTimestamp, raw)
+}
+
+func toStringList(raw interface{}) ([]string, error) {
+	switch v := raw.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("element %d: expected string, got %T", i, item)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, typeError(TypeStringList, raw)
+}
@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	s := Schema{
+		{Name: "name", Type: TypeString, Required: true},
+		{Name: "count", Type: TypeNumber},
+		{Name: "at", Type: TypeTimestamp},
+		{Name: "tags", Type: TypeStringList},
+		{Name: "point", Type: TypeObject, Fields: []Field{
+			{Name: "lat", Type: TypeNumber, Required: true},
+		}},
+	}
+	at := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		data   map[string]interface{}
+		want   map[string]interface{}
+		errors []string // failing field paths
+	}{
+		{
+			name: "canonical types",
+			data: map[string]interface{}{"name": "a", "count": 2.5, "at": at, "tags": []string{"x"}, "point": map[string]interface{}{"lat": 15.5}},
+			want: map[string]interface{}{"name": "a", "count": 2.5, "at": at, "tags": []string{"x"}, "point": map[string]interface{}{"lat": 15.5}},
+		},
+		{
+			name: "converted types",
+			data: map[string]interface{}{"name": int64(42), "count": json.Number("7"), "at": "2026-10-18T09:30:00Z", "tags": []interface{}{"x", "y"}},
+			want: map[string]interface{}{"name": "42", "count": 7.0, "at": at, "tags": []string{"x", "y"}},
+		},
+		{
+			name: "booleans format as strings",
+			data: map[string]interface{}{"name": true},
+			want: map[string]interface{}{"name": "true"},
+		},
+		{
+			name:   "missing required field",
+			data:   map[string]interface{}{"count": 1},
+			errors: []string{"name"},
+		},
+		{
+			name:   "null required field",
+			data:   map[string]interface{}{"name": nil},
+			errors: []string{"name"},
+		},
+		{
+			name:   "wrong types",
+			data:   map[string]interface{}{"name": "a", "count": "many", "at": 5, "tags": []interface{}{"x", 1}},
+			errors: []string{"count", "at", "tags"},
+		},
+		{
+			name:   "bad timestamp",
+			data:   map[string]interface{}{"name": "a", "at": "yesterday"},
+			errors: []string{"at"},
+		},
+		{
+			name:   "non-finite number",
+			data:   map[string]interface{}{"name": "a", "count": math.NaN()},
+			errors: []string{"count"},
+		},
+		{
+			name:   "nested errors carry the path",
+			data:   map[string]interface{}{"name": "a", "point": map[string]interface{}{}},
+			errors: []string{"point.lat"},
+		},
+		{
+			name:   "object of the wrong type",
+			data:   map[string]interface{}{"name": "a", "point": "15.5,73.8"},
+			errors: []string{"point"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := s.Validate(tt.data)
+			var paths []string
+			for _, e := range errs {
+				paths = append(paths, e.Field)
+			}
+			if len(paths) != len(tt.errors) {
+				t.Fatalf("errors = %v, want fields %v", errs, tt.errors)
+			}
+			for i := range paths {
+				if paths[i] != tt.errors[i] {
+					t.Errorf("error %d on %q, want %q", i, paths[i], tt.errors[i])
+				}
+			}
+			if tt.want == nil {
+				return
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Validate = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestDecodeVideoAnalysis(t *testing.T) {
+	created := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	doc := map[string]interface{}{
+		"video_id":       "v1",
+		"video_duration": int64(30),
+		"timestamp":      created,
+		"created_at":     created,
+		"location":       map[string]interface{}{"latitude": 15.5439, "longitude": int64(73), "timestamp": "now"},
+		"analysis": map[string]interface{}{
+			"crowd_count":                     int64(40),
+			"crowd_level":                     "high",
+			"police_intervention_required":    true,
+			"police_intervention_suggestions": []interface{}{"close the gate"},
+		},
+		"frame_urls": []interface{}{"https://example.com/1.jpg"},
+		"camera_id":  "cam1",
+	}
+
+	got, err := DecodeVideoAnalysis("v1", doc)
+	if err != nil {
+		t.Fatalf("DecodeVideoAnalysis: %v", err)
+	}
+	if got.VideoID != "v1" || got.VideoDuration != 30 || !got.CreatedAt.Equal(created) {
+		t.Errorf("top-level fields = %+v", got)
+	}
+	if got.Location.Latitude != 15.5439 || got.Location.Longitude != 73 {
+		t.Errorf("location = %+v", got.Location)
+	}
+	if got.Analysis.CrowdCount != "40" || !got.Analysis.InterventionRequired() {
+		t.Errorf("analysis = %+v", got.Analysis)
+	}
+	if len(got.Analysis.PoliceInterventionSuggestions) != 1 || len(got.FrameURLs) != 1 || got.CameraID != "cam1" {
+		t.Errorf("lists = %+v", got)
+	}
+
+	roundTrip, err := DecodeVideoAnalysis("v1", EncodeVideoAnalysis(got))
+	if err != nil {
+		t.Fatalf("decoding an encoded analysis: %v", err)
+	}
+	if roundTrip.Geohash == "" || roundTrip.VideoID != got.VideoID || roundTrip.Location != got.Location {
+		t.Errorf("round trip = %+v, want %+v with a geohash", roundTrip, got)
+	}
+}
+
+func TestDecodeVideoAnalysisReportsEveryField(t *testing.T) {
+	_, err := DecodeVideoAnalysis("bad", map[string]interface{}{
+		"video_duration": "long",
+		"location":       map[string]interface{}{"latitude": "north"},
+	})
+	docErr, ok := err.(*DocumentError)
+	if !ok {
+		t.Fatalf("error = %v, want a *DocumentError", err)
+	}
+	if docErr.DocumentID != "bad" {
+		t.Errorf("DocumentID = %q, want bad", docErr.DocumentID)
+	}
+	want := []string{"video_id", "video_duration", "location.latitude", "location.longitude"}
+	if len(docErr.Fields) != len(want) {
+		t.Fatalf("fields = %+v, want %v", docErr.Fields, want)
+	}
+	for i, f := range docErr.Fields {
+		if f.Field != want[i] {
+			t.Errorf("field %d = %q, want %q", i, f.Field, want[i])
+		}
+	}
+}
+
+func TestDecoderModes(t *testing.T) {
+	valid := map[string]interface{}{"video_id": "ok", "location": map[string]interface{}{"latitude": 1.0, "longitude": 2.0}}
+	invalid := map[string]interface{}{"video_id": "bad"}
+
+	tests := []struct {
+		name        string
+		mode        Mode
+		wantErr     bool
+		wantSkipped int
+	}{
+		{"skip invalid", SkipInvalid, false, 1},
+		{"fail on invalid", FailOnInvalid, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(tt.mode)
+			if _, ok, err := d.VideoAnalysis("ok", valid); !ok || err != nil {
+				t.Fatalf("valid document: ok = %v, err = %v", ok, err)
+			}
+			_, ok, err := d.VideoAnalysis("bad", invalid)
+			if ok {
+				t.Error("invalid document decoded")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(d.Skipped) != tt.wantSkipped {
+				t.Errorf("skipped %d, want %d", len(d.Skipped), tt.wantSkipped)
+			}
+		})
+	}
+}
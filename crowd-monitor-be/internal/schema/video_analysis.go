@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+// VideoAnalysis is the declared layout of a document in the video-analysis
+// collection.
+var VideoAnalysis = Schema{
+	{Name: "video_id", Type: TypeString, Required: true},
+	{Name: "video_duration", Type: TypeNumber},
+	{Name: "timestamp", Type: TypeTimestamp},
+	{Name: "created_at", Type: TypeTimestamp},
+	{Name: "location", Type: TypeObject, Required: true, Fields: []Field{
+		{Name: "latitude", Type: TypeNumber, Required: true},
+		{Name: "longitude", Type: TypeNumber, Required: true},
+		{Name: "timestamp", Type: TypeString},
+	}},
+	{Name: "analysis", Type: TypeObject, Fields: []Field{
+		{Name: "crowd_count", Type: TypeString},
+		{Name: "crowd_level", Type: TypeString},
+		{Name: "crowd_present", Type: TypeString},
+		{Name: "is_peak_hour", Type: TypeString},
+		{Name: "police_intervention_required", Type: TypeString},
+		{Name: "police_intervention_suggestions", Type: TypeStringList},
+	}},
+	{Name: "frame_urls", Type: TypeStringList},
+	{Name: "geohash", Type: TypeString},
+	{Name: "camera_id", Type: TypeString},
+	{Name: "zone_ids", Type: TypeStringList},
+	{Name: "department", Type: TypeString},
+}
+
+// DecodeVideoAnalysis validates data against the VideoAnalysis schema and
+// builds the model from the normalized values. A *DocumentError listing every
+// invalid field is returned when validation fails.
+func DecodeVideoAnalysis(docID string, data map[string]interface{}) (models.VideoAnalysis, error) {
+	normalized, errs := VideoAnalysis.Validate(data)
+	if len(errs) > 0 {
+		return models.VideoAnalysis{}, &DocumentError{DocumentID: docID, Fields: errs}
+	}
+
+	var analysis models.VideoAnalysis
+	analysis.VideoID, _ = normalized["video_id"].(string)
+	analysis.VideoDuration, _ = normalized["video_duration"].(float64)
+	analysis.Timestamp, _ = normalized["timestamp"].(time.Time)
+	analysis.CreatedAt, _ = normalized["created_at"].(time.Time)
+
+	if loc, ok := normalized["location"].(map[string]interface{}); ok {
+		analysis.Location.Latitude, _ = loc["latitude"].(float64)
+		analysis.Location.Longitude, _ = loc["longitude"].(float64)
+		analysis.Location.Timestamp, _ = loc["timestamp"].(string)
+	}
+
+	if a, ok := normalized["analysis"].(map[string]interface{}); ok {
+		analysis.Analysis.CrowdCount, _ = a["crowd_count"].(string)
+		analysis.Analysis.CrowdLevel, _ = a["crowd_level"].(string)
+		analysis.Analysis.CrowdPresent, _ = a["crowd_present"].(string)
+		analysis.Analysis.IsPeakHour, _ = a["is_peak_hour"].(string)
+		analysis.Analysis.PoliceInterventionRequired, _ = a["police_intervention_required"].(string)
+		analysis.Analysis.PoliceInterventionSuggestions, _ = a["police_intervention_suggestions"].([]string)
+	}
+
+	analysis.FrameURLs, _ = normalized["frame_urls"].([]string)
+	analysis.Geohash, _ = normalized["geohash"].(string)
+	analysis.CameraID, _ = normalized["camera_id"].(string)
+	analysis.ZoneIDs, _ = normalized["zone_ids"].([]string)
+	analysis.Department, _ = normalized["department"].(string)
+	return analysis, nil
+}
+
+// EncodeVideoAnalysis is the inverse of DecodeVideoAnalysis: it lays the
+// model out as a document matching the VideoAnalysis schema. The geohash is
+// always recomputed from the location so it can never drift from it.
+func EncodeVideoAnalysis(analysis models.VideoAnalysis) map[string]interface{} {
+	suggestions := analysis.Analysis.PoliceInterventionSuggestions
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+	frameURLs := analysis.FrameURLs
+	if frameURLs == nil {
+		frameURLs = []string{}
+	}
+	zoneIDs := analysis.ZoneIDs
+	if zoneIDs == nil {
+		zoneIDs = []string{}
+	}
+
+	return map[string]interface{}{
+		"video_id":       analysis.VideoID,
+		"video_duration": analysis.VideoDuration,
+		"timestamp":      analysis.Timestamp,
+		"created_at":     analysis.CreatedAt,
+		"location": map[string]interface{}{
+			"latitude":  analysis.Location.Latitude,
+			"longitude": analysis.Location.Longitude,
+			"timestamp": analysis.Location.Timestamp,
+		},
+		"analysis": map[string]interface{}{
+			"crowd_count":                     analysis.Analysis.CrowdCount,
+			"crowd_level":                     analysis.Analysis.CrowdLevel,
+			"crowd_present":                   analysis.Analysis.CrowdPresent,
+			"is_peak_hour":                    analysis.Analysis.IsPeakHour,
+			"police_intervention_required":    analysis.Analysis.PoliceInterventionRequired,
+			"police_intervention_suggestions": suggestions,
+		},
+		"frame_urls": frameURLs,
+		"geohash":    geo.EncodeGeohash(analysis.Location.Latitude, analysis.Location.Longitude, geo.GeohashPrecision),
+		"camera_id":  analysis.CameraID,
+		"zone_ids":   zoneIDs,
+		"department": analysis.Department,
+	}
+}
+
+// Mode controls what a Decoder does with a document that fails validation.
+type Mode int
+
+const (
+	// SkipInvalid drops invalid documents and records their errors.
+	SkipInvalid Mode = iota
+	// FailOnInvalid aborts the read at the first invalid document.
+	FailOnInvalid
+)
+
+// Decoder decodes a stream of documents under one Mode and collects the
+// errors of the documents it skipped.
+type Decoder struct {
+	Mode    Mode
+	Skipped []*DocumentError
+}
+
+func NewDecoder(mode Mode) *Decoder {
+	return &Decoder{Mode: mode}
+}
+
+// VideoAnalysis decodes one document. ok is false when the document was
+// skipped; err is only non-nil in FailOnInvalid mode.
+func (d *Decoder) VideoAnalysis(docID string, data map[string]interface{}) (analysis models.VideoAnalysis, ok bool, err error) {
+	analysis, err = DecodeVideoAnalysis(docID, data)
+	if err == nil {
+		return analysis, true, nil
+	}
+	docErr, isDocErr := err.(*DocumentError)
+	if d.Mode == FailOnInvalid || !isDocErr {
+		return models.VideoAnalysis{}, false, err
+	}
+	d.Skipped = append(d.Skipped, docErr)
+	return models.VideoAnalysis{}, false, nil
+}
+
+type decoderKey struct{}
+
+// WithDecoder attaches d to ctx so repository reads made with ctx decode
+// under the caller's chosen Mode and report skipped documents back to it.
+func WithDecoder(ctx context.Context, d *Decoder) context.Context {
+	return context.WithValue(ctx, decoderKey{}, d)
+}
+
+// DecoderFromContext returns the Decoder attached to ctx, or a fresh
+// SkipInvalid decoder when there is none.
+func DecoderFromContext(ctx context.Context) *Decoder {
+	if d, ok := ctx.Value(decoderKey{}).(*Decoder); ok && d != nil {
+		return d
+	}
+	return NewDecoder(SkipInvalid)
+}
@@ -0,0 +1,238 @@
+// Package alerts evaluates alert rules against new video analyses and texts
+// nearby officers when one fires.
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/events"
+)
+
+const (
+	// maxAlertVideoIDs caps the analyses remembered on one alert.
+	maxAlertVideoIDs = 20
+	// DefaultCooldown applies to rules without a cooldown of their own.
+	DefaultCooldown = 10 * time.Minute
+)
+
+// Engine evaluates every enabled rule against each newly created analysis.
+//
+// Matches for the same rule at the same hotspot (see DedupKey) are folded
+// into one alert until it is resolved. Officers are texted when the alert
+// opens and again for later matches only once the rule's cooldown has
+// passed since the last notification and nobody has acknowledged it.
+// Analyses are evaluated one at a time, so de-duplication holds within one
+// server process.
+type Engine struct {
+	bus           *events.Bus
+	rules         repository.AlertRuleRepository
+	alerts        repository.AlertRepository
+	officers      OfficerLocator
+	notifier      Notifier
+	incidents     IncidentRecorder
+	maxRecipients int
+	now           func() time.Time
+}
+
+func NewEngine(bus *events.Bus, rules repository.AlertRuleRepository, alerts repository.AlertRepository,
+	officers OfficerLocator, notifier Notifier, maxRecipients int) *Engine {
+	return &Engine{
+		bus:           bus,
+		rules:         rules,
+		alerts:        alerts,
+		officers:      officers,
+		notifier:      notifier,
+		maxRecipients: maxRecipients,
+		now:           time.Now,
+	}
+}
+
+// IncidentRecorder keeps an incident in step with each alert.
+type IncidentRecorder interface {
+	// OpenFromAlert opens an incident for a new alert and returns its ID.
+	OpenFromAlert(ctx context.Context, alert models.Alert) (string, error)
+	// AddAlertOccurrence links a further matching analysis to the incident.
+	AddAlertOccurrence(ctx context.Context, incidentID, videoID string) error
+}
+
+// RecordIncidents opens an incident through r for every new alert.
+func (e *Engine) RecordIncidents(r IncidentRecorder) {
+	e.incidents = r
+}
+
+// Run consumes the event bus until ctx is cancelled. If the engine falls
+// behind and is dropped by the bus it resubscribes from the last event it
+// saw.
+func (e *Engine) Run(ctx context.Context) {
+	lastID := ""
+	for {
+		sub, reset := e.bus.Subscribe(events.Filter{}, lastID)
+		if reset {
+			slog.WarnContext(ctx, "Alert engine missed events, those analyses were not evaluated", "after_event_id", lastID)
+		}
+
+		lastID = e.consume(ctx, sub, lastID)
+		sub.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (e *Engine) consume(ctx context.Context, sub *events.Subscription, lastID string) string {
+	for {
+		select {
+		case <-ctx.Done():
+			return lastID
+		case event, ok := <-sub.Events():
+			if !ok {
+				return lastID
+			}
+			lastID = event.ID
+			if event.Type == events.TypeAnalysisCreated {
+				e.Evaluate(ctx, event.Analysis)
+			}
+		}
+	}
+}
+
+// Evaluate applies every enabled rule to the analysis.
+func (e *Engine) Evaluate(ctx context.Context, analysis models.VideoAnalysis) {
+	rules, err := e.rules.ListAlertRules(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load alert rules", "video_id", analysis.VideoID, "error", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if !Matches(rule, analysis) {
+			continue
+		}
+		if err := e.trigger(ctx, rule, analysis); err != nil {
+			slog.ErrorContext(ctx, "Failed to raise alert", "rule_id", rule.ID, "video_id", analysis.VideoID, "error", err)
+		}
+	}
+}
+
+func (e *Engine) trigger(ctx context.Context, rule models.AlertRule, analysis models.VideoAnalysis) error {
+	now := e.now().UTC()
+	key := DedupKey(rule, analysis)
+
+	alert, err := e.alerts.FindOpenAlert(ctx, key)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		alert = &models.Alert{
+			ID:         uuid.NewString(),
+			RuleID:     rule.ID,
+			RuleName:   rule.Name,
+			DedupKey:   key,
+			Status:     models.AlertStatusOpen,
+			Department: rule.Department,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+	case err != nil:
+		return err
+	}
+	created := alert.Occurrences == 0
+
+	alert.Location = models.GeoPoint{Latitude: analysis.Location.Latitude, Longitude: analysis.Location.Longitude}
+	alert.CameraID = analysis.CameraID
+	alert.CrowdLevel = analysis.Analysis.CrowdLevel
+	alert.CrowdCount = analysis.Analysis.CrowdCount
+	alert.VideoIDs = append(alert.VideoIDs, analysis.VideoID)
+	if len(alert.VideoIDs) > maxAlertVideoIDs {
+		alert.VideoIDs = alert.VideoIDs[len(alert.VideoIDs)-maxAlertVideoIDs:]
+	}
+	alert.Occurrences++
+	alert.LastTriggered = now
+
+	if alert.Status == models.AlertStatusOpen && now.Sub(alert.LastNotified) >= cooldown(rule) {
+		e.notify(ctx, rule, alert, analysis)
+		alert.LastNotified = now
+	}
+
+	if e.incidents != nil {
+		if created {
+			incidentID, err := e.incidents.OpenFromAlert(ctx, *alert)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to open incident for alert", "alert_id", alert.ID, "error", err)
+			}
+			alert.IncidentID = incidentID
+		} else if alert.IncidentID != "" {
+			if err := e.incidents.AddAlertOccurrence(ctx, alert.IncidentID, analysis.VideoID); err != nil {
+				slog.ErrorContext(ctx, "Failed to update incident for alert", "incident_id", alert.IncidentID, "alert_id", alert.ID, "error", err)
+			}
+		}
+	}
+
+	if created {
+		slog.InfoContext(ctx, "Alert opened", "alert_id", alert.ID, "rule_id", rule.ID, "key", key, "video_id", analysis.VideoID)
+		return e.alerts.CreateAlert(ctx, *alert)
+	}
+	return e.alerts.UpdateAlert(ctx, *alert)
+}
+
+// notify texts the nearest officers and records who was reached.
+func (e *Engine) notify(ctx context.Context, rule models.AlertRule, alert *models.Alert, analysis models.VideoAnalysis) {
+	officers, err := e.officers.OfficersNear(ctx, analysis.Location.Latitude, analysis.Location.Longitude,
+		rule.NotifyRadiusKm, rule.Department)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find officers for alert", "alert_id", alert.ID, "error", err)
+		return
+	}
+	if len(officers) == 0 {
+		slog.WarnContext(ctx, "No officers to notify for alert", "alert_id", alert.ID)
+		return
+	}
+	if e.maxRecipients > 0 && len(officers) > e.maxRecipients {
+		officers = officers[:e.maxRecipients]
+	}
+
+	body := message(rule, analysis)
+	for _, officer := range officers {
+		if err := e.notifier.SendSMS(ctx, officer.PhoneNumber, body); err != nil {
+			slog.ErrorContext(ctx, "Failed to notify officer of alert", "phone_number", officer.PhoneNumber, "alert_id", alert.ID, "error", err)
+			continue
+		}
+		if !containsFold(alert.NotifiedPhones, officer.PhoneNumber) {
+			alert.NotifiedPhones = append(alert.NotifiedPhones, officer.PhoneNumber)
+		}
+	}
+}
+
+func cooldown(rule models.AlertRule) time.Duration {
+	if rule.CooldownSeconds > 0 {
+		return time.Duration(rule.CooldownSeconds) * time.Second
+	}
+	return DefaultCooldown
+}
+
+func message(rule models.AlertRule, a models.VideoAnalysis) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Crowd alert (%s): %s crowd", rule.Name, a.Analysis.CrowdLevel)
+	if a.Analysis.CrowdCount != "" {
+		fmt.Fprintf(&b, " of %s", a.Analysis.CrowdCount)
+	}
+	fmt.Fprintf(&b, " at %.5f,%.5f", a.Location.Latitude, a.Location.Longitude)
+	if a.Analysis.InterventionRequired() {
+		b.WriteString(". Police intervention required")
+		if len(a.Analysis.PoliceInterventionSuggestions) > 0 {
+			fmt.Fprintf(&b, ": %s", strings.Join(a.Analysis.PoliceInterventionSuggestions, "; "))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/services/events"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+// fixedOfficers returns the same officers for every hotspot.
+type fixedOfficers []models.User
+
+func (f fixedOfficers) OfficersNear(ctx context.Context, lat, lon, radiusKm float64, department string) ([]models.User, error) {
+	return f, nil
+}
+
+// recordingNotifier keeps the recipients of every message.
+type recordingNotifier struct {
+	sent []string
+}
+
+func (n *recordingNotifier) SendSMS(ctx context.Context, to, body string) error {
+	n.sent = append(n.sent, to)
+	return nil
+}
+
+// recordingIncidents counts the incidents opened and occurrences added.
+type recordingIncidents struct {
+	opened, occurrences int
+}
+
+func (r *recordingIncidents) OpenFromAlert(ctx context.Context, alert models.Alert) (string, error) {
+	r.opened++
+	return "inc1", nil
+}
+
+func (r *recordingIncidents) AddAlertOccurrence(ctx context.Context, incidentID, videoID string) error {
+	r.occurrences++
+	return nil
+}
+
+func TestEngineDeduplicatesAndCoolsDown(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryClient()
+	rule := models.AlertRule{ID: "r1", Name: "High crowd", Enabled: true, CrowdLevels: []string{"high"}, CooldownSeconds: 600}
+	if err := store.CreateAlertRule(ctx, rule); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+
+	officers := fixedOfficers{{PhoneNumber: "+919000000001"}, {PhoneNumber: "+919000000002"}, {PhoneNumber: "+919000000003"}}
+	notifier := &recordingNotifier{}
+	incidents := &recordingIncidents{}
+	engine := NewEngine(events.NewBus(0, 1), store, store, officers, notifier, 2)
+	engine.RecordIncidents(incidents)
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		after     time.Duration
+		videoID   string
+		level     string
+		wantSent  int
+		wantCount int
+	}{
+		{"first match opens and notifies", 0, "v1", "high", 2, 1},
+		{"not matching", time.Minute, "v2", "low", 2, 1},
+		{"repeat within the cooldown", time.Minute, "v3", "high", 2, 2},
+		{"repeat after the cooldown", 10 * time.Minute, "v4", "high", 4, 3},
+	}
+	for _, step := range steps {
+		now = now.Add(step.after)
+		a := testAnalysis()
+		a.VideoID, a.CameraID, a.Analysis.CrowdLevel = step.videoID, "cam1", step.level
+		engine.Evaluate(ctx, a)
+
+		if len(notifier.sent) != step.wantSent {
+			t.Errorf("%s: sent %d messages, want %d", step.name, len(notifier.sent), step.wantSent)
+		}
+		open, err := store.ListAlerts(ctx, models.AlertStatusOpen, 10)
+		if err != nil {
+			t.Fatalf("ListAlerts: %v", err)
+		}
+		if len(open) != 1 || open[0].Occurrences != step.wantCount {
+			t.Fatalf("%s: open alerts = %+v, want one with %d occurrences", step.name, open, step.wantCount)
+		}
+	}
+	if incidents.opened != 1 || incidents.occurrences != 2 {
+		t.Errorf("incidents opened %d with %d occurrences, want 1 with 2", incidents.opened, incidents.occurrences)
+	}
+
+	// An acknowledged alert keeps collecting matches without texting
+	alert, _ := store.FindOpenAlert(ctx, "r1/camera/cam1")
+	alert.Status = models.AlertStatusAcknowledged
+	if err := store.UpdateAlert(ctx, *alert); err != nil {
+		t.Fatalf("UpdateAlert: %v", err)
+	}
+	now = now.Add(time.Hour)
+	a := testAnalysis()
+	a.VideoID, a.CameraID = "v5", "cam1"
+	engine.Evaluate(ctx, a)
+	if len(notifier.sent) != 4 {
+		t.Errorf("an acknowledged alert notified again: %d messages", len(notifier.sent))
+	}
+	if got, _ := store.GetAlert(ctx, alert.ID); got.Occurrences != 4 {
+		t.Errorf("acknowledged alert has %d occurrences, want 4", got.Occurrences)
+	}
+}
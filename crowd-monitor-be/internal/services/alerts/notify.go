@@ -0,0 +1,31 @@
+package alerts
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+// Notifier delivers an alert message to one phone number.
+type Notifier interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// LogNotifier writes messages to the log instead of sending them, for
+// deployments without an SMS sender configured.
+type LogNotifier struct{}
+
+func (LogNotifier) SendSMS(ctx context.Context, to, body string) error {
+	slog.InfoContext(ctx, "Alert SMS logged instead of sent, no SMS sender configured", "to", to, "message", body)
+	return nil
+}
+
+// OfficerLocator finds the officers to alert about a hotspot.
+type OfficerLocator interface {
+	// OfficersNear returns officers of department (any department when
+	// empty) within radiusKm of the point, nearest first. A radius of zero
+	// means no distance limit. officers.Tracker implements it, falling back
+	// to the department roster only when a department is given.
+	OfficersNear(ctx context.Context, lat, lon, radiusKm float64, department string) ([]models.User, error)
+}
@@ -0,0 +1,74 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+// hotspotPrecision is the geohash precision (cells of roughly 1.2 x 0.6 km)
+// that groups analyses without a camera into one hotspot.
+const hotspotPrecision = 6
+
+// Matches reports whether an enabled rule's conditions all hold for the
+// analysis.
+func Matches(rule models.AlertRule, a models.VideoAnalysis) bool {
+	if !rule.Enabled {
+		return false
+	}
+	if len(rule.CrowdLevels) > 0 && !containsFold(rule.CrowdLevels, a.Analysis.CrowdLevel) {
+		return false
+	}
+	if rule.MinCrowdCount > 0 {
+		count, ok := a.Analysis.Count()
+		if !ok || count < rule.MinCrowdCount {
+			return false
+		}
+	}
+	if rule.RequirePeakHour && !a.Analysis.PeakHour() {
+		return false
+	}
+	if rule.RequirePoliceIntervention && !a.Analysis.InterventionRequired() {
+		return false
+	}
+	if area := rule.Area; area != nil &&
+		geo.Distance(area.Latitude, area.Longitude, a.Location.Latitude, a.Location.Longitude) > area.RadiusKm {
+		return false
+	}
+	if len(rule.ZoneIDs) > 0 && !intersects(rule.ZoneIDs, a.ZoneIDs) {
+		return false
+	}
+	return true
+}
+
+// DedupKey identifies the hotspot an analysis belongs to for a rule: its
+// camera when known, otherwise the surrounding geohash cell.
+func DedupKey(rule models.AlertRule, a models.VideoAnalysis) string {
+	if a.CameraID != "" {
+		return fmt.Sprintf("%s/camera/%s", rule.ID, a.CameraID)
+	}
+	return fmt.Sprintf("%s/cell/%s", rule.ID,
+		geo.EncodeGeohash(a.Location.Latitude, a.Location.Longitude, hotspotPrecision))
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+func testAnalysis() models.VideoAnalysis {
+	return models.VideoAnalysis{
+		VideoID:  "v1",
+		Location: models.Location{Latitude: 15.5439, Longitude: 73.7553},
+		Analysis: models.Analysis{
+			CrowdCount:                 "about 120 people",
+			CrowdLevel:                 "High",
+			IsPeakHour:                 "yes",
+			PoliceInterventionRequired: "no",
+		},
+		ZoneIDs: []string{"z1"},
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.AlertRule
+		want bool
+	}{
+		{"no conditions", models.AlertRule{Enabled: true}, true},
+		{"disabled", models.AlertRule{}, false},
+		{"crowd level ignores case", models.AlertRule{Enabled: true, CrowdLevels: []string{"high", "critical"}}, true},
+		{"other crowd level", models.AlertRule{Enabled: true, CrowdLevels: []string{"critical"}}, false},
+		{"count reached", models.AlertRule{Enabled: true, MinCrowdCount: 120}, true},
+		{"count not reached", models.AlertRule{Enabled: true, MinCrowdCount: 121}, false},
+		{"peak hour", models.AlertRule{Enabled: true, RequirePeakHour: true}, true},
+		{"intervention", models.AlertRule{Enabled: true, RequirePoliceIntervention: true}, false},
+		{"inside the area", models.AlertRule{Enabled: true, Area: &models.AlertArea{Latitude: 15.55, Longitude: 73.76, RadiusKm: 2}}, true},
+		{"outside the area", models.AlertRule{Enabled: true, Area: &models.AlertArea{Latitude: 15.7, Longitude: 73.76, RadiusKm: 2}}, false},
+		{"zone", models.AlertRule{Enabled: true, ZoneIDs: []string{"z9", "z1"}}, true},
+		{"other zone", models.AlertRule{Enabled: true, ZoneIDs: []string{"z9"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(tt.rule, testAnalysis()); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	uncounted := testAnalysis()
+	uncounted.Analysis.CrowdCount = "many"
+	if Matches(models.AlertRule{Enabled: true, MinCrowdCount: 1}, uncounted) {
+		t.Error("a rule with a minimum count matched an analysis without a count")
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	rule := models.AlertRule{ID: "r1"}
+	a := testAnalysis()
+
+	near := a
+	near.Location.Latitude += 0.0001
+	if DedupKey(rule, a) != DedupKey(rule, near) {
+		t.Error("analyses a few metres apart got different keys")
+	}
+	far := a
+	far.Location.Latitude += 0.1
+	if DedupKey(rule, a) == DedupKey(rule, far) {
+		t.Error("analyses kilometres apart share a key")
+	}
+
+	a.CameraID = "cam1"
+	far.CameraID = "cam1"
+	if got := DedupKey(rule, a); got != "r1/camera/cam1" || got != DedupKey(rule, far) {
+		t.Errorf("DedupKey with a camera = %q, want r1/camera/cam1 wherever it is", got)
+	}
+	if !strings.HasPrefix(DedupKey(models.AlertRule{ID: "r2"}, a), "r2/") {
+		t.Error("keys of different rules collide")
+	}
+}
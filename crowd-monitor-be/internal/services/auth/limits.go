@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTooManyRequests is returned when a phone number or client IP has
+	// asked for too many codes.
+	ErrTooManyRequests = errors.New("too many OTP requests")
+	// ErrLockedOut is returned while a phone number is locked after too
+	// many wrong codes.
+	ErrLockedOut = errors.New("too many failed OTP attempts")
+)
+
+// RetryError carries how long the client should wait before trying again.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", e.Err, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Limits guard the OTP endpoints against SMS bombing and code guessing. A
+// zero count disables that limit.
+type Limits struct {
+	SendPerNumber int // codes sent to one number per SendWindow
+	SendPerIP     int // codes requested from one client IP per SendWindow
+	SendWindow    time.Duration
+	MaxAttempts   int           // wrong codes before the number is locked out
+	Lockout       time.Duration // how long a number stays locked out
+	// RegisteredOnly sends codes only to numbers of registered users. The
+	// response is the same either way so numbers cannot be enumerated.
+	RegisteredOnly bool
+}
+
+// counter counts events per key in fixed windows. Counts are kept in process
+// memory, so behind several replicas each one enforces its own limits.
+type counter struct {
+	mu        sync.Mutex
+	windows   map[string]*window
+	sweptAt   time.Time
+	sweepEach time.Duration
+}
+
+type window struct {
+	count   int
+	resetAt time.Time
+}
+
+func newCounter(sweepEach time.Duration) *counter {
+	return &counter{windows: make(map[string]*window), sweepEach: sweepEach}
+}
+
+// add counts an event for key in a window of length d starting at its first
+// event, and returns the new count and when the window ends.
+func (c *counter) add(key string, now time.Time, d time.Duration) (int, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweep(now)
+
+	w, ok := c.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &window{resetAt: now.Add(d)}
+		c.windows[key] = w
+	}
+	w.count++
+	return w.count, w.resetAt
+}
+
+// extend restarts the window of key so it ends at until.
+func (c *counter) extend(key string, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if w, ok := c.windows[key]; ok {
+		w.resetAt = until
+	}
+}
+
+func (c *counter) reset(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.windows, key)
+}
+
+// sweep drops ended windows so numbers and IPs seen once do not pile up.
+func (c *counter) sweep(now time.Time) {
+	if now.Sub(c.sweptAt) < c.sweepEach {
+		return
+	}
+	for key, w := range c.windows {
+		if !now.Before(w.resetAt) {
+			delete(c.windows, key)
+		}
+	}
+	c.sweptAt = now
+}
+
+// limiter applies Limits. Send limits count every request, sent or not.
+type limiter struct {
+	limits   Limits
+	numbers  *counter
+	ips      *counter
+	attempts *counter
+}
+
+func newLimiter(limits Limits) *limiter {
+	return &limiter{
+		limits:   limits,
+		numbers:  newCounter(limits.SendWindow),
+		ips:      newCounter(limits.SendWindow),
+		attempts: newCounter(limits.Lockout),
+	}
+}
+
+// allowSend counts a send request and fails once the number or the IP is
+// over its limit.
+func (l *limiter) allowSend(phoneNumber, clientIP string, now time.Time) error {
+	if l.limits.SendPerIP > 0 && clientIP != "" {
+		if n, resetAt := l.ips.add(clientIP, now, l.limits.SendWindow); n > l.limits.SendPerIP {
+			return &RetryError{Err: ErrTooManyRequests, RetryAfter: resetAt.Sub(now)}
+		}
+	}
+	if l.limits.SendPerNumber > 0 {
+		if n, resetAt := l.numbers.add(phoneNumber, now, l.limits.SendWindow); n > l.limits.SendPerNumber {
+			return &RetryError{Err: ErrTooManyRequests, RetryAfter: resetAt.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// attempt counts a verify attempt and fails while the number is locked out.
+// Attempts are counted before the code is checked so parallel guesses
+// cannot slip past the limit; a correct code clears the count. The attempt
+// that reaches MaxAttempts starts a full Lockout.
+func (l *limiter) attempt(phoneNumber string, now time.Time) error {
+	if l.limits.MaxAttempts <= 0 {
+		return nil
+	}
+	n, resetAt := l.attempts.add(phoneNumber, now, l.limits.Lockout)
+	if n > l.limits.MaxAttempts {
+		return &RetryError{Err: ErrLockedOut, RetryAfter: resetAt.Sub(now)}
+	}
+	if n == l.limits.MaxAttempts {
+		l.attempts.extend(phoneNumber, now.Add(l.limits.Lockout))
+	}
+	return nil
+}
+
+func (l *limiter) succeed(phoneNumber string) {
+	l.attempts.reset(phoneNumber)
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAllowSend(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	l := newLimiter(Limits{SendPerNumber: 2, SendPerIP: 3, SendWindow: 10 * time.Minute})
+
+	steps := []struct {
+		name      string
+		after     time.Duration
+		phone, ip string
+		wantRetry time.Duration // zero when allowed
+	}{
+		{"first", 0, "+911", "10.0.0.1", 0},
+		{"second", time.Minute, "+911", "10.0.0.1", 0},
+		{"number over its limit", 2 * time.Minute, "+911", "10.0.0.2", 8 * time.Minute},
+		{"same IP, other number", 3 * time.Minute, "+912", "10.0.0.1", 0},
+		{"IP over its limit", 4 * time.Minute, "+913", "10.0.0.1", 6 * time.Minute},
+		{"no IP", 5 * time.Minute, "+913", "", 0},
+		{"number window over", 10 * time.Minute, "+911", "10.0.0.3", 0},
+		{"IP window over", 10 * time.Minute, "+914", "10.0.0.1", 0},
+	}
+	for _, step := range steps {
+		err := l.allowSend(step.phone, step.ip, start.Add(step.after))
+		if step.wantRetry == 0 {
+			if err != nil {
+				t.Errorf("%s: allowSend = %v, want allowed", step.name, err)
+			}
+			continue
+		}
+		var retry *RetryError
+		if !errors.As(err, &retry) || !errors.Is(err, ErrTooManyRequests) || retry.RetryAfter != step.wantRetry {
+			t.Errorf("%s: allowSend = %v, want ErrTooManyRequests retrying in %s", step.name, err, step.wantRetry)
+		}
+	}
+
+	unlimited := newLimiter(Limits{})
+	for i := 0; i < 100; i++ {
+		if err := unlimited.allowSend("+911", "10.0.0.1", start); err != nil {
+			t.Fatalf("allowSend without limits = %v", err)
+		}
+	}
+}
+
+func TestAttemptLockout(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	l := newLimiter(Limits{MaxAttempts: 3, Lockout: 15 * time.Minute})
+
+	steps := []struct {
+		name      string
+		at        time.Duration
+		wantRetry time.Duration
+	}{
+		{"first wrong code", 0, 0},
+		{"second wrong code", time.Minute, 0},
+		{"last attempt starts the lockout", 2 * time.Minute, 0},
+		{"locked out", 3 * time.Minute, 14 * time.Minute},
+		{"still locked out", 16 * time.Minute, time.Minute},
+		{"lockout over", 17 * time.Minute, 0},
+	}
+	for _, step := range steps {
+		err := l.attempt("+911", start.Add(step.at))
+		if step.wantRetry == 0 {
+			if err != nil {
+				t.Errorf("%s: attempt = %v, want allowed", step.name, err)
+			}
+			continue
+		}
+		var retry *RetryError
+		if !errors.As(err, &retry) || !errors.Is(err, ErrLockedOut) || retry.RetryAfter != step.wantRetry {
+			t.Errorf("%s: attempt = %v, want ErrLockedOut retrying in %s", step.name, err, step.wantRetry)
+		}
+	}
+	if err := l.attempt("+912", start.Add(3*time.Minute)); err != nil {
+		t.Errorf("other number locked out: %v", err)
+	}
+
+	// A correct code clears the count
+	l.attempt("+913", start)
+	l.attempt("+913", start)
+	l.succeed("+913")
+	for i := 0; i < 3; i++ {
+		if err := l.attempt("+913", start); err != nil {
+			t.Fatalf("attempt %d after success = %v", i+1, err)
+		}
+	}
+}
+
+func TestRetryErrorMessage(t *testing.T) {
+	err := &RetryError{Err: ErrLockedOut, RetryAfter: 90*time.Second + 400*time.Millisecond}
+	if got, want := err.Error(), "too many failed OTP attempts, retry in 1m30s"; got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
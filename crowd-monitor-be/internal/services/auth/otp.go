@@ -2,80 +2,167 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/jimil-28/crowd-monitor/internal/logging"
+	"github.com/jimil-28/crowd-monitor/internal/metrics"
 	"github.com/jimil-28/crowd-monitor/internal/models"
-	"github.com/jimil-28/crowd-monitor/internal/services/firebase"
-	"github.com/jimil-28/crowd-monitor/internal/services/twilio"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/sessions"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Make sure this matches exactly the secret used in middleware/auth.go
-var jwtSecret = []byte("08c06a7edfa777158e6024e7c2de3a1851fe29a50e48bfa1c3a126e5eb75a0fc")
+// ErrDeactivated is returned when a deactivated officer tries to log in.
+var ErrDeactivated = errors.New("account is deactivated")
+
+var (
+	otpSends = metrics.NewCounterVec("crowd_monitor_otp_sends_total",
+		"OTP send requests by outcome: sent, skipped (unregistered or deactivated number), rate_limited or error.",
+		"outcome")
+	otpVerifications = metrics.NewCounterVec("crowd_monitor_otp_verifications_total",
+		"OTP verifications by outcome: success, invalid, locked_out, deactivated or error.",
+		"outcome")
+
+	tracer = otel.Tracer("github.com/jimil-28/crowd-monitor/internal/services/auth")
+)
 
 type Service struct {
-	twilioClient   *twilio.Client
-	firebaseClient *firebase.Client
+	otp      OTPProvider
+	users    repository.UserRepository
+	sessions *sessions.Service
+	limiter  *limiter
+	now      func() time.Time
 }
 
-func NewAuthService(twilioClient *twilio.Client, firebaseClient *firebase.Client) *Service {
+func NewAuthService(otp OTPProvider, users repository.UserRepository, sessionService *sessions.Service, limits Limits) *Service {
 	return &Service{
-		twilioClient:   twilioClient,
-		firebaseClient: firebaseClient,
+		otp:      otp,
+		users:    users,
+		sessions: sessionService,
+		limiter:  newLimiter(limits),
+		now:      time.Now,
 	}
 }
 
-func (s *Service) SendOTP(phoneNumber string) error {
-	return s.twilioClient.SendOTP(phoneNumber)
+// SendOTP sends a login code to the number unless it or clientIP is over
+// its send limit. With Limits.RegisteredOnly, unknown numbers get no code
+// but the result is the same as for a sent one.
+func (s *Service) SendOTP(ctx context.Context, phoneNumber, clientIP string) error {
+	ctx, span := startSpan(ctx, "auth.SendOTP", phoneNumber)
+	defer span.End()
+
+	outcome, err := s.sendOTP(ctx, phoneNumber, clientIP)
+	otpSends.Inc(outcome)
+	endSpan(span, outcome, err)
+	return err
+}
+
+func (s *Service) sendOTP(ctx context.Context, phoneNumber, clientIP string) (string, error) {
+	if err := s.limiter.allowSend(phoneNumber, clientIP, s.now()); err != nil {
+		return "rate_limited", err
+	}
+
+	if s.limiter.limits.RegisteredOnly {
+		user, err := s.users.GetUserByPhoneNumber(repository.Unscoped(ctx), phoneNumber)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "OTP not sent to unregistered number", "phone_number", phoneNumber)
+			return "skipped", nil
+		}
+		if err != nil {
+			return "error", err
+		}
+		if user.Deactivated {
+			slog.InfoContext(ctx, "OTP not sent to deactivated user", "phone_number", phoneNumber)
+			return "skipped", nil
+		}
+	}
+	if err := s.otp.SendOTP(ctx, phoneNumber); err != nil {
+		return "error", err
+	}
+	return "sent", nil
 }
 
 func (s *Service) VerifyOTP(ctx context.Context, phoneNumber string, otpCode string) (*models.AuthResponse, error) {
-	// For development: Skip actual OTP verification
-	// In production, uncomment the verification code below
-	
-	verified, err := s.twilioClient.VerifyOTP(phoneNumber, otpCode)
+	ctx, span := startSpan(ctx, "auth.VerifyOTP", phoneNumber)
+	defer span.End()
+
+	response, outcome, err := s.verifyOTP(ctx, phoneNumber, otpCode)
+	otpVerifications.Inc(outcome)
+	endSpan(span, outcome, err)
+	return response, err
+}
+
+func (s *Service) verifyOTP(ctx context.Context, phoneNumber string, otpCode string) (*models.AuthResponse, string, error) {
+	if err := s.limiter.attempt(phoneNumber, s.now()); err != nil {
+		return nil, "locked_out", err
+	}
+
+	verified, err := s.otp.VerifyOTP(ctx, phoneNumber, otpCode)
 	if err != nil {
-		return nil, err
+		return nil, "error", err
 	}
 
 	if !verified {
-		return nil, fmt.Errorf("invalid OTP code")
+		return nil, "invalid", fmt.Errorf("invalid OTP code")
 	}
-	
+	s.limiter.succeed(phoneNumber)
 
-	// Fetch user from Firebase
-	user, err := s.firebaseClient.GetUserByPhoneNumber(ctx, phoneNumber)
+	// Fetch user from the user repository
+	user, err := s.users.GetUserByPhoneNumber(ctx, phoneNumber)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %v", err)
+		return nil, "error", fmt.Errorf("user not found: %v", err)
+	}
+	if user.Deactivated {
+		return nil, "deactivated", ErrDeactivated
 	}
 
-	// Generate JWT token
-	token, err := generateJWT(user.PhoneNumber)
+	// Open a session with an access and a refresh token
+	tokens, err := s.sessions.Start(ctx, *user)
 	if err != nil {
-		return nil, err
+		return nil, "error", err
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User:  *user,
-	}, nil
+	return NewAuthResponse(tokens, *user), "success", nil
 }
 
-func generateJWT(phoneNumber string) (string, error) {
-	// Create a new token with claims
-	token := jwt.New(jwt.SigningMethodHS256)
-	
-	// Set claims
-	claims := token.Claims.(jwt.MapClaims)
-	claims["phone_number"] = phoneNumber
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix() // Expires in 24 hours
-	
-	// Sign and get the complete encoded token as a string
-	tokenString, err := token.SignedString(jwtSecret)
+// Refresh rotates the refresh token and issues a new access token.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+	tokens, user, err := s.sessions.Refresh(ctx, refreshToken)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	
-	return tokenString, nil
-}
\ No newline at end of file
+	return NewAuthResponse(tokens, *user), nil
+}
+
+// startSpan starts a span for an OTP operation on the masked phone number.
+func startSpan(ctx context.Context, name, phoneNumber string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("otp.phone_number", logging.MaskPhone(phoneNumber)),
+	))
+}
+
+// endSpan records the outcome; only failures of the service itself mark
+// the span as an error, not wrong codes or limits.
+func endSpan(span trace.Span, outcome string, err error) {
+	span.SetAttributes(attribute.String("otp.outcome", outcome))
+	if outcome == "error" {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, logging.Mask(err.Error()))
+	}
+}
+
+func NewAuthResponse(tokens *sessions.Tokens, user models.User) *models.AuthResponse {
+	return &models.AuthResponse{
+		Token:            tokens.AccessToken,
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+		User:             user,
+	}
+}
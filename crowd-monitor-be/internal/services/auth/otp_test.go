@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+	"github.com/jimil-28/crowd-monitor/internal/services/sessions"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
+)
+
+// fixedCode accepts one code for every number and counts the codes sent.
+type fixedCode struct {
+	code string
+	sent int
+}
+
+func (f *fixedCode) SendOTP(ctx context.Context, phoneNumber string) error {
+	f.sent++
+	return nil
+}
+
+func (f *fixedCode) VerifyOTP(ctx context.Context, phoneNumber, code string) (bool, error) {
+	return code == f.code, nil
+}
+
+func newTestService(t *testing.T, limits Limits) (*Service, *fixedCode) {
+	t.Helper()
+	store := memory.NewMemoryClient()
+	ctx := context.Background()
+	for _, user := range []models.User{
+		{PhoneNumber: "+919405061349", Name: "Kavita Naik", Rank: "DYSP", Department: "Mapusa Police Department"},
+		{PhoneNumber: "+919405061350", Name: "Anil Gaonkar", Rank: "ASI", Department: "Mapusa Police Department", Deactivated: true},
+	} {
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	keys, err := tokens.LoadKeySet("", "test-secret")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	tokenService, err := tokens.NewTokenService(keys, "crowd-monitor-test", time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenService: %v", err)
+	}
+	provider := &fixedCode{code: "123456"}
+	return NewAuthService(provider, store, sessions.NewSessionService(tokenService, store, store, time.Hour), limits), provider
+}
+
+func TestVerifyOTPLockout(t *testing.T) {
+	s, _ := newTestService(t, Limits{MaxAttempts: 2, Lockout: time.Minute})
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.VerifyOTP(ctx, "+919405061349", "000000"); err == nil || errors.Is(err, ErrLockedOut) {
+			t.Fatalf("wrong code %d: error = %v, want invalid", i+1, err)
+		}
+	}
+	if _, err := s.VerifyOTP(ctx, "+919405061349", "123456"); !errors.Is(err, ErrLockedOut) {
+		t.Fatalf("correct code while locked out: error = %v, want ErrLockedOut", err)
+	}
+
+	now = now.Add(time.Minute)
+	resp, err := s.VerifyOTP(ctx, "+919405061349", "123456")
+	if err != nil {
+		t.Fatalf("VerifyOTP after the lockout: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.User.PhoneNumber != "+919405061349" {
+		t.Errorf("VerifyOTP = %+v, want tokens for the officer", resp)
+	}
+
+	if _, err := s.VerifyOTP(ctx, "+919405061350", "123456"); !errors.Is(err, ErrDeactivated) {
+		t.Errorf("deactivated officer: error = %v, want ErrDeactivated", err)
+	}
+}
+
+func TestSendOTPRegisteredOnly(t *testing.T) {
+	s, provider := newTestService(t, Limits{RegisteredOnly: true, SendPerNumber: 1, SendWindow: time.Minute})
+	ctx := context.Background()
+
+	for _, phone := range []string{"+919405061349", "+919000000000", "+919405061350"} {
+		if err := s.SendOTP(ctx, phone, "10.0.0.1"); err != nil {
+			t.Errorf("SendOTP(%s) = %v, want the same answer for every number", phone, err)
+		}
+	}
+	if provider.sent != 1 {
+		t.Errorf("sent %d codes, want only the one to the active registered officer", provider.sent)
+	}
+	if err := s.SendOTP(ctx, "+919000000000", "10.0.0.1"); !errors.Is(err, ErrTooManyRequests) {
+		t.Errorf("second request for an unknown number: error = %v, want ErrTooManyRequests", err)
+	}
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+// OTP providers selectable through OTP_PROVIDER.
+const (
+	ProviderTwilio  = "twilio"  // Twilio Verify sends and checks the codes
+	ProviderLocal   = "local"   // codes generated here and sent as plain SMS
+	ProviderConsole = "console" // codes generated here and only logged
+)
+
+const otpDigits = 6
+
+// OTPProvider sends one-time login codes and checks them.
+type OTPProvider interface {
+	SendOTP(ctx context.Context, phoneNumber string) error
+	// VerifyOTP reports whether code is the pending code for the number.
+	VerifyOTP(ctx context.Context, phoneNumber, code string) (bool, error)
+}
+
+// CodeSender delivers a generated code; twilio.SMSSender is one.
+type CodeSender interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// ConsoleSender logs codes instead of sending them, for development
+// without an SMS account.
+type ConsoleSender struct{}
+
+func (ConsoleSender) SendSMS(ctx context.Context, to, body string) error {
+	slog.WarnContext(ctx, "OTP logged instead of sent", "to", to, "message", body)
+	return nil
+}
+
+// LocalProvider generates codes itself and keeps a salted digest of the
+// pending one per phone number until it is used or expires.
+type LocalProvider struct {
+	store  repository.OTPStore
+	sender CodeSender
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewLocalProvider issues codes valid for ttl and delivers them with
+// sender.
+func NewLocalProvider(store repository.OTPStore, sender CodeSender, ttl time.Duration) *LocalProvider {
+	return &LocalProvider{
+		store:  store,
+		sender: sender,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// SendOTP replaces any pending code for the number with a new one.
+func (p *LocalProvider) SendOTP(ctx context.Context, phoneNumber string) error {
+	code, err := randomDigits(otpDigits)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	now := p.now().UTC()
+	err = p.store.SaveOTP(ctx, models.OTPCode{
+		PhoneNumber: phoneNumber,
+		Hash:        hashCode(salt, code),
+		Salt:        hex.EncodeToString(salt),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(p.ttl),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store OTP: %v", err)
+	}
+
+	body := fmt.Sprintf("Your Crowd Monitor login code is %s. It expires in %d minutes.", code, max(1, int(p.ttl.Minutes())))
+	if err := p.sender.SendSMS(ctx, phoneNumber, body); err != nil {
+		return fmt.Errorf("failed to send OTP: %v", err)
+	}
+	return nil
+}
+
+// VerifyOTP consumes the pending code when it matches.
+func (p *LocalProvider) VerifyOTP(ctx context.Context, phoneNumber, code string) (bool, error) {
+	pending, err := p.store.GetOTP(ctx, phoneNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !p.now().Before(pending.ExpiresAt) {
+		return false, p.store.DeleteOTP(ctx, phoneNumber)
+	}
+
+	salt, err := hex.DecodeString(pending.Salt)
+	if err != nil {
+		return false, fmt.Errorf("stored OTP for %s has an invalid salt", phoneNumber)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashCode(salt, code)), []byte(pending.Hash)) != 1 {
+		return false, nil
+	}
+	return true, p.store.DeleteOTP(ctx, phoneNumber)
+}
+
+func hashCode(salt []byte, code string) string {
+	sum := sha256.Sum256(append(append([]byte(nil), salt...), code...))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomDigits(n int) (string, error) {
+	digits := make([]byte, n)
+	for i := range digits {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + d.Int64())
+	}
+	return string(digits), nil
+}
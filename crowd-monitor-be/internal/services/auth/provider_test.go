@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// capturingSender keeps the last code sent to each number.
+type capturingSender map[string]string
+
+func (s capturingSender) SendSMS(ctx context.Context, to, body string) error {
+	s[to] = codePattern.FindString(body)
+	return nil
+}
+
+func TestLocalProvider(t *testing.T) {
+	const phone = "+919405061349"
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	sent := capturingSender{}
+	p := NewLocalProvider(memory.NewMemoryClient(), sent, 5*time.Minute)
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	send := func() string {
+		t.Helper()
+		if err := p.SendOTP(ctx, phone); err != nil {
+			t.Fatalf("SendOTP: %v", err)
+		}
+		if sent[phone] == "" {
+			t.Fatal("no code in the message")
+		}
+		return sent[phone]
+	}
+	verify := func(code string) bool {
+		t.Helper()
+		ok, err := p.VerifyOTP(ctx, phone, code)
+		if err != nil {
+			t.Fatalf("VerifyOTP: %v", err)
+		}
+		return ok
+	}
+	wrong := func(code string) string {
+		if code == "000000" {
+			return "000001"
+		}
+		return "000000"
+	}
+
+	if verify("123456") {
+		t.Error("a code verified before any was sent")
+	}
+
+	code := send()
+	if verify(wrong(code)) {
+		t.Error("a wrong code verified")
+	}
+	if !verify(code) {
+		t.Error("the sent code did not verify")
+	}
+	if verify(code) {
+		t.Error("a code verified twice")
+	}
+
+	first := send()
+	second := send()
+	if first != second && verify(first) {
+		t.Error("a replaced code still verified")
+	}
+	if !verify(second) {
+		t.Error("the latest code did not verify")
+	}
+
+	code = send()
+	now = now.Add(5 * time.Minute)
+	if verify(code) {
+		t.Error("an expired code verified")
+	}
+}
@@ -0,0 +1,117 @@
+// Package cameras watches registered cameras for missed heartbeats and
+// analyses.
+package cameras
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/metrics"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+var staleCameras = metrics.NewGaugeVec("crowd_monitor_cameras_stale",
+	"Active cameras flagged stale as of the last check.")
+
+// StaleChecker periodically marks active cameras stale when neither a
+// heartbeat nor an analysis arrived within the configured window. A
+// heartbeat or analysis marks the camera online again.
+type StaleChecker struct {
+	repo     repository.CameraRepository
+	window   time.Duration
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	lastRun time.Time
+	lastErr error
+}
+
+func NewStaleChecker(repo repository.CameraRepository, window, interval time.Duration) *StaleChecker {
+	return &StaleChecker{
+		repo:     repo,
+		window:   window,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run checks immediately and then every interval until ctx is cancelled.
+func (s *StaleChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := s.Check(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Stale camera check failed", "error", err)
+		}
+		s.mu.Lock()
+		s.lastRun, s.lastErr = s.now(), err
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Health fails when the last check failed or no check has finished for two
+// intervals.
+func (s *StaleChecker) Health(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.lastRun.IsZero():
+		return fmt.Errorf("no check has finished yet")
+	case s.lastErr != nil:
+		return fmt.Errorf("last check failed: %v", s.lastErr)
+	case s.now().Sub(s.lastRun) > 2*s.interval:
+		return fmt.Errorf("no check since %s", s.lastRun.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Check flags stale cameras and returns the IDs newly marked stale.
+func (s *StaleChecker) Check(ctx context.Context) ([]string, error) {
+	cameras, err := s.repo.ListCameras(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := s.now().Add(-s.window)
+	var flagged []string
+	stale := 0
+	for _, camera := range cameras {
+		if camera.Status != models.CameraStatusActive {
+			continue
+		}
+		if camera.Health == models.CameraHealthStale {
+			stale++
+			continue
+		}
+		// A camera that never reported is measured from its registration.
+		lastSeen := camera.LastSeen()
+		if lastSeen.IsZero() {
+			lastSeen = camera.CreatedAt
+		}
+		if lastSeen.After(cutoff) {
+			continue
+		}
+
+		if err := s.repo.SetCameraHealth(ctx, camera.ID, models.CameraHealthStale); err != nil {
+			slog.ErrorContext(ctx, "Failed to flag camera as stale", "camera_id", camera.ID, "error", err)
+			continue
+		}
+		slog.WarnContext(ctx, "Camera flagged stale", "camera_id", camera.ID, "camera_name", camera.Name, "last_seen", lastSeen)
+		flagged = append(flagged, camera.ID)
+		stale++
+	}
+	staleCameras.Set(float64(stale))
+	return flagged, nil
+}
@@ -0,0 +1,86 @@
+package cameras
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+func TestStaleCheckerCheck(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	window := 5 * time.Minute
+	store := memory.NewMemoryClient()
+	for _, camera := range []models.Camera{
+		{ID: "heartbeat", Status: models.CameraStatusActive, Health: models.CameraHealthOnline, LastHeartbeatAt: now.Add(-time.Minute)},
+		{ID: "analysis", Status: models.CameraStatusActive, Health: models.CameraHealthOnline, LastHeartbeatAt: now.Add(-time.Hour), LastAnalysisAt: now.Add(-time.Minute)},
+		{ID: "silent", Status: models.CameraStatusActive, Health: models.CameraHealthOnline, LastHeartbeatAt: now.Add(-10 * time.Minute)},
+		{ID: "new", Status: models.CameraStatusActive, CreatedAt: now.Add(-time.Minute)},
+		{ID: "never reported", Status: models.CameraStatusActive, CreatedAt: now.Add(-time.Hour)},
+		{ID: "already stale", Status: models.CameraStatusActive, Health: models.CameraHealthStale},
+		{ID: "maintenance", Status: models.CameraStatusMaintenance, Health: models.CameraHealthOnline},
+	} {
+		if err := store.CreateCamera(context.Background(), camera); err != nil {
+			t.Fatalf("CreateCamera: %v", err)
+		}
+	}
+
+	checker := NewStaleChecker(store, window, time.Minute)
+	checker.now = func() time.Time { return now }
+	flagged, err := checker.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	want := map[string]bool{"silent": true, "never reported": true}
+	if len(flagged) != len(want) {
+		t.Fatalf("flagged %v, want %v", flagged, want)
+	}
+	for _, id := range flagged {
+		if !want[id] {
+			t.Errorf("flagged %q", id)
+		}
+		camera, _ := store.GetCamera(context.Background(), id)
+		if camera.Health != models.CameraHealthStale {
+			t.Errorf("%s health = %q, want stale", id, camera.Health)
+		}
+	}
+
+	// A heartbeat brings a camera back and a second check flags nothing new
+	if err := store.RecordCameraHeartbeat(context.Background(), "silent", now); err != nil {
+		t.Fatalf("RecordCameraHeartbeat: %v", err)
+	}
+	if camera, _ := store.GetCamera(context.Background(), "silent"); camera.Health != models.CameraHealthOnline {
+		t.Errorf("health after a heartbeat = %q, want online", camera.Health)
+	}
+	if flagged, _ := checker.Check(context.Background()); len(flagged) != 0 {
+		t.Errorf("second check flagged %v", flagged)
+	}
+}
+
+func TestStaleCheckerHealth(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	checker := NewStaleChecker(memory.NewMemoryClient(), time.Minute, time.Minute)
+	checker.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		lastErr error
+		wantErr bool
+	}{
+		{"never ran", time.Time{}, nil, true},
+		{"ran recently", now.Add(-time.Minute), nil, false},
+		{"last run failed", now.Add(-time.Minute), context.DeadlineExceeded, true},
+		{"stopped running", now.Add(-3 * time.Minute), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker.lastRun, checker.lastErr = tt.lastRun, tt.lastErr
+			if err := checker.Health(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Health = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
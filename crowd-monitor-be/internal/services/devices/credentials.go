@@ -0,0 +1,55 @@
+// Package devices authenticates the edge analyzers that submit video
+// analyses. Device credentials are unrelated to officer JWTs.
+package devices
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Verifier checks a device ID and API key pair.
+type Verifier interface {
+	VerifyDevice(ctx context.Context, deviceID, key string) (bool, error)
+}
+
+// StaticCredentials holds the SHA-256 digest of each device's API key, as
+// configured through DEVICE_API_KEYS.
+type StaticCredentials map[string][sha256.Size]byte
+
+// ParseCredentials parses "id1:sha256hex,id2:sha256hex". Only key digests are
+// configured so the plaintext keys never live in the server environment.
+// Generate one with: printf %s "$KEY" | sha256sum
+func ParseCredentials(spec string) (StaticCredentials, error) {
+	creds := make(StaticCredentials)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, digestHex, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid device credential %q, expected id:sha256hex", entry)
+		}
+		digest, err := hex.DecodeString(digestHex)
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("invalid key digest for device %q", id)
+		}
+		var d [sha256.Size]byte
+		copy(d[:], digest)
+		creds[id] = d
+	}
+	return creds, nil
+}
+
+func (s StaticCredentials) VerifyDevice(ctx context.Context, deviceID, key string) (bool, error) {
+	want, ok := s[deviceID]
+	if !ok {
+		return false, nil
+	}
+	got := sha256.Sum256([]byte(key))
+	return subtle.ConstantTimeCompare(want[:], got[:]) == 1, nil
+}
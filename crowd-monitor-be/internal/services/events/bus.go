@@ -0,0 +1,194 @@
+// Package events fans video-analysis changes out to in-process consumers:
+// live streams, the alerting engine and anything else that reacts to new
+// crowd data.
+package events
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+// Event types.
+const (
+	TypeAnalysisCreated = "analysis.created"
+	TypeAnalysisUpdated = "analysis.updated"
+)
+
+type Event struct {
+	// ID is "<epoch>-<sequence>". The epoch changes on every restart so a
+	// client resuming with an ID from a previous process is told to reset.
+	ID       string               `json:"id"`
+	Type     string               `json:"type"`
+	Time     time.Time            `json:"time"`
+	Analysis models.VideoAnalysis `json:"analysis"`
+
+	seq uint64
+}
+
+// Bus is an in-memory publish/subscribe hub that keeps the most recent
+// events so subscribers can resume after a reconnect.
+type Bus struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	history     []Event // ring buffer of the last cap(history) events
+	next        int
+	subscribers map[*Subscription]struct{}
+	bufferSize  int
+	closed      bool
+}
+
+// NewBus creates a bus that replays up to historySize events and buffers up
+// to bufferSize undelivered events per subscriber.
+func NewBus(historySize, bufferSize int) *Bus {
+	return &Bus{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		history:     make([]Event, 0, historySize),
+		subscribers: make(map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Publish assigns the event its ID and delivers it to every subscriber whose
+// filter matches. A subscriber whose buffer is full is disconnected rather
+// than allowed to slow down the publisher; it can resume from its last ID.
+func (b *Bus) Publish(eventType string, analysis models.VideoAnalysis) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := Event{
+		ID:       fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		Type:     eventType,
+		Time:     time.Now().UTC(),
+		Analysis: analysis,
+		seq:      b.seq,
+	}
+
+	if len(b.history) < cap(b.history) {
+		b.history = append(b.history, event)
+	} else if cap(b.history) > 0 {
+		b.history[b.next] = event
+		b.next = (b.next + 1) % cap(b.history)
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			slog.Warn("Dropping slow event subscriber", "event_id", event.ID)
+			b.removeLocked(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe registers a subscriber. When lastEventID is set, matching events
+// published after it are queued first. reset is true when lastEventID is
+// unknown or too old to replay, in which case the client should refetch its
+// state before relying on the stream.
+func (b *Bus) Subscribe(filter Filter, lastEventID string) (sub *Subscription, reset bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if lastEventID != "" {
+		replay, reset = b.replayLocked(lastEventID)
+	}
+
+	size := b.bufferSize
+	if len(replay) > size {
+		size = len(replay)
+	}
+	sub = &Subscription{
+		bus:    b,
+		filter: filter,
+		events: make(chan Event, size),
+	}
+	if b.closed {
+		close(sub.events)
+		return sub, reset
+	}
+	for _, event := range replay {
+		if filter.Matches(event) {
+			sub.events <- event
+		}
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub, reset
+}
+
+// Close disconnects every subscriber and closes the channels of any later
+// ones immediately, letting open streams finish during shutdown.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.removeLocked(sub)
+	}
+}
+
+// SubscriberCount reports the number of connected subscribers.
+func (b *Bus) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+func (b *Bus) replayLocked(lastEventID string) ([]Event, bool) {
+	epoch, seqStr, ok := strings.Cut(lastEventID, "-")
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if !ok || err != nil || epoch != b.epoch || seq > b.seq {
+		return nil, true
+	}
+
+	ordered := append(append([]Event{}, b.history[b.next:]...), b.history[:b.next]...)
+	if seq < b.seq && (len(ordered) == 0 || ordered[0].seq > seq+1) {
+		// Events between lastEventID and the oldest we kept were lost.
+		return nil, true
+	}
+
+	var replay []Event
+	for _, event := range ordered {
+		if event.seq > seq {
+			replay = append(replay, event)
+		}
+	}
+	return replay, false
+}
+
+func (b *Bus) removeLocked(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+type Subscription struct {
+	bus    *Bus
+	filter Filter
+	events chan Event
+}
+
+// Events delivers matching events. It is closed when the subscription is
+// cancelled or dropped for falling behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.removeLocked(s)
+}
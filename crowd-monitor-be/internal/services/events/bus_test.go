@@ -0,0 +1,154 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func analysis(id string) models.VideoAnalysis {
+	return models.VideoAnalysis{
+		VideoID:    id,
+		Location:   models.Location{Latitude: 15.5439, Longitude: 73.7553},
+		Analysis:   models.Analysis{CrowdLevel: "high"},
+		CameraID:   "cam1",
+		ZoneIDs:    []string{"z1"},
+		Department: "Mapusa Police Department",
+	}
+}
+
+// drain returns the IDs of the analyses already queued on sub.
+func drain(sub *Subscription) []string {
+	var ids []string
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, event.Analysis.VideoID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	event := Event{Analysis: analysis("v1")}
+	inside := geo.BoundingBox{MinLat: 15.5, MinLon: 73.7, MaxLat: 15.6, MaxLon: 73.8}
+	outside := geo.BoundingBox{MinLat: 16, MinLon: 74, MaxLat: 16.1, MaxLon: 74.1}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"within the radius", Filter{Latitude: 15.55, Longitude: 73.76, RadiusKm: 2}, true},
+		{"outside the radius", Filter{Latitude: 15.7, Longitude: 73.76, RadiusKm: 2}, false},
+		{"inside the box", Filter{BoundingBox: &inside}, true},
+		{"outside the box", Filter{BoundingBox: &outside}, false},
+		{"crowd level", Filter{CrowdLevels: map[string]bool{"high": true, "critical": true}}, true},
+		{"other crowd level", Filter{CrowdLevels: map[string]bool{"low": true}}, false},
+		{"camera", Filter{CameraID: "cam1"}, true},
+		{"other camera", Filter{CameraID: "cam2"}, false},
+		{"zone", Filter{ZoneID: "z1"}, true},
+		{"other zone", Filter{ZoneID: "z2"}, false},
+		{"own department", Filter{Scope: repository.DepartmentScope("Mapusa Police Department")}, true},
+		{"other department", Filter{Scope: repository.DepartmentScope("Madgaon Police Department")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(event); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusDeliversMatchingEvents(t *testing.T) {
+	b := NewBus(10, 10)
+	all, _ := b.Subscribe(Filter{}, "")
+	defer all.Close()
+	cam2, _ := b.Subscribe(Filter{CameraID: "cam2"}, "")
+	defer cam2.Close()
+
+	b.Publish(TypeAnalysisCreated, analysis("v1"))
+	other := analysis("v2")
+	other.CameraID = "cam2"
+	b.Publish(TypeAnalysisUpdated, other)
+
+	if got := drain(all); fmt.Sprint(got) != "[v1 v2]" {
+		t.Errorf("unfiltered subscriber got %v, want [v1 v2]", got)
+	}
+	if got := drain(cam2); fmt.Sprint(got) != "[v2]" {
+		t.Errorf("cam2 subscriber got %v, want [v2]", got)
+	}
+}
+
+func TestBusReplay(t *testing.T) {
+	b := NewBus(3, 10)
+	var ids []string
+	for i := 1; i <= 5; i++ {
+		ids = append(ids, b.Publish(TypeAnalysisCreated, analysis(fmt.Sprintf("v%d", i))).ID)
+	}
+
+	tests := []struct {
+		name      string
+		lastID    string
+		wantReset bool
+		want      string
+	}{
+		{"no last ID", "", false, "[]"},
+		{"latest", ids[4], false, "[]"},
+		{"within history", ids[2], false, "[v4 v5]"},
+		{"oldest kept is next", ids[1], false, "[v3 v4 v5]"},
+		{"older than history", ids[0], true, "[]"},
+		{"previous process", "abc-3", true, "[]"},
+		{"from the future", ids[4][:len(ids[4])-1] + "9", true, "[]"},
+		{"malformed", "nonsense", true, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, reset := b.Subscribe(Filter{}, tt.lastID)
+			defer sub.Close()
+			if reset != tt.wantReset {
+				t.Errorf("reset = %v, want %v", reset, tt.wantReset)
+			}
+			if got := fmt.Sprint(drain(sub)); got != tt.want {
+				t.Errorf("replayed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusDropsSlowSubscribers(t *testing.T) {
+	b := NewBus(0, 1)
+	slow, _ := b.Subscribe(Filter{}, "")
+	b.Publish(TypeAnalysisCreated, analysis("v1"))
+	b.Publish(TypeAnalysisCreated, analysis("v2"))
+
+	if n := b.SubscriberCount(); n != 0 {
+		t.Errorf("SubscriberCount = %d, want the slow subscriber dropped", n)
+	}
+	<-slow.Events()
+	if _, ok := <-slow.Events(); ok {
+		t.Error("channel of a dropped subscriber is still open")
+	}
+}
+
+func TestBusClose(t *testing.T) {
+	b := NewBus(10, 10)
+	before, _ := b.Subscribe(Filter{}, "")
+	b.Close()
+	after, _ := b.Subscribe(Filter{}, "")
+
+	for name, sub := range map[string]*Subscription{"before": before, "after": after} {
+		if _, ok := <-sub.Events(); ok {
+			t.Errorf("subscription made %s Close is still open", name)
+		}
+	}
+}
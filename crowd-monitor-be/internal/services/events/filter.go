@@ -0,0 +1,55 @@
+package events
+
+import (
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+// Filter selects the events a subscriber receives. Zero values match
+// everything.
+type Filter struct {
+	// Radius filter: analyses within RadiusKm of (Latitude, Longitude).
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+
+	BoundingBox *geo.BoundingBox
+	CrowdLevels map[string]bool
+	CameraID    string
+	ZoneID      string
+
+	// Scope hides analyses of departments the subscriber cannot see.
+	Scope repository.Scope
+}
+
+func (f Filter) Matches(event Event) bool {
+	a := event.Analysis
+	if !f.Scope.Allows(a.Department) {
+		return false
+	}
+	if f.RadiusKm > 0 && geo.Distance(f.Latitude, f.Longitude, a.Location.Latitude, a.Location.Longitude) > f.RadiusKm {
+		return false
+	}
+	if f.BoundingBox != nil && !f.BoundingBox.Contains(a.Location.Latitude, a.Location.Longitude) {
+		return false
+	}
+	if len(f.CrowdLevels) > 0 && !f.CrowdLevels[a.Analysis.CrowdLevel] {
+		return false
+	}
+	if f.CameraID != "" && a.CameraID != f.CameraID {
+		return false
+	}
+	if f.ZoneID != "" && !inZone(a.ZoneIDs, f.ZoneID) {
+		return false
+	}
+	return true
+}
+
+func inZone(zoneIDs []string, zoneID string) bool {
+	for _, id := range zoneIDs {
+		if id == zoneID {
+			return true
+		}
+	}
+	return false
+}
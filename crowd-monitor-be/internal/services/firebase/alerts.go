@@ -0,0 +1,197 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"google.golang.org/api/iterator"
+)
+
+func (c *Client) GetAlertRule(ctx context.Context, ruleID string) (*models.AlertRule, error) {
+	ctx, done := observe(ctx, "GetAlertRule")
+	defer done()
+
+	doc, err := c.firestore.Collection("alert-rules").Doc(ruleID).Get(ctx)
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	countReads(ctx, 1)
+
+	var rule models.AlertRule
+	if err := doc.DataTo(&rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (c *Client) ListAlertRules(ctx context.Context) ([]models.AlertRule, error) {
+	ctx, done := observe(ctx, "ListAlertRules")
+	defer done()
+
+	iter := c.firestore.Collection("alert-rules").OrderBy("id", firestore.Asc).Documents(ctx)
+	rules := []models.AlertRule{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, 1)
+
+		var rule models.AlertRule
+		if err := doc.DataTo(&rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (c *Client) CreateAlertRule(ctx context.Context, rule models.AlertRule) error {
+	ctx, done := observe(ctx, "CreateAlertRule")
+	defer done()
+
+	_, err := c.firestore.Collection("alert-rules").Doc(rule.ID).Create(ctx, rule)
+	return mapStatusError(err)
+}
+
+func (c *Client) UpdateAlertRule(ctx context.Context, rule models.AlertRule) error {
+	ctx, done := observe(ctx, "UpdateAlertRule")
+	defer done()
+
+	return c.replaceExisting(ctx, c.firestore.Collection("alert-rules").Doc(rule.ID), rule)
+}
+
+func (c *Client) DeleteAlertRule(ctx context.Context, ruleID string) error {
+	ctx, done := observe(ctx, "DeleteAlertRule")
+	defer done()
+
+	_, err := c.firestore.Collection("alert-rules").Doc(ruleID).Delete(ctx, firestore.Exists)
+	return mapStatusError(err)
+}
+
+func (c *Client) GetAlert(ctx context.Context, alertID string) (*models.Alert, error) {
+	ctx, done := observe(ctx, "GetAlert")
+	defer done()
+
+	doc, err := c.firestore.Collection("alerts").Doc(alertID).Get(ctx)
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	countReads(ctx, 1)
+
+	var alert models.Alert
+	if err := doc.DataTo(&alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// ListAlerts filtered by status needs the (status, created_at) composite
+// index declared in firestore.indexes.json.
+func (c *Client) ListAlerts(ctx context.Context, status string, limit int) ([]models.Alert, error) {
+	ctx, done := observe(ctx, "ListAlerts")
+	defer done()
+
+	query := c.firestore.Collection("alerts").Query
+	if status != "" {
+		query = query.Where("status", "==", status)
+	}
+	return collectAlerts(ctx, query.OrderBy("created_at", firestore.Desc).Limit(limit).Documents(ctx))
+}
+
+// CountAlerts runs a count aggregation, which Firestore bills as one read
+// per 1000 matching index entries rather than one per alert.
+func (c *Client) CountAlerts(ctx context.Context, status string) (int, error) {
+	ctx, done := observe(ctx, "CountAlerts")
+	defer done()
+
+	query := c.firestore.Collection("alerts").Where("status", "==", status)
+	result, err := query.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("count aggregation returned %T", result["count"])
+	}
+	n := int(value.GetIntegerValue())
+	countReads(ctx, 1+n/1000)
+	return n, nil
+}
+
+func (c *Client) FindOpenAlert(ctx context.Context, dedupKey string) (*models.Alert, error) {
+	ctx, done := observe(ctx, "FindOpenAlert")
+	defer done()
+
+	query := c.firestore.Collection("alerts").
+		Where("dedup_key", "==", dedupKey).
+		Where("status", "in", []string{models.AlertStatusOpen, models.AlertStatusAcknowledged}).
+		Limit(1)
+	alerts, err := collectAlerts(ctx, query.Documents(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &alerts[0], nil
+}
+
+func (c *Client) CreateAlert(ctx context.Context, alert models.Alert) error {
+	ctx, done := observe(ctx, "CreateAlert")
+	defer done()
+
+	_, err := c.firestore.Collection("alerts").Doc(alert.ID).Create(ctx, alert)
+	return mapStatusError(err)
+}
+
+func (c *Client) UpdateAlert(ctx context.Context, alert models.Alert) error {
+	ctx, done := observe(ctx, "UpdateAlert")
+	defer done()
+
+	return c.replaceExisting(ctx, c.firestore.Collection("alerts").Doc(alert.ID), alert)
+}
+
+func collectAlerts(ctx context.Context, iter *firestore.DocumentIterator) ([]models.Alert, error) {
+	defer iter.Stop()
+	alerts := []models.Alert{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, 1)
+
+		var alert models.Alert
+		if err := doc.DataTo(&alert); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// replaceExisting overwrites ref with data, returning ErrNotFound instead of
+// creating the document when it does not exist.
+func (c *Client) replaceExisting(ctx context.Context, ref *firestore.DocumentRef, data interface{}) error {
+	err := c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(ref); err != nil {
+			return err
+		}
+		countReads(ctx, 1)
+		return tx.Set(ref, data)
+	})
+	return mapStatusError(err)
+}
@@ -0,0 +1,112 @@
+package firebase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/schema"
+	"google.golang.org/api/iterator"
+)
+
+// backfillBatchSize stays under Firestore's 500 writes per batch.
+const backfillBatchSize = 400
+
+type BackfillResult struct {
+	Scanned int
+	Updated int
+	Invalid int
+}
+
+// BackfillGeohashes adds the geohash field to every video-analysis document
+// that lacks it or whose stored value no longer matches its location. Legacy
+// RFC3339 string timestamps are rewritten as Firestore Timestamps in the same
+// update so time-range queries see the document, and analyses written
+// before departments were stamped at ingestion get their camera's
+// department. With dryRun set nothing is written and the result reports what
+// would change.
+func (c *Client) BackfillGeohashes(ctx context.Context, dryRun bool) (BackfillResult, error) {
+	var result BackfillResult
+	cameras, err := c.ListCameras(ctx)
+	if err != nil {
+		return result, err
+	}
+	departments := make(map[string]string, len(cameras))
+	for _, camera := range cameras {
+		departments[camera.ID] = camera.Department
+	}
+
+	iter := c.firestore.Collection("video-analysis").Documents(ctx)
+	batch := c.firestore.Batch()
+	pending := 0
+
+	commit := func() error {
+		if pending == 0 || dryRun {
+			pending = 0
+			return nil
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Committed geohash updates", "count", pending)
+		batch = c.firestore.Batch()
+		pending = 0
+		return nil
+	}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Scanned++
+
+		data := doc.Data()
+		analysis, err := schema.DecodeVideoAnalysis(doc.Ref.ID, data)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping invalid document", "error", err)
+			result.Invalid++
+			continue
+		}
+
+		var updates []firestore.Update
+		hash := geo.EncodeGeohash(analysis.Location.Latitude, analysis.Location.Longitude, geo.GeohashPrecision)
+		if analysis.Geohash != hash {
+			updates = append(updates, firestore.Update{Path: "geohash", Value: hash})
+		}
+		if department := departments[analysis.CameraID]; analysis.Department == "" && department != "" {
+			updates = append(updates, firestore.Update{Path: "department", Value: department})
+		}
+		for _, field := range []string{"timestamp", "created_at"} {
+			if _, isString := data[field].(string); isString {
+				value := analysis.Timestamp
+				if field == "created_at" {
+					value = analysis.CreatedAt
+				}
+				updates = append(updates, firestore.Update{Path: field, Value: value.UTC().Truncate(time.Microsecond)})
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		result.Updated++
+		batch.Update(doc.Ref, updates)
+		pending++
+		if pending == backfillBatchSize {
+			if err := commit(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := commit(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
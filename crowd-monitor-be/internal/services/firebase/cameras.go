@@ -0,0 +1,108 @@
+package firebase
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+func (c *Client) GetCamera(ctx context.Context, cameraID string) (*models.Camera, error) {
+	ctx, done := observe(ctx, "GetCamera")
+	defer done()
+
+	doc, err := c.firestore.Collection("cameras").Doc(cameraID).Get(ctx)
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	countReads(ctx, 1)
+
+	var camera models.Camera
+	if err := doc.DataTo(&camera); err != nil {
+		return nil, err
+	}
+	return &camera, nil
+}
+
+func (c *Client) ListCameras(ctx context.Context) ([]models.Camera, error) {
+	ctx, done := observe(ctx, "ListCameras")
+	defer done()
+
+	iter := c.firestore.Collection("cameras").OrderBy("id", firestore.Asc).Documents(ctx)
+	cameras := []models.Camera{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, 1)
+
+		var camera models.Camera
+		if err := doc.DataTo(&camera); err != nil {
+			return nil, err
+		}
+		cameras = append(cameras, camera)
+	}
+	return cameras, nil
+}
+
+func (c *Client) CreateCamera(ctx context.Context, camera models.Camera) error {
+	ctx, done := observe(ctx, "CreateCamera")
+	defer done()
+
+	_, err := c.firestore.Collection("cameras").Doc(camera.ID).Create(ctx, camera)
+	return mapStatusError(err)
+}
+
+func (c *Client) UpdateCamera(ctx context.Context, camera models.Camera) error {
+	ctx, done := observe(ctx, "UpdateCamera")
+	defer done()
+
+	return c.replaceExisting(ctx, c.firestore.Collection("cameras").Doc(camera.ID), camera)
+}
+
+func (c *Client) DeleteCamera(ctx context.Context, cameraID string) error {
+	ctx, done := observe(ctx, "DeleteCamera")
+	defer done()
+
+	_, err := c.firestore.Collection("cameras").Doc(cameraID).Delete(ctx, firestore.Exists)
+	return mapStatusError(err)
+}
+
+func (c *Client) RecordCameraHeartbeat(ctx context.Context, cameraID string, at time.Time) error {
+	ctx, done := observe(ctx, "RecordCameraHeartbeat")
+	defer done()
+
+	return c.updateCamera(ctx, cameraID,
+		firestore.Update{Path: "last_heartbeat_at", Value: at},
+		firestore.Update{Path: "health", Value: models.CameraHealthOnline},
+	)
+}
+
+func (c *Client) RecordCameraAnalysis(ctx context.Context, cameraID string, at time.Time) error {
+	ctx, done := observe(ctx, "RecordCameraAnalysis")
+	defer done()
+
+	return c.updateCamera(ctx, cameraID,
+		firestore.Update{Path: "last_analysis_at", Value: at},
+		firestore.Update{Path: "health", Value: models.CameraHealthOnline},
+	)
+}
+
+func (c *Client) SetCameraHealth(ctx context.Context, cameraID, health string) error {
+	ctx, done := observe(ctx, "SetCameraHealth")
+	defer done()
+
+	return c.updateCamera(ctx, cameraID, firestore.Update{Path: "health", Value: health})
+}
+
+func (c *Client) updateCamera(ctx context.Context, cameraID string, updates ...firestore.Update) error {
+	_, err := c.firestore.Collection("cameras").Doc(cameraID).Update(ctx, updates)
+	return mapStatusError(err)
+}
@@ -4,19 +4,35 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"math"
-	"time"
+	"log/slog"
+	"sort"
+	"sync"
 
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
 	"firebase.google.com/go/v4/db"
+	"github.com/jimil-28/crowd-monitor/internal/geo"
 	"github.com/jimil-28/crowd-monitor/internal/models"
-	"github.com/jimil-28/crowd-monitor/internal/utils"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/schema"
 	"github.com/twilio/twilio-go"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	_ repository.UserRepository          = (*Client)(nil)
+	_ repository.VideoAnalysisRepository = (*Client)(nil)
+	_ repository.CameraRepository        = (*Client)(nil)
+	_ repository.AlertRuleRepository     = (*Client)(nil)
+	_ repository.AlertRepository         = (*Client)(nil)
+	_ repository.IncidentRepository      = (*Client)(nil)
+	_ repository.ZoneRepository          = (*Client)(nil)
+	_ repository.SessionRepository       = (*Client)(nil)
+	_ repository.OTPStore                = (*Client)(nil)
 )
 
 type Client struct {
@@ -26,6 +42,9 @@ type Client struct {
 	database     *db.Client
 	twilioClient *twilio.RestClient
 	serviceSid   string
+
+	watchMu  sync.Mutex
+	watchErr error // why the video analysis listener is down, see WatchHealth
 }
 
 func NewFirebaseClient(credentialsPath, databaseURL string) (*Client, error) {
@@ -55,7 +74,7 @@ func NewFirebaseClient(credentialsPath, databaseURL string) (*Client, error) {
 
 	database, err := app.Database(context.Background())
 	if err != nil {
-		log.Printf("Warning: Could not initialize Realtime Database: %v", err)
+		slog.Warn("Could not initialize Realtime Database", "error", err)
 		// Continue without realtime database
 	}
 
@@ -72,8 +91,6 @@ func NewTwilioClient(accountSid, authToken, serviceSid string) (*Client, error)
 		return nil, errors.New("missing Twilio credentials")
 	}
 
-	log.Printf("Initializing Twilio client with Account SID: %s, Service SID: %s", accountSid, serviceSid)
-
 	client := twilio.NewRestClientWithParams(twilio.ClientParams{
 		Username: accountSid,
 		Password: authToken,
@@ -86,223 +103,498 @@ func NewTwilioClient(accountSid, authToken, serviceSid string) (*Client, error)
 }
 
 func (c *Client) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*models.User, error) {
+	ctx, done := observe(ctx, "GetUserByPhoneNumber")
+	defer done()
+
 	query := c.firestore.Collection("users").Where("phone_number", "==", phoneNumber).Limit(1)
 
 	iter := query.Documents(ctx)
 	doc, err := iter.Next()
+	if err == iterator.Done {
+		return nil, repository.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	countReads(ctx, 1)
 
 	var user models.User
 	if err := doc.DataTo(&user); err != nil {
 		return nil, err
 	}
+	if !repository.ScopeFromContext(ctx).Allows(user.Department) {
+		return nil, repository.ErrNotFound
+	}
 
 	return &user, nil
 }
 
-func (c *Client) SaveUser(ctx context.Context, user models.User) error {
-	_, err := c.firestore.Collection("users").Doc(user.PhoneNumber).Set(ctx, user)
-	return err
-}
-
-func (c *Client) GetAllVideoAnalyses(ctx context.Context) ([]models.VideoAnalysis, error) {
-	log.Println("Starting Firestore query...")
-	query := c.firestore.Collection("video-analysis")
-	iter := query.Documents(ctx)
+// SearchUsers pushes the exact-match filters down to Firestore and applies
+// the name and deactivation filters here, so it needs no composite index.
+func (c *Client) SearchUsers(ctx context.Context, filter repository.UserFilter) ([]models.User, error) {
+	ctx, done := observe(ctx, "SearchUsers")
+	defer done()
 
-	var analyses []models.VideoAnalysis
-	docCount := 0
+	department, ok := repository.ScopeFromContext(ctx).Narrow(filter.Department)
+	if !ok {
+		return []models.User{}, nil
+	}
+	query := c.firestore.Collection("users").Query
+	if department != "" {
+		query = query.Where("department", "==", department)
+	}
+	if filter.Rank != "" {
+		query = query.Where("rank", "==", filter.Rank)
+	}
+	if filter.IDCardNumber != "" {
+		query = query.Where("id_card_number", "==", filter.IDCardNumber)
+	}
 
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+	users := []models.User{}
 	for {
 		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
 		if err != nil {
-			if err == iterator.Done {
-				log.Printf("Query completed. Found %d documents", docCount)
-				break
-			}
 			return nil, err
 		}
-		docCount++
+		countReads(ctx, 1)
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return nil, err
+		}
+		if filter.Matches(user) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].PhoneNumber < users[j].PhoneNumber })
+	return users, nil
+}
 
-		data := doc.Data()
-		var analysis models.VideoAnalysis
+// CreateUser stores the user keyed by phone number. The ID card number is
+// checked in the same transaction, across every department.
+func (c *Client) CreateUser(ctx context.Context, user models.User) error {
+	ctx, done := observe(ctx, "CreateUser")
+	defer done()
 
-		// Handle basic fields
-		analysis.VideoID = data["video_id"].(string)
-		analysis.VideoDuration = data["video_duration"].(float64)
+	if !repository.ScopeFromContext(ctx).Allows(user.Department) {
+		return repository.ErrOutOfScope
+	}
+	users := c.firestore.Collection("users")
 
-		// Handle timestamps
-		if ts, ok := data["timestamp"].(string); ok {
-			parsedTime, err := time.Parse(time.RFC3339Nano, ts)
-			if err != nil {
-				log.Printf("Error parsing timestamp: %v", err)
-			} else {
-				analysis.Timestamp = parsedTime
-			}
+	err := c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		existing, err := tx.Documents(users.Where("phone_number", "==", user.PhoneNumber).Limit(1)).GetAll()
+		if err != nil {
+			return err
 		}
-		if ts, ok := data["created_at"].(string); ok {
-			parsedTime, err := time.Parse(time.RFC3339Nano, ts)
-			if err != nil {
-				log.Printf("Error parsing created_at: %v", err)
-			} else {
-				analysis.CreatedAt = parsedTime
-			}
+		countReads(ctx, len(existing))
+		if len(existing) > 0 {
+			return repository.ErrAlreadyExists
+		}
+		if err := checkIDCard(ctx, tx, users, user); err != nil {
+			return err
 		}
+		return tx.Create(users.Doc(user.PhoneNumber), user)
+	})
+	return mapStatusError(err)
+}
 
-		// Handle location
-		if loc, ok := data["location"].(map[string]interface{}); ok {
-			analysis.Location = models.Location{
-				Latitude:  loc["latitude"].(float64),
-				Longitude: loc["longitude"].(float64),
-			}
-			if ts, ok := loc["timestamp"].(string); ok {
-				analysis.Location.Timestamp = ts // Keep this as string as defined in the model
-			}
+// UpdateUser treats users of other departments as missing and refuses to
+// move a user out of the caller's department.
+func (c *Client) UpdateUser(ctx context.Context, phoneNumber string, update func(*models.User) error) (*models.User, error) {
+	ctx, done := observe(ctx, "UpdateUser")
+	defer done()
+
+	scope := repository.ScopeFromContext(ctx)
+	users := c.firestore.Collection("users")
+	var user models.User
+
+	err := c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := getUserDoc(ctx, tx, users, phoneNumber)
+		if err != nil {
+			return err
+		}
+		user = models.User{}
+		if err := doc.DataTo(&user); err != nil {
+			return err
+		}
+		if !scope.Allows(user.Department) {
+			return repository.ErrNotFound
+		}
+		if err := update(&user); err != nil {
+			return err
+		}
+		user.PhoneNumber = phoneNumber
+		if !scope.Allows(user.Department) {
+			return repository.ErrOutOfScope
+		}
+		if err := checkIDCard(ctx, tx, users, user); err != nil {
+			return err
 		}
+		return tx.Set(doc.Ref, user)
+	})
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	return &user, nil
+}
 
-		// Handle analysis fields
-		if analysisData, ok := data["analysis"].(map[string]interface{}); ok {
-			analysis.Analysis = models.Analysis{
-				CrowdCount:                 analysisData["crowd_count"].(string),
-				CrowdLevel:                 analysisData["crowd_level"].(string),
-				CrowdPresent:               analysisData["crowd_present"].(string),
-				IsPeakHour:                 analysisData["is_peak_hour"].(string),
-				PoliceInterventionRequired: analysisData["police_intervention_required"].(string),
-			}
-			if suggestions, ok := analysisData["police_intervention_suggestions"].([]interface{}); ok {
-				for _, suggestion := range suggestions {
-					analysis.Analysis.PoliceInterventionSuggestions = append(
-						analysis.Analysis.PoliceInterventionSuggestions,
-						suggestion.(string),
-					)
-				}
-			}
+func (c *Client) DeleteUser(ctx context.Context, phoneNumber string) error {
+	ctx, done := observe(ctx, "DeleteUser")
+	defer done()
+
+	scope := repository.ScopeFromContext(ctx)
+	users := c.firestore.Collection("users")
+
+	err := c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := getUserDoc(ctx, tx, users, phoneNumber)
+		if err != nil {
+			return err
 		}
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return err
+		}
+		if !scope.Allows(user.Department) {
+			return repository.ErrNotFound
+		}
+		return tx.Delete(doc.Ref)
+	})
+	return mapStatusError(err)
+}
 
-		// Handle frame URLs
-		if urls, ok := data["frame_urls"].([]interface{}); ok {
-			for _, url := range urls {
-				analysis.FrameURLs = append(analysis.FrameURLs, url.(string))
-			}
+// ImportUsers commits the users in batches of backfillBatchSize. A failed
+// batch leaves the earlier ones written.
+func (c *Client) ImportUsers(ctx context.Context, users []models.User) error {
+	ctx, done := observe(ctx, "ImportUsers")
+	defer done()
+
+	scope := repository.ScopeFromContext(ctx)
+	for _, user := range users {
+		if !scope.Allows(user.Department) {
+			return repository.ErrOutOfScope
+		}
+	}
+
+	collection := c.firestore.Collection("users")
+	for start := 0; start < len(users); start += backfillBatchSize {
+		batch := c.firestore.Batch()
+		end := min(start+backfillBatchSize, len(users))
+		for _, user := range users[start:end] {
+			batch.Set(collection.Doc(user.PhoneNumber), user)
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Imported users", "from", start+1, "to", end, "total", len(users))
+	}
+	return nil
+}
+
+// getUserDoc finds a user by the phone_number field, like
+// GetUserByPhoneNumber, rather than by document ID.
+func getUserDoc(ctx context.Context, tx *firestore.Transaction, users *firestore.CollectionRef, phoneNumber string) (*firestore.DocumentSnapshot, error) {
+	docs, err := tx.Documents(users.Where("phone_number", "==", phoneNumber).Limit(1)).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	countReads(ctx, len(docs))
+	if len(docs) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return docs[0], nil
+}
+
+// checkIDCard fails with ErrIDCardInUse when another user holds user's ID
+// card number. Reading the query in the transaction makes concurrent
+// claims of the same number conflict.
+func checkIDCard(ctx context.Context, tx *firestore.Transaction, users *firestore.CollectionRef, user models.User) error {
+	if user.IDCardNumber == "" {
+		return nil
+	}
+	docs, err := tx.Documents(users.Where("id_card_number", "==", user.IDCardNumber)).GetAll()
+	if err != nil {
+		return err
+	}
+	countReads(ctx, len(docs))
+	for _, doc := range docs {
+		var other models.User
+		if err := doc.DataTo(&other); err != nil {
+			return err
+		}
+		if other.PhoneNumber != user.PhoneNumber {
+			return repository.ErrIDCardInUse
 		}
+	}
+	return nil
+}
 
-		analyses = append(analyses, analysis)
+// maxScanFactor bounds how many documents ListVideoAnalyses reads per page
+// when filters that cannot be pushed down (bounding box) or skipped invalid
+// documents leave the page short. The page is returned partial with a
+// cursor once the budget is spent.
+const maxScanFactor = 10
+
+// ListVideoAnalyses pushes equality filters on the analysis fields and the
+// time range down to Firestore. Combinations of these need the composite
+// indexes declared in firestore.indexes.json. Documents whose sort field is
+// a legacy RFC3339 string instead of a Timestamp are excluded by a time
+// range filter; run the backfill command to normalize them. A department
+// scope is pushed down in place of the analysis fields when no zone is
+// requested.
+func (c *Client) ListVideoAnalyses(ctx context.Context, q repository.VideoAnalysisQuery) (*repository.VideoAnalysisPage, error) {
+	ctx, done := observe(ctx, "ListVideoAnalyses")
+	defer done()
+
+	filter := q.Filter
+	sortField := filter.SortField()
+	cursor, err := repository.DecodeCursor(q.Cursor, sortField)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := repository.ClampPageSize(q.PageSize)
+
+	scope := repository.ScopeFromContext(ctx)
+	query := c.firestore.Collection("video-analysis").Query
+	switch {
+	case filter.ZoneID != "":
+		// Only the zone is pushed down alongside the time range so one
+		// index per sort field covers it; other filters are applied while
+		// scanning.
+		query = query.Where("zone_ids", "array-contains", filter.ZoneID)
+	case scope.Restricted():
+		query = query.Where("department", "==", scope.Department())
+	default:
+		if filter.CrowdLevel != "" {
+			query = query.Where("analysis.crowd_level", "==", filter.CrowdLevel)
+		}
+		if filter.PoliceInterventionRequired != "" {
+			query = query.Where("analysis.police_intervention_required", "==", filter.PoliceInterventionRequired)
+		}
+		if filter.IsPeakHour != "" {
+			query = query.Where("analysis.is_peak_hour", "==", filter.IsPeakHour)
+		}
+	}
+	if !filter.From.IsZero() {
+		query = query.Where(sortField, ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(sortField, "<", filter.To)
 	}
+	query = query.OrderBy(sortField, firestore.Desc).OrderBy(firestore.DocumentID, firestore.Desc)
+
+	decoder := schema.DecoderFromContext(ctx)
+	page := &repository.VideoAnalysisPage{Items: []models.VideoAnalysis{}}
+	budget := pageSize * maxScanFactor
+	var last *firestore.DocumentSnapshot
+
+	for budget > 0 && len(page.Items) < pageSize {
+		batch := query.Limit(pageSize)
+		if last != nil {
+			batch = batch.StartAfter(last.Data()[sortField], last.Ref.ID)
+		} else if cursor != nil {
+			batch = batch.StartAfter(cursor.Value, cursor.ID)
+		}
 
-	return analyses, nil
+		docs, err := batch.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, len(docs))
+		for _, doc := range docs {
+			budget--
+			last = doc
+
+			analysis, ok, err := decoder.VideoAnalysis(doc.Ref.ID, doc.Data())
+			if err != nil {
+				return nil, err
+			}
+			if ok && scope.Allows(analysis.Department) && filter.Matches(analysis) {
+				page.Items = append(page.Items, analysis)
+			}
+			if len(page.Items) == pageSize {
+				break
+			}
+		}
+		if len(docs) < pageSize && len(page.Items) < pageSize {
+			// Collection exhausted.
+			return page, nil
+		}
+	}
+
+	if last != nil {
+		page.NextCursor = repository.EncodeCursor(repository.Cursor{
+			Field: sortField,
+			Value: last.Data()[sortField],
+			ID:    last.Ref.ID,
+		})
+	}
+	return page, nil
 }
 
 func (c *Client) GetVideoAnalysisByID(ctx context.Context, videoID string) (*models.VideoAnalysis, error) {
+	ctx, done := observe(ctx, "GetVideoAnalysisByID")
+	defer done()
+
 	query := c.firestore.Collection("video-analysis").Where("video_id", "==", videoID)
 	iter := query.Documents(ctx)
 
 	doc, err := iter.Next()
+	if err == iterator.Done {
+		return nil, repository.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	countReads(ctx, 1)
 
-	data := doc.Data()
-	analysis := &models.VideoAnalysis{}
-
-	// Use the same parsing logic as GetAllVideoAnalyses
-	analysis.VideoID = data["video_id"].(string)
-	analysis.VideoDuration = data["video_duration"].(float64)
-
-	if ts, ok := data["timestamp"].(string); ok {
-		parsedTime, err := time.Parse(time.RFC3339Nano, ts)
-		if err != nil {
-			log.Printf("Error parsing timestamp: %v", err)
-		} else {
-			analysis.Timestamp = parsedTime
-		}
+	analysis, err := schema.DecodeVideoAnalysis(doc.Ref.ID, doc.Data())
+	if err != nil {
+		return nil, err
 	}
-	if ts, ok := data["created_at"].(string); ok {
-		parsedTime, err := time.Parse(time.RFC3339Nano, ts)
-		if err != nil {
-			log.Printf("Error parsing created_at: %v", err)
-		} else {
-			analysis.CreatedAt = parsedTime
-		}
+	if !repository.ScopeFromContext(ctx).Allows(analysis.Department) {
+		return nil, repository.ErrNotFound
 	}
 
-	// ... rest of the parsing logic (same as above)
-
-	return analysis, nil
+	return &analysis, nil
 }
 
+// GetVideoAnalysesNearby reads only the documents whose geohash falls in the
+// cells covering the search circle and then drops the ones outside radiusKm.
 func (c *Client) GetVideoAnalysesNearby(ctx context.Context, lat, lon float64, radiusKm float64) ([]models.VideoAnalysis, error) {
-	utils.Logger.Printf("Fetching all video analyses from Firestore...")
+	ctx, done := observe(ctx, "GetVideoAnalysesNearby")
+	defer done()
 
-	// Get collection reference
-	collection := c.firestore.Collection("video-analysis")
-	if collection == nil {
-		utils.Logger.Printf("Error: video-analysis collection not found")
-		return nil, fmt.Errorf("video-analysis collection not found")
-	}
+	prefixes := geo.GeohashPrefixes(lat, lon, radiusKm)
+	slog.DebugContext(ctx, "Searching geohash cells around point", "cells", len(prefixes), "latitude", lat, "longitude", lon, "radius_km", radiusKm)
 
-	// Get all documents
-	docs, err := collection.Documents(ctx).GetAll()
+	nearby, err := c.queryGeohashCells(ctx, prefixes, func(a models.VideoAnalysis) bool {
+		return geo.Distance(lat, lon, a.Location.Latitude, a.Location.Longitude) <= radiusKm
+	})
 	if err != nil {
-		utils.Logger.Printf("Error fetching documents: %v", err)
-		return []models.VideoAnalysis{}, err
+		return nil, err
 	}
 
-	utils.Logger.Printf("Found %d total documents", len(docs))
-	var nearbyAnalyses []models.VideoAnalysis
+	repository.SortByDistance(nearby, lat, lon)
+	return nearby, nil
+}
 
-	for _, doc := range docs {
-		var analysis models.VideoAnalysis
-		if err := doc.DataTo(&analysis); err != nil {
-			utils.Logger.Printf("Error parsing document %s: %v", doc.Ref.ID, err)
-			continue
-		}
+func (c *Client) GetVideoAnalysesInBoundingBox(ctx context.Context, box geo.BoundingBox) ([]models.VideoAnalysis, error) {
+	ctx, done := observe(ctx, "GetVideoAnalysesInBoundingBox")
+	defer done()
 
-		utils.Logger.Printf("Parsed document %s: Latitude=%f, Longitude=%f", analysis.VideoID, analysis.Location.Latitude, analysis.Location.Longitude)
+	cells := geo.GeohashCover(box)
+	slog.DebugContext(ctx, "Searching geohash cells for bounding box", "cells", len(cells), "box", fmt.Sprintf("%+v", box))
 
-		distance := calculateDistance(
-			lat, lon,
-			analysis.Location.Latitude,
-			analysis.Location.Longitude,
-		)
+	return c.queryGeohashCells(ctx, cells, func(a models.VideoAnalysis) bool {
+		return box.Contains(a.Location.Latitude, a.Location.Longitude)
+	})
+}
 
-		utils.Logger.Printf("Document %s is %.2f km away", analysis.VideoID, distance)
+// queryGeohashCells runs one geohash range query per cell and returns the
+// decoded documents accepted by keep, each at most once.
+func (c *Client) queryGeohashCells(ctx context.Context, cells []string, keep func(models.VideoAnalysis) bool) ([]models.VideoAnalysis, error) {
+	collection := c.firestore.Collection("video-analysis")
+	decoder := schema.DecoderFromContext(ctx)
+	scope := repository.ScopeFromContext(ctx)
+	seen := make(map[string]bool)
+	var matched []models.VideoAnalysis
+	read := 0
+
+	for _, cell := range cells {
+		iter := collection.
+			Where("geohash", ">=", cell).
+			Where("geohash", "<", geo.GeohashPrefixEnd(cell)).
+			Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to fetch documents for geohash cell", "cell", cell, "error", err)
+				return nil, err
+			}
+			read++
+			countReads(ctx, 1)
+			if seen[doc.Ref.ID] {
+				continue
+			}
+			seen[doc.Ref.ID] = true
 
-		if distance <= radiusKm {
-			nearbyAnalyses = append(nearbyAnalyses, analysis)
-			utils.Logger.Printf("Added document %s to results (within range)", analysis.VideoID)
+			analysis, ok, err := decoder.VideoAnalysis(doc.Ref.ID, doc.Data())
+			if err != nil {
+				return nil, err
+			}
+			if ok && scope.Allows(analysis.Department) && keep(analysis) {
+				matched = append(matched, analysis)
+			}
 		}
 	}
 
-	utils.Logger.Printf("Returning %d nearby analyses", len(nearbyAnalyses))
-	return nearbyAnalyses, nil
+	slog.DebugContext(ctx, "Geohash cell search done", "read", read, "matched", len(matched))
+	return matched, nil
 }
 
-// calculateDistance returns distance in kilometers between two points using Haversine formula
-func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371 // Earth's radius in kilometers
+func (c *Client) SaveVideoAnalysis(ctx context.Context, analysis models.VideoAnalysis) error {
+	ctx, done := observe(ctx, "SaveVideoAnalysis")
+	defer done()
 
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	lon1Rad := lon1 * math.Pi / 180
-	lon2Rad := lon2 * math.Pi / 180
+	if !repository.ScopeFromContext(ctx).Allows(analysis.Department) {
+		return repository.ErrOutOfScope
+	}
+	_, err := c.firestore.Collection("video-analysis").Doc(analysis.VideoID).Set(ctx, schema.EncodeVideoAnalysis(analysis))
+	return err
+}
 
-	dlat := lat2Rad - lat1Rad
-	dlon := lon2Rad - lon1Rad
+// CreateVideoAnalysis also checks the video_id field because documents
+// written before ingestion existed are keyed by random IDs.
+func (c *Client) CreateVideoAnalysis(ctx context.Context, analysis models.VideoAnalysis) error {
+	ctx, done := observe(ctx, "CreateVideoAnalysis")
+	defer done()
 
-	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(dlon/2)*math.Sin(dlon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	if !repository.ScopeFromContext(ctx).Allows(analysis.Department) {
+		return repository.ErrOutOfScope
+	}
+	collection := c.firestore.Collection("video-analysis")
 
-	distance := R * c
-	utils.Logger.Printf("Calculated distance: %.2f km between (%f, %f) and (%f, %f)", distance, lat1, lon1, lat2, lon2)
-	return distance
+	_, err := collection.Where("video_id", "==", analysis.VideoID).Limit(1).Documents(ctx).Next()
+	if err == nil {
+		countReads(ctx, 1)
+		return repository.ErrAlreadyExists
+	}
+	if err != iterator.Done {
+		return err
+	}
+
+	_, err = collection.Doc(analysis.VideoID).Create(ctx, schema.EncodeVideoAnalysis(analysis))
+	return mapStatusError(err)
+}
+
+// mapStatusError translates Firestore NotFound and AlreadyExists errors into
+// their repository equivalents.
+func mapStatusError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return repository.ErrNotFound
+	case codes.AlreadyExists:
+		return repository.ErrAlreadyExists
+	}
+	return err
 }
 
 func (c *Client) GetAllUsers(ctx context.Context) ([]models.User, error) {
-	iter := c.firestore.Collection("users").Documents(ctx)
+	ctx, done := observe(ctx, "GetAllUsers")
+	defer done()
+
+	query := c.firestore.Collection("users").Query
+	if scope := repository.ScopeFromContext(ctx); scope.Restricted() {
+		query = query.Where("department", "==", scope.Department())
+	}
+	iter := query.Documents(ctx)
 	var users []models.User
 
 	for {
@@ -313,6 +605,7 @@ func (c *Client) GetAllUsers(ctx context.Context) ([]models.User, error) {
 			}
 			return nil, err
 		}
+		countReads(ctx, 1)
 
 		var user models.User
 		if err := doc.DataTo(&user); err != nil {
@@ -0,0 +1,48 @@
+package firebase
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ping reads a document to check that Firestore is reachable and the
+// credentials are accepted. The document need not exist.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, done := observe(ctx, "Ping")
+	defer done()
+
+	_, err := c.firestore.Collection("health").Doc("ping").Get(ctx)
+	countReads(ctx, 1)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+// PingRealtimeDatabase reads the top-level keys of the Realtime Database.
+func (c *Client) PingRealtimeDatabase(ctx context.Context) error {
+	ctx, span := traceRealtime(ctx, "Ping")
+	defer span.End()
+
+	if c.database == nil {
+		return errNoRealtimeDatabase
+	}
+	var keys map[string]any
+	return c.database.NewRef("").GetShallow(ctx, &keys)
+}
+
+// WatchHealth fails while the video analysis listener is not receiving
+// snapshots, before it first connects and while it waits to reconnect.
+func (c *Client) WatchHealth(ctx context.Context) error {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	return c.watchErr
+}
+
+func (c *Client) setWatchErr(err error) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	c.watchErr = err
+}
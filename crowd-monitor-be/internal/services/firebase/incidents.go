@@ -0,0 +1,122 @@
+package firebase
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"google.golang.org/api/iterator"
+)
+
+func (c *Client) GetIncident(ctx context.Context, incidentID string) (*models.Incident, error) {
+	ctx, done := observe(ctx, "GetIncident")
+	defer done()
+
+	doc, err := c.firestore.Collection("incidents").Doc(incidentID).Get(ctx)
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	countReads(ctx, 1)
+
+	var incident models.Incident
+	if err := doc.DataTo(&incident); err != nil {
+		return nil, err
+	}
+	if !repository.ScopeFromContext(ctx).Allows(incident.Department) {
+		return nil, repository.ErrNotFound
+	}
+	return &incident, nil
+}
+
+// ListIncidents pushes the status and department filters down to Firestore
+// (see the incidents indexes in firestore.indexes.json) and applies the
+// assignee and video filters to at most maxScanFactor pages of results. A
+// department scope narrows the department filter.
+func (c *Client) ListIncidents(ctx context.Context, filter repository.IncidentFilter) ([]models.Incident, error) {
+	ctx, done := observe(ctx, "ListIncidents")
+	defer done()
+
+	var ok bool
+	if filter.Department, ok = repository.ScopeFromContext(ctx).Narrow(filter.Department); !ok {
+		return []models.Incident{}, nil
+	}
+
+	query := c.firestore.Collection("incidents").Query
+	if filter.Status != "" {
+		query = query.Where("status", "==", filter.Status)
+	}
+	if filter.Department != "" {
+		query = query.Where("department", "==", filter.Department)
+	}
+	query = query.OrderBy("created_at", firestore.Desc)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit * maxScanFactor)
+	}
+
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+	incidents := []models.Incident{}
+
+	for filter.Limit <= 0 || len(incidents) < filter.Limit {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, 1)
+
+		var incident models.Incident
+		if err := doc.DataTo(&incident); err != nil {
+			return nil, err
+		}
+		if filter.Matches(incident) {
+			incidents = append(incidents, incident)
+		}
+	}
+	return incidents, nil
+}
+
+func (c *Client) CreateIncident(ctx context.Context, incident models.Incident) error {
+	ctx, done := observe(ctx, "CreateIncident")
+	defer done()
+
+	if !repository.ScopeFromContext(ctx).Allows(incident.Department) {
+		return repository.ErrOutOfScope
+	}
+	_, err := c.firestore.Collection("incidents").Doc(incident.ID).Create(ctx, incident)
+	return mapStatusError(err)
+}
+
+func (c *Client) UpdateIncident(ctx context.Context, incidentID string, update func(*models.Incident) error) (*models.Incident, error) {
+	ctx, done := observe(ctx, "UpdateIncident")
+	defer done()
+
+	ref := c.firestore.Collection("incidents").Doc(incidentID)
+	scope := repository.ScopeFromContext(ctx)
+	var incident models.Incident
+	err := c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return mapStatusError(err)
+		}
+		countReads(ctx, 1)
+		incident = models.Incident{}
+		if err := doc.DataTo(&incident); err != nil {
+			return err
+		}
+		if !scope.Allows(incident.Department) {
+			return repository.ErrNotFound
+		}
+		if err := update(&incident); err != nil {
+			return err
+		}
+		return tx.Set(ref, incident)
+	})
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	return &incident, nil
+}
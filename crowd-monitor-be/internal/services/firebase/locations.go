@@ -0,0 +1,130 @@
+package firebase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+var _ repository.OfficerLocationStore = (*Client)(nil)
+
+// officerLocationsPath is the Realtime Database node holding one child per
+// officer, keyed by phone number. ListOfficerLocations needs the
+// ".indexOn": "received_at_ms" rule from database.rules.json.
+const officerLocationsPath = "officer-locations"
+
+var errNoRealtimeDatabase = errors.New("realtime database is not configured")
+
+// rtdbOfficerLocation is the stored form of models.OfficerLocation. The
+// Realtime Database has no timestamp type, so times are kept as Unix
+// milliseconds for range queries.
+type rtdbOfficerLocation struct {
+	PhoneNumber  string  `json:"phone_number"`
+	Name         string  `json:"name"`
+	Rank         string  `json:"rank"`
+	Department   string  `json:"department"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	AccuracyM    float64 `json:"accuracy_m"`
+	Available    bool    `json:"available"`
+	RecordedAtMs int64   `json:"recorded_at_ms"`
+	ReceivedAtMs int64   `json:"received_at_ms"`
+}
+
+func (l rtdbOfficerLocation) model() models.OfficerLocation {
+	return models.OfficerLocation{
+		PhoneNumber: l.PhoneNumber,
+		Name:        l.Name,
+		Rank:        l.Rank,
+		Department:  l.Department,
+		Latitude:    l.Latitude,
+		Longitude:   l.Longitude,
+		AccuracyM:   l.AccuracyM,
+		Available:   l.Available,
+		RecordedAt:  time.UnixMilli(l.RecordedAtMs).UTC(),
+		ReceivedAt:  time.UnixMilli(l.ReceivedAtMs).UTC(),
+	}
+}
+
+// HasRealtimeDatabase reports whether the Realtime Database client was
+// initialized; without it the officer location methods fail.
+func (c *Client) HasRealtimeDatabase() bool {
+	return c.database != nil
+}
+
+func (c *Client) SaveOfficerLocation(ctx context.Context, location models.OfficerLocation) error {
+	ctx, span := traceRealtime(ctx, "SaveOfficerLocation")
+	defer span.End()
+
+	if c.database == nil {
+		return errNoRealtimeDatabase
+	}
+	stored := rtdbOfficerLocation{
+		PhoneNumber:  location.PhoneNumber,
+		Name:         location.Name,
+		Rank:         location.Rank,
+		Department:   location.Department,
+		Latitude:     location.Latitude,
+		Longitude:    location.Longitude,
+		AccuracyM:    location.AccuracyM,
+		Available:    location.Available,
+		RecordedAtMs: location.RecordedAt.UnixMilli(),
+		ReceivedAtMs: location.ReceivedAt.UnixMilli(),
+	}
+	return c.database.NewRef(officerLocationsPath).Child(locationKey(location.PhoneNumber)).Set(ctx, stored)
+}
+
+func (c *Client) GetOfficerLocation(ctx context.Context, phoneNumber string) (*models.OfficerLocation, error) {
+	ctx, span := traceRealtime(ctx, "GetOfficerLocation")
+	defer span.End()
+
+	if c.database == nil {
+		return nil, errNoRealtimeDatabase
+	}
+	var stored *rtdbOfficerLocation
+	if err := c.database.NewRef(officerLocationsPath).Child(locationKey(phoneNumber)).Get(ctx, &stored); err != nil {
+		return nil, err
+	}
+	if stored == nil || !repository.ScopeFromContext(ctx).Allows(stored.Department) {
+		return nil, repository.ErrNotFound
+	}
+	location := stored.model()
+	return &location, nil
+}
+
+func (c *Client) ListOfficerLocations(ctx context.Context, since time.Time) ([]models.OfficerLocation, error) {
+	ctx, span := traceRealtime(ctx, "ListOfficerLocations")
+	defer span.End()
+
+	if c.database == nil {
+		return nil, errNoRealtimeDatabase
+	}
+	var stored map[string]rtdbOfficerLocation
+	err := c.database.NewRef(officerLocationsPath).
+		OrderByChild("received_at_ms").
+		StartAt(since.UnixMilli()).
+		Get(ctx, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	// The node is keyed by phone number, so the department is filtered here
+	scope := repository.ScopeFromContext(ctx)
+	locations := make([]models.OfficerLocation, 0, len(stored))
+	for _, l := range stored {
+		if scope.Allows(l.Department) {
+			locations = append(locations, l.model())
+		}
+	}
+	return locations, nil
+}
+
+// locationKey makes a phone number safe as a Realtime Database key, which
+// may not contain . $ # [ ] or /.
+func locationKey(phoneNumber string) string {
+	return strings.NewReplacer(".", "_", "$", "_", "#", "_", "[", "_", "]", "_", "/", "_").Replace(phoneNumber)
+}
@@ -0,0 +1,70 @@
+package firebase
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	operationDuration = metrics.NewHistogramVec("crowd_monitor_firestore_operation_duration_seconds",
+		"Time spent in Firestore repository methods, transaction retries included.",
+		metrics.DefBuckets, "method")
+	documentsRead = metrics.NewCounterVec("crowd_monitor_firestore_documents_read_total",
+		"Firestore documents read by repository method, including ones filtered out after reading.",
+		"method")
+
+	tracer = otel.Tracer("github.com/jimil-28/crowd-monitor/internal/services/firebase")
+)
+
+// call is the repository method a context is observing.
+type call struct {
+	method string
+	reads  int
+}
+
+type callKey struct{}
+
+// observe times the repository method in a client span until the returned
+// func is called. Documents read with the returned context are counted
+// against method; a method called by another one counts its own reads.
+func observe(ctx context.Context, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "firestore."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(string(semconv.DBSystemKey), "firestore"),
+			semconv.DBOperationName(method),
+		),
+	)
+	current := &call{method: method}
+	return context.WithValue(ctx, callKey{}, current), func() {
+		operationDuration.Observe(time.Since(start).Seconds(), method)
+		span.SetAttributes(attribute.Int("db.firestore.documents_read", current.reads))
+		span.End()
+	}
+}
+
+// countReads records n documents read by the method observing ctx.
+func countReads(ctx context.Context, n int) {
+	if current, ok := ctx.Value(callKey{}).(*call); ok && n > 0 {
+		current.reads += n
+		documentsRead.Add(float64(n), current.method)
+	}
+}
+
+// traceRealtime wraps a Realtime Database method in a client span.
+func traceRealtime(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "rtdb."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(string(semconv.DBSystemKey), "firebase_realtime_database"),
+			semconv.DBOperationName(method),
+		),
+	)
+}
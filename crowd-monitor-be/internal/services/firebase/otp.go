@@ -0,0 +1,40 @@
+package firebase
+
+import (
+	"context"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+func (c *Client) SaveOTP(ctx context.Context, code models.OTPCode) error {
+	ctx, done := observe(ctx, "SaveOTP")
+	defer done()
+
+	_, err := c.firestore.Collection("otp-codes").Doc(code.PhoneNumber).Set(ctx, code)
+	return err
+}
+
+func (c *Client) GetOTP(ctx context.Context, phoneNumber string) (*models.OTPCode, error) {
+	ctx, done := observe(ctx, "GetOTP")
+	defer done()
+
+	doc, err := c.firestore.Collection("otp-codes").Doc(phoneNumber).Get(ctx)
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	countReads(ctx, 1)
+
+	var code models.OTPCode
+	if err := doc.DataTo(&code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (c *Client) DeleteOTP(ctx context.Context, phoneNumber string) error {
+	ctx, done := observe(ctx, "DeleteOTP")
+	defer done()
+
+	_, err := c.firestore.Collection("otp-codes").Doc(phoneNumber).Delete(ctx)
+	return err
+}
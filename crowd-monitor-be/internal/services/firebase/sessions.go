@@ -0,0 +1,130 @@
+package firebase
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+func (c *Client) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	ctx, done := observe(ctx, "GetSession")
+	defer done()
+
+	doc, err := c.firestore.Collection("sessions").Doc(sessionID).Get(ctx)
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	countReads(ctx, 1)
+
+	var session models.Session
+	if err := doc.DataTo(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (c *Client) CreateSession(ctx context.Context, session models.Session) error {
+	ctx, done := observe(ctx, "CreateSession")
+	defer done()
+
+	_, err := c.firestore.Collection("sessions").Doc(session.ID).Create(ctx, session)
+	return mapStatusError(err)
+}
+
+func (c *Client) UpdateSession(ctx context.Context, sessionID string, update func(*models.Session) error) (*models.Session, error) {
+	ctx, done := observe(ctx, "UpdateSession")
+	defer done()
+
+	ref := c.firestore.Collection("sessions").Doc(sessionID)
+	var session models.Session
+	err := c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return mapStatusError(err)
+		}
+		countReads(ctx, 1)
+		session = models.Session{}
+		if err := doc.DataTo(&session); err != nil {
+			return err
+		}
+		if err := update(&session); err != nil {
+			return err
+		}
+		return tx.Set(ref, session)
+	})
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	return &session, nil
+}
+
+// ListSessions needs the sessions index in firestore.indexes.json.
+func (c *Client) ListSessions(ctx context.Context, phoneNumber string) ([]models.Session, error) {
+	ctx, done := observe(ctx, "ListSessions")
+	defer done()
+
+	iter := c.firestore.Collection("sessions").
+		Where("phone_number", "==", phoneNumber).
+		OrderBy("created_at", firestore.Desc).
+		Documents(ctx)
+	defer iter.Stop()
+	sessions := []models.Session{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, 1)
+
+		var session models.Session
+		if err := doc.DataTo(&session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (c *Client) SaveRevocation(ctx context.Context, revocation models.Revocation) error {
+	ctx, done := observe(ctx, "SaveRevocation")
+	defer done()
+
+	_, err := c.firestore.Collection("revocations").Doc(revocation.ID).Set(ctx, revocation)
+	return err
+}
+
+// ListRevocations leaves expired revocations in place; a Firestore TTL
+// policy on expires_at can remove them.
+func (c *Client) ListRevocations(ctx context.Context, now time.Time) ([]models.Revocation, error) {
+	ctx, done := observe(ctx, "ListRevocations")
+	defer done()
+
+	iter := c.firestore.Collection("revocations").Where("expires_at", ">", now).Documents(ctx)
+	defer iter.Stop()
+	revocations := []models.Revocation{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, 1)
+
+		var revocation models.Revocation
+		if err := doc.DataTo(&revocation); err != nil {
+			return nil, err
+		}
+		revocations = append(revocations, revocation)
+	}
+	return revocations, nil
+}
@@ -0,0 +1,90 @@
+package firebase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/schema"
+)
+
+var _ repository.VideoAnalysisWatcher = (*Client)(nil)
+
+const (
+	// listenerRefresh restarts the snapshot listener periodically so the
+	// created_at lower bound advances and the listener's result set, which
+	// Firestore keeps in memory, stays small.
+	listenerRefresh = time.Hour
+	maxWatchBackoff = time.Minute
+)
+
+// WatchVideoAnalyses listens to documents whose created_at is later than the
+// newest one delivered so far, so a reconnect neither replays nor misses new
+// analyses. Updates to analyses created before the latest (re)connect are
+// not observed. Documents with legacy string created_at values never match.
+func (c *Client) WatchVideoAnalyses(ctx context.Context, fn func(repository.VideoAnalysisChange)) error {
+	since := time.Now().UTC()
+	backoff := time.Second
+	c.setWatchErr(errors.New("listener not connected yet"))
+	defer c.setWatchErr(errors.New("listener stopped"))
+
+	for {
+		listenCtx, cancel := context.WithTimeout(ctx, listenerRefresh)
+		err := c.watchVideoAnalysesSince(listenCtx, &since, fn)
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if listenCtx.Err() == context.DeadlineExceeded {
+			backoff = time.Second
+			continue
+		}
+
+		c.setWatchErr(err)
+		slog.WarnContext(ctx, "Video analysis listener stopped, reconnecting", "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxWatchBackoff {
+			backoff = maxWatchBackoff
+		}
+	}
+}
+
+func (c *Client) watchVideoAnalysesSince(ctx context.Context, since *time.Time, fn func(repository.VideoAnalysisChange)) error {
+	iter := c.firestore.Collection("video-analysis").Where("created_at", ">", *since).Snapshots(ctx)
+	defer iter.Stop()
+
+	for {
+		snap, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		c.setWatchErr(nil)
+
+		for _, change := range snap.Changes {
+			if change.Kind == firestore.DocumentRemoved {
+				continue
+			}
+			analysis, err := schema.DecodeVideoAnalysis(change.Doc.Ref.ID, change.Doc.Data())
+			if err != nil {
+				slog.WarnContext(ctx, "Ignoring change to invalid document", "error", err)
+				continue
+			}
+
+			fn(repository.VideoAnalysisChange{
+				Created:  change.Kind == firestore.DocumentAdded,
+				Analysis: analysis,
+			})
+			if analysis.CreatedAt.After(*since) {
+				*since = analysis.CreatedAt
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package firebase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"google.golang.org/api/iterator"
+)
+
+// zoneDocument is the stored form of models.Zone. Firestore cannot hold the
+// nested arrays of GeoJSON coordinates, so the geometry is kept as GeoJSON
+// text.
+type zoneDocument struct {
+	ID               string    `firestore:"id"`
+	Name             string    `firestore:"name"`
+	Department       string    `firestore:"department"`
+	Geometry         string    `firestore:"geometry"`
+	AreaSquareMeters float64   `firestore:"area_m2"`
+	WarningCapacity  int       `firestore:"warning_capacity"`
+	SafeCapacity     int       `firestore:"safe_capacity"`
+	CreatedAt        time.Time `firestore:"created_at"`
+	UpdatedAt        time.Time `firestore:"updated_at"`
+}
+
+func newZoneDocument(zone models.Zone) (zoneDocument, error) {
+	geometry, err := json.Marshal(zone.Geometry)
+	if err != nil {
+		return zoneDocument{}, err
+	}
+	return zoneDocument{
+		ID:               zone.ID,
+		Name:             zone.Name,
+		Department:       zone.Department,
+		Geometry:         string(geometry),
+		AreaSquareMeters: zone.AreaSquareMeters,
+		WarningCapacity:  zone.WarningCapacity,
+		SafeCapacity:     zone.SafeCapacity,
+		CreatedAt:        zone.CreatedAt,
+		UpdatedAt:        zone.UpdatedAt,
+	}, nil
+}
+
+func decodeZone(doc *firestore.DocumentSnapshot) (models.Zone, error) {
+	var stored zoneDocument
+	if err := doc.DataTo(&stored); err != nil {
+		return models.Zone{}, err
+	}
+	var geometry geo.Geometry
+	if err := json.Unmarshal([]byte(stored.Geometry), &geometry); err != nil {
+		return models.Zone{}, fmt.Errorf("zone %s has invalid geometry: %v", doc.Ref.ID, err)
+	}
+	return models.Zone{
+		ID:               stored.ID,
+		Name:             stored.Name,
+		Department:       stored.Department,
+		Geometry:         geometry,
+		AreaSquareMeters: stored.AreaSquareMeters,
+		WarningCapacity:  stored.WarningCapacity,
+		SafeCapacity:     stored.SafeCapacity,
+		CreatedAt:        stored.CreatedAt,
+		UpdatedAt:        stored.UpdatedAt,
+	}, nil
+}
+
+func (c *Client) GetZone(ctx context.Context, zoneID string) (*models.Zone, error) {
+	ctx, done := observe(ctx, "GetZone")
+	defer done()
+
+	doc, err := c.firestore.Collection("zones").Doc(zoneID).Get(ctx)
+	if err != nil {
+		return nil, mapStatusError(err)
+	}
+	countReads(ctx, 1)
+
+	zone, err := decodeZone(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !repository.ScopeFromContext(ctx).Allows(zone.Department) {
+		return nil, repository.ErrNotFound
+	}
+	return &zone, nil
+}
+
+// ListZones applies a department scope while scanning; there are few enough
+// zones that it is not worth an index.
+func (c *Client) ListZones(ctx context.Context) ([]models.Zone, error) {
+	ctx, done := observe(ctx, "ListZones")
+	defer done()
+
+	iter := c.firestore.Collection("zones").OrderBy("id", firestore.Asc).Documents(ctx)
+	scope := repository.ScopeFromContext(ctx)
+	zones := []models.Zone{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		countReads(ctx, 1)
+
+		zone, err := decodeZone(doc)
+		if err != nil {
+			return nil, err
+		}
+		if scope.Allows(zone.Department) {
+			zones = append(zones, zone)
+		}
+	}
+	return zones, nil
+}
+
+func (c *Client) CreateZone(ctx context.Context, zone models.Zone) error {
+	ctx, done := observe(ctx, "CreateZone")
+	defer done()
+
+	if !repository.ScopeFromContext(ctx).Allows(zone.Department) {
+		return repository.ErrOutOfScope
+	}
+	stored, err := newZoneDocument(zone)
+	if err != nil {
+		return err
+	}
+	_, err = c.firestore.Collection("zones").Doc(zone.ID).Create(ctx, stored)
+	return mapStatusError(err)
+}
+
+func (c *Client) UpdateZone(ctx context.Context, zone models.Zone) error {
+	ctx, done := observe(ctx, "UpdateZone")
+	defer done()
+
+	scope := repository.ScopeFromContext(ctx)
+	if !scope.Allows(zone.Department) {
+		return repository.ErrOutOfScope
+	}
+	stored, err := newZoneDocument(zone)
+	if err != nil {
+		return err
+	}
+	ref := c.firestore.Collection("zones").Doc(zone.ID)
+	if !scope.Restricted() {
+		return c.replaceExisting(ctx, ref, stored)
+	}
+
+	err = c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := checkZoneScope(ctx, tx, ref, scope); err != nil {
+			return err
+		}
+		return tx.Set(ref, stored)
+	})
+	return mapStatusError(err)
+}
+
+func (c *Client) DeleteZone(ctx context.Context, zoneID string) error {
+	ctx, done := observe(ctx, "DeleteZone")
+	defer done()
+
+	ref := c.firestore.Collection("zones").Doc(zoneID)
+	scope := repository.ScopeFromContext(ctx)
+	if !scope.Restricted() {
+		_, err := ref.Delete(ctx, firestore.Exists)
+		return mapStatusError(err)
+	}
+
+	err := c.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := checkZoneScope(ctx, tx, ref, scope); err != nil {
+			return err
+		}
+		return tx.Delete(ref)
+	})
+	return mapStatusError(err)
+}
+
+// checkZoneScope reads the stored zone in tx and reports it as not found
+// when it belongs to a department outside scope.
+func checkZoneScope(ctx context.Context, tx *firestore.Transaction, ref *firestore.DocumentRef, scope repository.Scope) error {
+	doc, err := tx.Get(ref)
+	if err != nil {
+		return err
+	}
+	countReads(ctx, 1)
+	department, _ := doc.Data()["department"].(string)
+	if !scope.Allows(department) {
+		return repository.ErrNotFound
+	}
+	return nil
+}
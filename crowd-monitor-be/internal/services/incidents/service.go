@@ -0,0 +1,304 @@
+// Package incidents manages the lifecycle of incidents raised from alerts or
+// by officers: status transitions, assignment, notes and linked analyses.
+package incidents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/officers"
+)
+
+// SystemActor is recorded on timeline events not caused by an officer.
+const SystemActor = "system"
+
+var (
+	// ErrInvalidTransition is returned for a status change the lifecycle
+	// does not allow.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrInvalidRequest is returned when the change refers to unknown
+	// analyses or officers or is otherwise malformed.
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+type Service struct {
+	repo     repository.IncidentRepository
+	analyses repository.VideoAnalysisRepository
+	users    repository.UserRepository
+	tracker  *officers.Tracker
+	now      func() time.Time
+}
+
+func NewIncidentService(repo repository.IncidentRepository, analyses repository.VideoAnalysisRepository,
+	users repository.UserRepository, tracker *officers.Tracker) *Service {
+	return &Service{
+		repo:     repo,
+		analyses: analyses,
+		users:    users,
+		tracker:  tracker,
+		now:      time.Now,
+	}
+}
+
+// NewIncident describes a manually reported incident.
+type NewIncident struct {
+	Title       string
+	Description string
+	Department  string
+	VideoIDs    []string
+	// Location defaults to the first linked analysis.
+	Location *models.GeoPoint
+}
+
+// Create opens an incident after checking that every linked analysis
+// exists.
+func (s *Service) Create(ctx context.Context, actor string, req NewIncident) (*models.Incident, error) {
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidRequest)
+	}
+	videoIDs := dedupe(req.VideoIDs)
+	location := req.Location
+	for _, id := range videoIDs {
+		analysis, err := s.analyses.GetVideoAnalysisByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: video analysis %s not found", ErrInvalidRequest, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if location == nil {
+			location = &models.GeoPoint{Latitude: analysis.Location.Latitude, Longitude: analysis.Location.Longitude}
+		}
+	}
+
+	now := s.now().UTC()
+	incident := models.Incident{
+		ID:          uuid.NewString(),
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      models.IncidentStatusOpen,
+		Department:  req.Department,
+		Location:    location,
+		VideoIDs:    videoIDs,
+		CreatedBy:   actor,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Timeline: []models.IncidentEvent{{
+			Time: now, Actor: actor, Type: models.IncidentEventCreated, ToStatus: models.IncidentStatusOpen, VideoIDs: videoIDs,
+		}},
+	}
+	if err := s.repo.CreateIncident(ctx, incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// OpenFromAlert opens an incident for a newly raised alert, assigns it to
+// the nearest available officer of the department if there is one, and
+// returns its ID.
+func (s *Service) OpenFromAlert(ctx context.Context, alert models.Alert) (string, error) {
+	now := s.now().UTC()
+	location := alert.Location
+	incident := models.Incident{
+		ID:          uuid.NewString(),
+		Title:       fmt.Sprintf("%s: %s crowd", alert.RuleName, alert.CrowdLevel),
+		Description: fmt.Sprintf("Opened automatically by alert %s.", alert.ID),
+		Status:      models.IncidentStatusOpen,
+		Department:  alert.Department,
+		Location:    &location,
+		VideoIDs:    dedupe(alert.VideoIDs),
+		AlertID:     alert.ID,
+		CreatedBy:   SystemActor,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Timeline: []models.IncidentEvent{{
+			Time: now, Actor: SystemActor, Type: models.IncidentEventCreated, ToStatus: models.IncidentStatusOpen,
+			Message: fmt.Sprintf("Raised by alert rule %s", alert.RuleName), VideoIDs: dedupe(alert.VideoIDs),
+		}},
+	}
+	if err := s.repo.CreateIncident(ctx, incident); err != nil {
+		return "", err
+	}
+	if _, err := s.AssignNearest(ctx, SystemActor, incident.ID); err != nil {
+		slog.WarnContext(ctx, "Incident left unassigned", "incident_id", incident.ID, "error", err)
+	}
+	return incident.ID, nil
+}
+
+// AddAlertOccurrence links another matching analysis to an alert's incident
+// unless the incident is already closed.
+func (s *Service) AddAlertOccurrence(ctx context.Context, incidentID, videoID string) error {
+	_, err := s.repo.UpdateIncident(ctx, incidentID, func(incident *models.Incident) error {
+		if incident.Status == models.IncidentStatusClosed {
+			return nil
+		}
+		s.link(incident, SystemActor, []string{videoID})
+		return nil
+	})
+	return err
+}
+
+// Transition moves the incident to status, recording an optional note.
+// Dispatching requires an assigned officer.
+func (s *Service) Transition(ctx context.Context, actor, incidentID, status, note string) (*models.Incident, error) {
+	if !models.IsIncidentStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidRequest, status)
+	}
+	return s.repo.UpdateIncident(ctx, incidentID, func(incident *models.Incident) error {
+		from := incident.Status
+		if !models.CanTransitionIncident(from, status) {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, status)
+		}
+		if status == models.IncidentStatusDispatched && incident.AssignedTo == nil {
+			return fmt.Errorf("%w: assign an officer before dispatching", ErrInvalidTransition)
+		}
+
+		now := s.now().UTC()
+		incident.Status = status
+		switch status {
+		case models.IncidentStatusResolved:
+			incident.ResolvedAt = now
+		case models.IncidentStatusClosed:
+			incident.ClosedAt = now
+		case models.IncidentStatusOpen:
+			incident.ResolvedAt = time.Time{}
+		}
+		incident.UpdatedAt = now
+		incident.Timeline = append(incident.Timeline, models.IncidentEvent{
+			Time: now, Actor: actor, Type: models.IncidentEventStatusChanged, FromStatus: from, ToStatus: status, Message: note,
+		})
+		return nil
+	})
+}
+
+// Assign makes the officer with phoneNumber responsible for the incident.
+func (s *Service) Assign(ctx context.Context, actor, incidentID, phoneNumber string) (*models.Incident, error) {
+	officer, err := s.users.GetUserByPhoneNumber(ctx, phoneNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: officer %s not found", ErrInvalidRequest, phoneNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.UpdateIncident(ctx, incidentID, func(incident *models.Incident) error {
+		if incident.Status == models.IncidentStatusClosed {
+			return fmt.Errorf("%w: incident is closed", ErrInvalidTransition)
+		}
+		now := s.now().UTC()
+		incident.AssignedTo = officer
+		incident.UpdatedAt = now
+		incident.Timeline = append(incident.Timeline, models.IncidentEvent{
+			Time: now, Actor: actor, Type: models.IncidentEventAssigned, AssignedTo: officer.PhoneNumber,
+			Message: fmt.Sprintf("Assigned to %s %s", officer.Rank, officer.Name),
+		})
+		return nil
+	})
+}
+
+// AssignNearest assigns the incident to the closest available officer of
+// its department (any department when it has none).
+func (s *Service) AssignNearest(ctx context.Context, actor, incidentID string) (*models.Incident, error) {
+	incident, err := s.repo.GetIncident(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	if incident.Location == nil {
+		return nil, fmt.Errorf("%w: incident has no location", ErrInvalidRequest)
+	}
+
+	nearby, err := s.tracker.Nearest(ctx, incident.Location.Latitude, incident.Location.Longitude, 0, incident.Department, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(nearby) == 0 {
+		return nil, fmt.Errorf("%w: no available officer with a recent location", ErrInvalidRequest)
+	}
+	return s.Assign(ctx, actor, incidentID, nearby[0].Location.PhoneNumber)
+}
+
+// AddNote appends a free-text note to the timeline. Notes may be added to
+// closed incidents.
+func (s *Service) AddNote(ctx context.Context, actor, incidentID, message string) (*models.Incident, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("%w: message is required", ErrInvalidRequest)
+	}
+	return s.repo.UpdateIncident(ctx, incidentID, func(incident *models.Incident) error {
+		now := s.now().UTC()
+		incident.UpdatedAt = now
+		incident.Timeline = append(incident.Timeline, models.IncidentEvent{
+			Time: now, Actor: actor, Type: models.IncidentEventNote, Message: message,
+		})
+		return nil
+	})
+}
+
+// LinkAnalyses adds analyses to the incident after checking they exist.
+func (s *Service) LinkAnalyses(ctx context.Context, actor, incidentID string, videoIDs []string) (*models.Incident, error) {
+	videoIDs = dedupe(videoIDs)
+	if len(videoIDs) == 0 {
+		return nil, fmt.Errorf("%w: video_ids is required", ErrInvalidRequest)
+	}
+	for _, id := range videoIDs {
+		_, err := s.analyses.GetVideoAnalysisByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: video analysis %s not found", ErrInvalidRequest, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.UpdateIncident(ctx, incidentID, func(incident *models.Incident) error {
+		if incident.Status == models.IncidentStatusClosed {
+			return fmt.Errorf("%w: incident is closed", ErrInvalidTransition)
+		}
+		s.link(incident, actor, videoIDs)
+		return nil
+	})
+}
+
+// link adds the video IDs not already on the incident and records them.
+func (s *Service) link(incident *models.Incident, actor string, videoIDs []string) {
+	var added []string
+	for _, id := range videoIDs {
+		if !contains(incident.VideoIDs, id) {
+			incident.VideoIDs = append(incident.VideoIDs, id)
+			added = append(added, id)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	now := s.now().UTC()
+	incident.UpdatedAt = now
+	incident.Timeline = append(incident.Timeline, models.IncidentEvent{
+		Time: now, Actor: actor, Type: models.IncidentEventAnalysesLinked, VideoIDs: added,
+	})
+}
+
+func dedupe(ids []string) []string {
+	out := []string{}
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" && !contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package incidents
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+	"github.com/jimil-28/crowd-monitor/internal/services/officers"
+)
+
+const (
+	department = "Mapusa Police Department"
+	actor      = "+919405061349"
+	officer    = "+919405061350"
+)
+
+func newTestService(t *testing.T) (*Service, *officers.Tracker) {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewMemoryClient()
+	for _, user := range []models.User{
+		{PhoneNumber: actor, Name: "Kavita Naik", Rank: "PI", Department: department},
+		{PhoneNumber: officer, Name: "Anil Gaonkar", Rank: "ASI", Department: department},
+	} {
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	err := store.CreateVideoAnalysis(ctx, models.VideoAnalysis{
+		VideoID:  "v1",
+		Location: models.Location{Latitude: 15.5439, Longitude: 73.7553},
+	})
+	if err != nil {
+		t.Fatalf("CreateVideoAnalysis: %v", err)
+	}
+	tracker := officers.NewTracker(store, store, time.Hour)
+	return NewIncidentService(store, store, store, tracker), tracker
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     NewIncident
+		wantErr error
+	}{
+		{"title required", NewIncident{Title: "  "}, ErrInvalidRequest},
+		{"unknown analysis", NewIncident{Title: "Crowd", VideoIDs: []string{"missing"}}, ErrInvalidRequest},
+		{"location from the analysis", NewIncident{Title: "Crowd", VideoIDs: []string{"v1", "v1"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			incident, err := s.Create(context.Background(), actor, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if incident.Status != models.IncidentStatusOpen || len(incident.Timeline) != 1 {
+				t.Errorf("new incident = %+v", incident)
+			}
+			if len(incident.VideoIDs) != 1 || incident.Location == nil || incident.Location.Latitude != 15.5439 {
+				t.Errorf("linked %v at %+v, want v1 once at its location", incident.VideoIDs, incident.Location)
+			}
+		})
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	incident, err := s.Create(ctx, actor, NewIncident{Title: "Crowd at the gate", VideoIDs: []string{"v1"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := incident.ID
+
+	steps := []struct {
+		name    string
+		do      func() (*models.Incident, error)
+		wantErr error
+		status  string
+	}{
+		{"dispatch from open", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusDispatched, "")
+		}, ErrInvalidTransition, models.IncidentStatusOpen},
+		{"unknown status", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, "cancelled", "")
+		}, ErrInvalidRequest, models.IncidentStatusOpen},
+		{"acknowledge", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusAcknowledged, "on it")
+		}, nil, models.IncidentStatusAcknowledged},
+		{"dispatch without an officer", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusDispatched, "")
+		}, ErrInvalidTransition, models.IncidentStatusAcknowledged},
+		{"assign an unknown officer", func() (*models.Incident, error) {
+			return s.Assign(ctx, actor, id, "+919000000000")
+		}, ErrInvalidRequest, models.IncidentStatusAcknowledged},
+		{"assign", func() (*models.Incident, error) {
+			return s.Assign(ctx, actor, id, officer)
+		}, nil, models.IncidentStatusAcknowledged},
+		{"dispatch", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusDispatched, "")
+		}, nil, models.IncidentStatusDispatched},
+		{"resolve", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusResolved, "")
+		}, nil, models.IncidentStatusResolved},
+		{"reopen", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusOpen, "crowd is back")
+		}, nil, models.IncidentStatusOpen},
+		{"resolve again", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusResolved, "")
+		}, nil, models.IncidentStatusResolved},
+		{"close", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusClosed, "")
+		}, nil, models.IncidentStatusClosed},
+		{"reopen a closed incident", func() (*models.Incident, error) {
+			return s.Transition(ctx, actor, id, models.IncidentStatusOpen, "")
+		}, ErrInvalidTransition, models.IncidentStatusClosed},
+		{"assign a closed incident", func() (*models.Incident, error) {
+			return s.Assign(ctx, actor, id, officer)
+		}, ErrInvalidTransition, models.IncidentStatusClosed},
+		{"link to a closed incident", func() (*models.Incident, error) {
+			return s.LinkAnalyses(ctx, actor, id, []string{"v1"})
+		}, ErrInvalidTransition, models.IncidentStatusClosed},
+		{"note on a closed incident", func() (*models.Incident, error) {
+			return s.AddNote(ctx, actor, id, "crowd dispersed")
+		}, nil, models.IncidentStatusClosed},
+	}
+	for _, step := range steps {
+		if _, err := step.do(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		incident, err := s.repo.GetIncident(ctx, id)
+		if err != nil {
+			t.Fatalf("GetIncident: %v", err)
+		}
+		if incident.Status != step.status {
+			t.Fatalf("%s: status = %s, want %s", step.name, incident.Status, step.status)
+		}
+	}
+
+	incident, _ = s.repo.GetIncident(ctx, id)
+	var types []string
+	for _, event := range incident.Timeline {
+		types = append(types, event.Type)
+	}
+	want := []string{"created", "status_changed", "assigned", "status_changed", "status_changed",
+		"status_changed", "status_changed", "status_changed", "note"}
+	if len(types) != len(want) {
+		t.Fatalf("timeline = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("timeline[%d] = %s, want %s", i, types[i], want[i])
+		}
+	}
+	if incident.ClosedAt.IsZero() || incident.ResolvedAt.IsZero() {
+		t.Errorf("resolved_at %v, closed_at %v, want both set", incident.ResolvedAt, incident.ClosedAt)
+	}
+}
+
+func TestOpenFromAlertAssignsNearest(t *testing.T) {
+	s, tracker := newTestService(t)
+	ctx := context.Background()
+	for phone, lat := range map[string]float64{actor: 15.60, officer: 15.55} {
+		if _, err := tracker.RecordPing(ctx, phone, officers.Ping{Latitude: lat, Longitude: 73.7553, Available: true}); err != nil {
+			t.Fatalf("RecordPing: %v", err)
+		}
+	}
+
+	id, err := s.OpenFromAlert(ctx, models.Alert{
+		ID:         "a1",
+		RuleName:   "High crowd",
+		CrowdLevel: "high",
+		Department: department,
+		Location:   models.GeoPoint{Latitude: 15.5439, Longitude: 73.7553},
+		VideoIDs:   []string{"v1"},
+	})
+	if err != nil {
+		t.Fatalf("OpenFromAlert: %v", err)
+	}
+	if err := s.AddAlertOccurrence(ctx, id, "v2"); err != nil {
+		t.Fatalf("AddAlertOccurrence: %v", err)
+	}
+
+	incident, err := s.repo.GetIncident(ctx, id)
+	if err != nil {
+		t.Fatalf("GetIncident: %v", err)
+	}
+	if incident.AssignedTo == nil || incident.AssignedTo.PhoneNumber != officer {
+		t.Errorf("assigned to %+v, want the nearest officer %s", incident.AssignedTo, officer)
+	}
+	if incident.AlertID != "a1" || len(incident.VideoIDs) != 2 {
+		t.Errorf("alert %q with analyses %v, want a1 with v1 and v2", incident.AlertID, incident.VideoIDs)
+	}
+}
@@ -0,0 +1,193 @@
+// Package ingest validates and stores video analyses submitted by edge
+// analyzers.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/schema"
+)
+
+// Item outcomes reported in Result.Status.
+const (
+	StatusCreated   = "created"
+	StatusDuplicate = "duplicate"
+	StatusInvalid   = "invalid"
+	StatusFailed    = "failed"
+)
+
+// Result is the outcome of ingesting one submitted analysis.
+type Result struct {
+	Index   int                 `json:"index"`
+	VideoID string              `json:"video_id,omitempty"`
+	Status  string              `json:"status"`
+	Errors  []schema.FieldError `json:"errors,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// ZoneLocator finds the zones containing a point and the department whose
+// jurisdiction they fall in.
+type ZoneLocator interface {
+	Locate(ctx context.Context, lat, lon float64) (zoneIDs []string, department string, err error)
+}
+
+type Service struct {
+	repo    repository.VideoAnalysisRepository
+	cameras repository.CameraRepository
+	zones   ZoneLocator
+	now     func() time.Time
+}
+
+func NewIngestService(repo repository.VideoAnalysisRepository, cameras repository.CameraRepository, zones ZoneLocator) *Service {
+	return &Service{
+		repo:    repo,
+		cameras: cameras,
+		zones:   zones,
+		now:     time.Now,
+	}
+}
+
+// Ingest validates one JSON-encoded analysis and stores it unless an
+// analysis with the same video_id already exists. created_at, zone_ids and
+// department are always set by the server; any client values are ignored.
+// The department is the camera's, or else the zones' for analyses not
+// linked to a camera.
+func (s *Service) Ingest(ctx context.Context, deviceID string, index int, raw []byte) Result {
+	result := Result{Index: index}
+
+	analysis, fieldErrs := s.decode(raw)
+	result.VideoID = analysis.VideoID
+	if len(fieldErrs) > 0 {
+		result.Status = StatusInvalid
+		result.Errors = fieldErrs
+		return result
+	}
+
+	camera, fieldErr, err := s.resolveCamera(ctx, deviceID, analysis.CameraID)
+	if fieldErr != nil {
+		result.Status = StatusInvalid
+		result.Errors = []schema.FieldError{*fieldErr}
+		return result
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look up camera for analysis", "video_id", analysis.VideoID, "device_id", deviceID, "error", err)
+		result.Status = StatusFailed
+		result.Error = "failed to look up camera"
+		return result
+	}
+	var cameraID string
+	if camera != nil {
+		cameraID = camera.ID
+	}
+	analysis.CameraID = cameraID
+
+	// An analysis is still stored if its zones cannot be resolved.
+	var zoneDepartment string
+	analysis.ZoneIDs, zoneDepartment, err = s.zones.Locate(ctx, analysis.Location.Latitude, analysis.Location.Longitude)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to resolve zones for analysis", "video_id", analysis.VideoID, "error", err)
+	}
+	analysis.Department = zoneDepartment
+	if camera != nil && camera.Department != "" {
+		analysis.Department = camera.Department
+	}
+
+	analysis.CreatedAt = s.now().UTC()
+	err = s.repo.CreateVideoAnalysis(ctx, analysis)
+	switch {
+	case errors.Is(err, repository.ErrAlreadyExists):
+		result.Status = StatusDuplicate
+	case err != nil:
+		slog.ErrorContext(ctx, "Failed to store analysis", "video_id", analysis.VideoID, "device_id", deviceID, "error", err)
+		result.Status = StatusFailed
+		result.Error = "failed to store analysis"
+	default:
+		slog.InfoContext(ctx, "Stored analysis", "video_id", analysis.VideoID, "device_id", deviceID)
+		result.Status = StatusCreated
+		if cameraID != "" {
+			if err := s.cameras.RecordCameraAnalysis(ctx, cameraID, analysis.CreatedAt); err != nil {
+				slog.ErrorContext(ctx, "Failed to record analysis on camera", "camera_id", cameraID, "error", err)
+			}
+		}
+	}
+	return result
+}
+
+// resolveCamera links the analysis to a registered camera. A device may
+// only submit for its own camera, as with heartbeats, so an explicit
+// camera_id must match the device ID and be registered; without one the
+// device ID is used when it names a registered camera, and the analysis is
+// left unlinked otherwise.
+func (s *Service) resolveCamera(ctx context.Context, deviceID, cameraID string) (*models.Camera, *schema.FieldError, error) {
+	explicit := cameraID != ""
+	if explicit && cameraID != deviceID {
+		return nil, &schema.FieldError{Field: "camera_id", Message: "must be the submitting device's own camera"}, nil
+	}
+	cameraID = deviceID
+	if cameraID == "" {
+		return nil, nil, nil
+	}
+
+	camera, err := s.cameras.GetCamera(ctx, cameraID)
+	if errors.Is(err, repository.ErrNotFound) {
+		if explicit {
+			return nil, &schema.FieldError{Field: "camera_id", Message: "is not a registered camera"}, nil
+		}
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return camera, nil, nil
+}
+
+func (s *Service) decode(raw []byte) (models.VideoAnalysis, []schema.FieldError) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var data map[string]interface{}
+	if err := dec.Decode(&data); err != nil {
+		return models.VideoAnalysis{}, []schema.FieldError{{Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	analysis, err := schema.DecodeVideoAnalysis("", data)
+	if err != nil {
+		var docErr *schema.DocumentError
+		if errors.As(err, &docErr) {
+			id, _ := data["video_id"].(string)
+			return models.VideoAnalysis{VideoID: id}, docErr.Fields
+		}
+		return models.VideoAnalysis{}, []schema.FieldError{{Message: err.Error()}}
+	}
+	return analysis, validate(analysis)
+}
+
+// validate applies the rules the stored schema leaves open but new
+// submissions must meet.
+func validate(a models.VideoAnalysis) []schema.FieldError {
+	var errs []schema.FieldError
+	if a.VideoID == "" || strings.Contains(a.VideoID, "/") || len(a.VideoID) > 512 {
+		errs = append(errs, schema.FieldError{Field: "video_id", Message: "must be 1-512 characters without '/'"})
+	}
+	if a.Timestamp.IsZero() {
+		errs = append(errs, schema.FieldError{Field: "timestamp", Message: "is required"})
+	}
+	if a.VideoDuration < 0 {
+		errs = append(errs, schema.FieldError{Field: "video_duration", Message: "must not be negative"})
+	}
+	if a.Location.Latitude < -90 || a.Location.Latitude > 90 {
+		errs = append(errs, schema.FieldError{Field: "location.latitude", Message: "must be between -90 and 90"})
+	}
+	if a.Location.Longitude < -180 || a.Location.Longitude > 180 {
+		errs = append(errs, schema.FieldError{Field: "location.longitude", Message: "must be between -180 and 180"})
+	}
+	return errs
+}
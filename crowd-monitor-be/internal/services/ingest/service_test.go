@@ -0,0 +1,149 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+// fixedZones puts every point in the same zones.
+type fixedZones struct {
+	ids        []string
+	department string
+}
+
+func (z fixedZones) Locate(ctx context.Context, lat, lon float64) ([]string, string, error) {
+	return z.ids, z.department, nil
+}
+
+func newTestService(t *testing.T) (*Service, *memory.Client) {
+	t.Helper()
+	store := memory.NewMemoryClient()
+	s := NewIngestService(store, store, fixedZones{ids: []string{"z1"}, department: "Mapusa Police Department"})
+	s.now = func() time.Time { return time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC) }
+	return s, store
+}
+
+func TestIngestValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		status string
+		fields []string
+	}{
+		{"valid", `{"video_id":"v1","timestamp":"2026-10-18T09:00:00Z","location":{"latitude":15.5,"longitude":73.8}}`, StatusCreated, nil},
+		{"not JSON", `{`, StatusInvalid, []string{""}},
+		{"missing location", `{"video_id":"v1","timestamp":"2026-10-18T09:00:00Z"}`, StatusInvalid, []string{"location"}},
+		{"missing timestamp", `{"video_id":"v1","location":{"latitude":15.5,"longitude":73.8}}`, StatusInvalid, []string{"timestamp"}},
+		{"slash in video ID", `{"video_id":"a/b","timestamp":"2026-10-18T09:00:00Z","location":{"latitude":15.5,"longitude":73.8}}`, StatusInvalid, []string{"video_id"}},
+		{"negative duration", `{"video_id":"v1","video_duration":-1,"timestamp":"2026-10-18T09:00:00Z","location":{"latitude":15.5,"longitude":73.8}}`, StatusInvalid, []string{"video_duration"}},
+		{"coordinates out of range", `{"video_id":"v1","timestamp":"2026-10-18T09:00:00Z","location":{"latitude":91,"longitude":-181}}`, StatusInvalid, []string{"location.latitude", "location.longitude"}},
+		{"wrong type", `{"video_id":"v1","timestamp":"2026-10-18T09:00:00Z","location":{"latitude":"north","longitude":73.8}}`, StatusInvalid, []string{"location.latitude"}},
+		{"numeric string", `{"video_id":"v1","timestamp":"2026-10-18T09:00:00Z","location":{"latitude":"15.5","longitude":73.8}}`, StatusInvalid, []string{"location.latitude"}},
+		{"Unix timestamp", `{"video_id":"v1","timestamp":1760000000,"location":{"latitude":15.5,"longitude":73.8}}`, StatusInvalid, []string{"timestamp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			result := s.Ingest(context.Background(), "", 3, []byte(tt.raw))
+			if result.Status != tt.status || result.Index != 3 {
+				t.Fatalf("result = %+v, want status %s at index 3", result, tt.status)
+			}
+			if len(result.Errors) != len(tt.fields) {
+				t.Fatalf("errors = %+v, want fields %v", result.Errors, tt.fields)
+			}
+			for i, e := range result.Errors {
+				if e.Field != tt.fields[i] {
+					t.Errorf("error %d on %q, want %q", i, e.Field, tt.fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIngestStoresServerFields(t *testing.T) {
+	s, store := newTestService(t)
+	raw := `{"video_id":"v1","timestamp":"2026-10-18T09:00:00Z","location":{"latitude":15.5,"longitude":73.8},
+		"created_at":"2020-01-01T00:00:00Z","zone_ids":["forged"],"department":"Madgaon Police Department"}`
+
+	if result := s.Ingest(context.Background(), "", 0, []byte(raw)); result.Status != StatusCreated {
+		t.Fatalf("first submission: %+v", result)
+	}
+	if result := s.Ingest(context.Background(), "", 1, []byte(raw)); result.Status != StatusDuplicate || result.VideoID != "v1" {
+		t.Errorf("second submission: %+v, want a duplicate of v1", result)
+	}
+
+	stored, err := store.GetVideoAnalysisByID(context.Background(), "v1")
+	if err != nil {
+		t.Fatalf("GetVideoAnalysisByID: %v", err)
+	}
+	if !stored.CreatedAt.Equal(s.now()) {
+		t.Errorf("created_at = %v, want the server time %v", stored.CreatedAt, s.now())
+	}
+	if len(stored.ZoneIDs) != 1 || stored.ZoneIDs[0] != "z1" {
+		t.Errorf("zone_ids = %v, want [z1]", stored.ZoneIDs)
+	}
+	if stored.Department != "Mapusa Police Department" {
+		t.Errorf("department = %q, want the zones' department", stored.Department)
+	}
+}
+
+func TestIngestCameraOwnership(t *testing.T) {
+	tests := []struct {
+		name       string
+		deviceID   string
+		cameraID   string
+		status     string
+		linked     string
+		department string
+	}{
+		{"own camera", "cam1", "cam1", StatusCreated, "cam1", "Calangute Police Department"},
+		{"device ID as camera", "cam1", "", StatusCreated, "cam1", "Calangute Police Department"},
+		{"another device's camera", "cam1", "cam2", StatusInvalid, "", ""},
+		{"unregistered device", "edge9", "", StatusCreated, "", "Mapusa Police Department"},
+		{"unregistered camera", "edge9", "edge9", StatusInvalid, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestService(t)
+			for _, id := range []string{"cam1", "cam2"} {
+				err := store.CreateCamera(context.Background(), models.Camera{ID: id, Department: "Calangute Police Department"})
+				if err != nil {
+					t.Fatalf("CreateCamera: %v", err)
+				}
+			}
+
+			raw := `{"video_id":"v1","timestamp":"2026-10-18T09:00:00Z","location":{"latitude":15.5,"longitude":73.8}`
+			if tt.cameraID != "" {
+				raw += `,"camera_id":"` + tt.cameraID + `"`
+			}
+			result := s.Ingest(context.Background(), tt.deviceID, 0, []byte(raw+"}"))
+			if result.Status != tt.status {
+				t.Fatalf("result = %+v, want status %s", result, tt.status)
+			}
+			if tt.status != StatusCreated {
+				if len(result.Errors) != 1 || result.Errors[0].Field != "camera_id" {
+					t.Errorf("errors = %+v, want one on camera_id", result.Errors)
+				}
+				return
+			}
+
+			stored, err := store.GetVideoAnalysisByID(context.Background(), "v1")
+			if err != nil {
+				t.Fatalf("GetVideoAnalysisByID: %v", err)
+			}
+			if stored.CameraID != tt.linked || stored.Department != tt.department {
+				t.Errorf("stored camera %q in %q, want %q in %q", stored.CameraID, stored.Department, tt.linked, tt.department)
+			}
+			if tt.linked != "" {
+				camera, _ := store.GetCamera(context.Background(), tt.linked)
+				if !camera.LastAnalysisAt.Equal(s.now()) {
+					t.Errorf("camera last_analysis_at = %v, want %v", camera.LastAnalysisAt, s.now())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func (c *Client) GetAlertRule(ctx context.Context, ruleID string) (*models.AlertRule, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rule, ok := c.rules[ruleID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &rule, nil
+}
+
+func (c *Client) ListAlertRules(ctx context.Context) ([]models.AlertRule, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rules := make([]models.AlertRule, 0, len(c.rules))
+	for _, rule := range c.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+func (c *Client) CreateAlertRule(ctx context.Context, rule models.AlertRule) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.rules[rule.ID]; exists {
+		return repository.ErrAlreadyExists
+	}
+	c.rules[rule.ID] = rule
+	return nil
+}
+
+func (c *Client) UpdateAlertRule(ctx context.Context, rule models.AlertRule) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.rules[rule.ID]; !exists {
+		return repository.ErrNotFound
+	}
+	c.rules[rule.ID] = rule
+	return nil
+}
+
+func (c *Client) DeleteAlertRule(ctx context.Context, ruleID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.rules[ruleID]; !exists {
+		return repository.ErrNotFound
+	}
+	delete(c.rules, ruleID)
+	return nil
+}
+
+func (c *Client) GetAlert(ctx context.Context, alertID string) (*models.Alert, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	alert, ok := c.alerts[alertID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &alert, nil
+}
+
+func (c *Client) ListAlerts(ctx context.Context, status string, limit int) ([]models.Alert, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	alerts := []models.Alert{}
+	for _, alert := range c.alerts {
+		if status == "" || alert.Status == status {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].CreatedAt.After(alerts[j].CreatedAt) })
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts, nil
+}
+
+func (c *Client) CountAlerts(ctx context.Context, status string) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	n := 0
+	for _, alert := range c.alerts {
+		if alert.Status == status {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (c *Client) FindOpenAlert(ctx context.Context, dedupKey string) (*models.Alert, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, alert := range c.alerts {
+		if alert.DedupKey == dedupKey && alert.Status != models.AlertStatusResolved {
+			return &alert, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (c *Client) CreateAlert(ctx context.Context, alert models.Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.alerts[alert.ID]; exists {
+		return repository.ErrAlreadyExists
+	}
+	c.alerts[alert.ID] = alert
+	return nil
+}
+
+func (c *Client) UpdateAlert(ctx context.Context, alert models.Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.alerts[alert.ID]; !exists {
+		return repository.ErrNotFound
+	}
+	c.alerts[alert.ID] = alert
+	return nil
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func (c *Client) GetCamera(ctx context.Context, cameraID string) (*models.Camera, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	camera, ok := c.cameras[cameraID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &camera, nil
+}
+
+func (c *Client) ListCameras(ctx context.Context) ([]models.Camera, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cameras := make([]models.Camera, 0, len(c.cameras))
+	for _, camera := range c.cameras {
+		cameras = append(cameras, camera)
+	}
+	sort.Slice(cameras, func(i, j int) bool { return cameras[i].ID < cameras[j].ID })
+	return cameras, nil
+}
+
+func (c *Client) CreateCamera(ctx context.Context, camera models.Camera) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.cameras[camera.ID]; exists {
+		return repository.ErrAlreadyExists
+	}
+	c.cameras[camera.ID] = camera
+	return nil
+}
+
+func (c *Client) UpdateCamera(ctx context.Context, camera models.Camera) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.cameras[camera.ID]; !exists {
+		return repository.ErrNotFound
+	}
+	c.cameras[camera.ID] = camera
+	return nil
+}
+
+func (c *Client) DeleteCamera(ctx context.Context, cameraID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.cameras[cameraID]; !exists {
+		return repository.ErrNotFound
+	}
+	delete(c.cameras, cameraID)
+	return nil
+}
+
+func (c *Client) RecordCameraHeartbeat(ctx context.Context, cameraID string, at time.Time) error {
+	return c.updateCamera(cameraID, func(camera *models.Camera) {
+		camera.LastHeartbeatAt = at
+		camera.Health = models.CameraHealthOnline
+	})
+}
+
+func (c *Client) RecordCameraAnalysis(ctx context.Context, cameraID string, at time.Time) error {
+	return c.updateCamera(cameraID, func(camera *models.Camera) {
+		camera.LastAnalysisAt = at
+		camera.Health = models.CameraHealthOnline
+	})
+}
+
+func (c *Client) SetCameraHealth(ctx context.Context, cameraID, health string) error {
+	return c.updateCamera(cameraID, func(camera *models.Camera) {
+		camera.Health = health
+	})
+}
+
+func (c *Client) updateCamera(cameraID string, update func(*models.Camera)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	camera, ok := c.cameras[cameraID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	update(&camera)
+	c.cameras[cameraID] = camera
+	return nil
+}
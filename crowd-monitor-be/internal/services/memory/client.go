@@ -0,0 +1,397 @@
+// Package memory is an in-process implementation of the repository
+// interfaces. It lets the API run locally and in tests without Firestore
+// credentials; nothing is persisted across restarts.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+var (
+	_ repository.UserRepository          = (*Client)(nil)
+	_ repository.VideoAnalysisRepository = (*Client)(nil)
+	_ repository.CameraRepository        = (*Client)(nil)
+	_ repository.VideoAnalysisWatcher    = (*Client)(nil)
+	_ repository.AlertRuleRepository     = (*Client)(nil)
+	_ repository.AlertRepository         = (*Client)(nil)
+	_ repository.IncidentRepository      = (*Client)(nil)
+	_ repository.OfficerLocationStore    = (*Client)(nil)
+	_ repository.ZoneRepository          = (*Client)(nil)
+	_ repository.SessionRepository       = (*Client)(nil)
+	_ repository.OTPStore                = (*Client)(nil)
+)
+
+type Client struct {
+	mu          sync.RWMutex
+	users       map[string]models.User
+	analyses    map[string]models.VideoAnalysis
+	cameras     map[string]models.Camera
+	rules       map[string]models.AlertRule
+	alerts      map[string]models.Alert
+	incidents   map[string]models.Incident
+	locations   map[string]models.OfficerLocation
+	zones       map[string]models.Zone
+	sessions    map[string]models.Session
+	revocations map[string]models.Revocation
+	otpCodes    map[string]models.OTPCode
+
+	watchMu  sync.Mutex
+	watchers map[int]func(repository.VideoAnalysisChange)
+	nextID   int
+}
+
+// Seed is the layout of the optional JSON file loaded by LoadSeedFile.
+type Seed struct {
+	Users         []models.User          `json:"users"`
+	VideoAnalyses []models.VideoAnalysis `json:"video_analyses"`
+	Cameras       []models.Camera        `json:"cameras"`
+	AlertRules    []models.AlertRule     `json:"alert_rules"`
+	Zones         []models.Zone          `json:"zones"`
+}
+
+func NewMemoryClient() *Client {
+	return &Client{
+		users:       make(map[string]models.User),
+		analyses:    make(map[string]models.VideoAnalysis),
+		cameras:     make(map[string]models.Camera),
+		rules:       make(map[string]models.AlertRule),
+		alerts:      make(map[string]models.Alert),
+		incidents:   make(map[string]models.Incident),
+		locations:   make(map[string]models.OfficerLocation),
+		zones:       make(map[string]models.Zone),
+		sessions:    make(map[string]models.Session),
+		revocations: make(map[string]models.Revocation),
+		otpCodes:    make(map[string]models.OTPCode),
+		watchers:    make(map[int]func(repository.VideoAnalysisChange)),
+	}
+}
+
+// LoadSeedFile populates the store from a JSON file shaped like Seed.
+func (c *Client) LoadSeedFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read seed file: %v", err)
+	}
+
+	var seed Seed
+	if err := json.Unmarshal(raw, &seed); err != nil {
+		return fmt.Errorf("failed to parse seed file: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, user := range seed.Users {
+		c.users[user.PhoneNumber] = user
+	}
+	for _, analysis := range seed.VideoAnalyses {
+		analysis.Geohash = geo.EncodeGeohash(analysis.Location.Latitude, analysis.Location.Longitude, geo.GeohashPrecision)
+		c.analyses[analysis.VideoID] = analysis
+	}
+	for _, camera := range seed.Cameras {
+		c.cameras[camera.ID] = camera
+	}
+	for _, rule := range seed.AlertRules {
+		c.rules[rule.ID] = rule
+	}
+	for _, zone := range seed.Zones {
+		c.zones[zone.ID] = zone
+	}
+	return nil
+}
+
+func (c *Client) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*models.User, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	user, ok := c.users[phoneNumber]
+	if !ok || !repository.ScopeFromContext(ctx).Allows(user.Department) {
+		return nil, repository.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (c *Client) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	scope := repository.ScopeFromContext(ctx)
+	users := make([]models.User, 0, len(c.users))
+	for _, user := range c.users {
+		if scope.Allows(user.Department) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].PhoneNumber < users[j].PhoneNumber })
+	return users, nil
+}
+
+func (c *Client) SearchUsers(ctx context.Context, filter repository.UserFilter) ([]models.User, error) {
+	users, err := c.GetAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := []models.User{}
+	for _, user := range users {
+		if filter.Matches(user) {
+			matching = append(matching, user)
+		}
+	}
+	return matching, nil
+}
+
+func (c *Client) CreateUser(ctx context.Context, user models.User) error {
+	if !repository.ScopeFromContext(ctx).Allows(user.Department) {
+		return repository.ErrOutOfScope
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.users[user.PhoneNumber]; ok {
+		return repository.ErrAlreadyExists
+	}
+	if c.idCardTaken(user) {
+		return repository.ErrIDCardInUse
+	}
+	c.users[user.PhoneNumber] = user
+	return nil
+}
+
+// UpdateUser treats users of other departments as missing and refuses to
+// move a user out of the caller's department.
+func (c *Client) UpdateUser(ctx context.Context, phoneNumber string, update func(*models.User) error) (*models.User, error) {
+	scope := repository.ScopeFromContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	user, ok := c.users[phoneNumber]
+	if !ok || !scope.Allows(user.Department) {
+		return nil, repository.ErrNotFound
+	}
+	if err := update(&user); err != nil {
+		return nil, err
+	}
+	user.PhoneNumber = phoneNumber
+	if !scope.Allows(user.Department) {
+		return nil, repository.ErrOutOfScope
+	}
+	if c.idCardTaken(user) {
+		return nil, repository.ErrIDCardInUse
+	}
+	c.users[phoneNumber] = user
+	return &user, nil
+}
+
+func (c *Client) DeleteUser(ctx context.Context, phoneNumber string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	user, ok := c.users[phoneNumber]
+	if !ok || !repository.ScopeFromContext(ctx).Allows(user.Department) {
+		return repository.ErrNotFound
+	}
+	delete(c.users, phoneNumber)
+	return nil
+}
+
+func (c *Client) ImportUsers(ctx context.Context, users []models.User) error {
+	scope := repository.ScopeFromContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, user := range users {
+		existing, ok := c.users[user.PhoneNumber]
+		if !scope.Allows(user.Department) || (ok && !scope.Allows(existing.Department)) {
+			return repository.ErrOutOfScope
+		}
+	}
+	for _, user := range users {
+		c.users[user.PhoneNumber] = user
+	}
+	return nil
+}
+
+// idCardTaken reports whether another user holds user's ID card number.
+// c.mu must be held.
+func (c *Client) idCardTaken(user models.User) bool {
+	if user.IDCardNumber == "" {
+		return false
+	}
+	for _, other := range c.users {
+		if other.IDCardNumber == user.IDCardNumber && other.PhoneNumber != user.PhoneNumber {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) ListVideoAnalyses(ctx context.Context, q repository.VideoAnalysisQuery) (*repository.VideoAnalysisPage, error) {
+	filter := q.Filter
+	sortField := filter.SortField()
+	cursor, err := repository.DecodeCursor(q.Cursor, sortField)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := repository.ClampPageSize(q.PageSize)
+	scope := repository.ScopeFromContext(ctx)
+
+	c.mu.RLock()
+	sorted := make([]models.VideoAnalysis, 0, len(c.analyses))
+	for _, analysis := range c.analyses {
+		if scope.Allows(analysis.Department) {
+			sorted = append(sorted, analysis)
+		}
+	}
+	c.mu.RUnlock()
+
+	sortTime := func(a models.VideoAnalysis) time.Time {
+		if sortField == "created_at" {
+			return a.CreatedAt
+		}
+		return a.Timestamp
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		ti, tj := sortTime(sorted[i]), sortTime(sorted[j])
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return sorted[i].VideoID > sorted[j].VideoID
+	})
+
+	page := &repository.VideoAnalysisPage{Items: []models.VideoAnalysis{}}
+	for i, analysis := range sorted {
+		if cursor != nil {
+			cursorTime, _ := cursor.Value.(time.Time)
+			t := sortTime(analysis)
+			if t.After(cursorTime) || (t.Equal(cursorTime) && analysis.VideoID >= cursor.ID) {
+				continue
+			}
+		}
+		if !filter.Matches(analysis) {
+			continue
+		}
+		page.Items = append(page.Items, analysis)
+		if len(page.Items) == pageSize {
+			if i < len(sorted)-1 {
+				page.NextCursor = repository.EncodeCursor(repository.Cursor{
+					Field: sortField,
+					Value: sortTime(analysis),
+					ID:    analysis.VideoID,
+				})
+			}
+			break
+		}
+	}
+	return page, nil
+}
+
+func (c *Client) GetVideoAnalysisByID(ctx context.Context, videoID string) (*models.VideoAnalysis, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	analysis, ok := c.analyses[videoID]
+	if !ok || !repository.ScopeFromContext(ctx).Allows(analysis.Department) {
+		return nil, repository.ErrNotFound
+	}
+	return &analysis, nil
+}
+
+func (c *Client) GetVideoAnalysesNearby(ctx context.Context, lat, lon float64, radiusKm float64) ([]models.VideoAnalysis, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	scope := repository.ScopeFromContext(ctx)
+	var nearby []models.VideoAnalysis
+	for _, analysis := range c.analyses {
+		if scope.Allows(analysis.Department) && geo.Distance(lat, lon, analysis.Location.Latitude, analysis.Location.Longitude) <= radiusKm {
+			nearby = append(nearby, analysis)
+		}
+	}
+	repository.SortByDistance(nearby, lat, lon)
+	return nearby, nil
+}
+
+func (c *Client) GetVideoAnalysesInBoundingBox(ctx context.Context, box geo.BoundingBox) ([]models.VideoAnalysis, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	scope := repository.ScopeFromContext(ctx)
+	var inside []models.VideoAnalysis
+	for _, analysis := range c.analyses {
+		if scope.Allows(analysis.Department) && box.Contains(analysis.Location.Latitude, analysis.Location.Longitude) {
+			inside = append(inside, analysis)
+		}
+	}
+	return inside, nil
+}
+
+func (c *Client) SaveVideoAnalysis(ctx context.Context, analysis models.VideoAnalysis) error {
+	if !repository.ScopeFromContext(ctx).Allows(analysis.Department) {
+		return repository.ErrOutOfScope
+	}
+	analysis.Geohash = geo.EncodeGeohash(analysis.Location.Latitude, analysis.Location.Longitude, geo.GeohashPrecision)
+
+	c.mu.Lock()
+	_, existed := c.analyses[analysis.VideoID]
+	c.analyses[analysis.VideoID] = analysis
+	c.mu.Unlock()
+
+	c.notify(repository.VideoAnalysisChange{Created: !existed, Analysis: analysis})
+	return nil
+}
+
+func (c *Client) CreateVideoAnalysis(ctx context.Context, analysis models.VideoAnalysis) error {
+	if !repository.ScopeFromContext(ctx).Allows(analysis.Department) {
+		return repository.ErrOutOfScope
+	}
+	analysis.Geohash = geo.EncodeGeohash(analysis.Location.Latitude, analysis.Location.Longitude, geo.GeohashPrecision)
+
+	c.mu.Lock()
+	if _, exists := c.analyses[analysis.VideoID]; exists {
+		c.mu.Unlock()
+		return repository.ErrAlreadyExists
+	}
+	c.analyses[analysis.VideoID] = analysis
+	c.mu.Unlock()
+
+	c.notify(repository.VideoAnalysisChange{Created: true, Analysis: analysis})
+	return nil
+}
+
+func (c *Client) WatchVideoAnalyses(ctx context.Context, fn func(repository.VideoAnalysisChange)) error {
+	c.watchMu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.watchers[id] = fn
+	c.watchMu.Unlock()
+
+	<-ctx.Done()
+
+	c.watchMu.Lock()
+	delete(c.watchers, id)
+	c.watchMu.Unlock()
+	return ctx.Err()
+}
+
+// notify runs the watchers synchronously, outside the data lock, so a
+// watcher may read back from the store.
+func (c *Client) notify(change repository.VideoAnalysisChange) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+
+	for _, fn := range c.watchers {
+		fn(change)
+	}
+}
@@ -0,0 +1,361 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+const (
+	mapusa  = "Mapusa Police Department"
+	madgaon = "Madgaon Police Department"
+)
+
+func seededClient(t *testing.T) *Client {
+	t.Helper()
+	c := NewMemoryClient()
+	ctx := context.Background()
+	for _, user := range []models.User{
+		{PhoneNumber: "+919405061349", Name: "Kavita Naik", Rank: "DYSP", Department: mapusa, IDCardNumber: "321"},
+		{PhoneNumber: "+919175045787", Name: "Rajesh Kumar", Rank: "ASI", Department: madgaon, IDCardNumber: "123"},
+	} {
+		if err := c.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	return c
+}
+
+func TestUserScope(t *testing.T) {
+	c := seededClient(t)
+	tests := []struct {
+		name    string
+		scope   repository.Scope
+		visible []string
+	}{
+		{"unscoped", repository.Scope{}, []string{"+919175045787", "+919405061349"}},
+		{"mapusa", repository.DepartmentScope(mapusa), []string{"+919405061349"}},
+		{"madgaon", repository.DepartmentScope(madgaon), []string{"+919175045787"}},
+		{"no department", repository.DepartmentScope(""), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := repository.WithScope(context.Background(), tt.scope)
+			users, err := c.GetAllUsers(ctx)
+			if err != nil {
+				t.Fatalf("GetAllUsers: %v", err)
+			}
+			var got []string
+			for _, user := range users {
+				got = append(got, user.PhoneNumber)
+			}
+			if !equalStrings(got, tt.visible) {
+				t.Errorf("GetAllUsers = %v, want %v", got, tt.visible)
+			}
+
+			for _, phone := range []string{"+919405061349", "+919175045787"} {
+				_, err := c.GetUserByPhoneNumber(ctx, phone)
+				if want := contains(tt.visible, phone); (err == nil) != want {
+					t.Errorf("GetUserByPhoneNumber(%s) error = %v, want visible %v", phone, err, want)
+				}
+				if err != nil && !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("GetUserByPhoneNumber(%s) error = %v, want ErrNotFound", phone, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUserWritesOutOfScope(t *testing.T) {
+	c := seededClient(t)
+	ctx := repository.WithScope(context.Background(), repository.DepartmentScope(mapusa))
+
+	err := c.CreateUser(ctx, models.User{PhoneNumber: "+919000000001", Department: madgaon})
+	if !errors.Is(err, repository.ErrOutOfScope) {
+		t.Errorf("CreateUser in another department: error = %v, want ErrOutOfScope", err)
+	}
+
+	_, err = c.UpdateUser(ctx, "+919175045787", func(u *models.User) error { u.Name = "x"; return nil })
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateUser of another department: error = %v, want ErrNotFound", err)
+	}
+
+	_, err = c.UpdateUser(ctx, "+919405061349", func(u *models.User) error { u.Department = madgaon; return nil })
+	if !errors.Is(err, repository.ErrOutOfScope) {
+		t.Errorf("UpdateUser moving a user out: error = %v, want ErrOutOfScope", err)
+	}
+	if user, _ := c.GetUserByPhoneNumber(context.Background(), "+919405061349"); user.Department != mapusa {
+		t.Errorf("rejected update was stored: department = %q", user.Department)
+	}
+
+	if err := c.DeleteUser(ctx, "+919175045787"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("DeleteUser of another department: error = %v, want ErrNotFound", err)
+	}
+
+	err = c.ImportUsers(ctx, []models.User{
+		{PhoneNumber: "+919000000002", Department: mapusa},
+		{PhoneNumber: "+919175045787", Department: mapusa},
+	})
+	if !errors.Is(err, repository.ErrOutOfScope) {
+		t.Errorf("ImportUsers overwriting another department: error = %v, want ErrOutOfScope", err)
+	}
+	if _, err := c.GetUserByPhoneNumber(context.Background(), "+919000000002"); err == nil {
+		t.Error("a rejected import stored some of its users")
+	}
+}
+
+func TestUserUniqueness(t *testing.T) {
+	c := seededClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		user models.User
+		want error
+	}{
+		{"phone number taken", models.User{PhoneNumber: "+919405061349", IDCardNumber: "999"}, repository.ErrAlreadyExists},
+		{"ID card taken", models.User{PhoneNumber: "+919000000001", IDCardNumber: "123"}, repository.ErrIDCardInUse},
+		{"no ID card", models.User{PhoneNumber: "+919000000002"}, nil},
+		{"second user without ID card", models.User{PhoneNumber: "+919000000003"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.CreateUser(ctx, tt.user); !errors.Is(err, tt.want) {
+				t.Errorf("CreateUser error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	_, err := c.UpdateUser(ctx, "+919405061349", func(u *models.User) error { u.IDCardNumber = "123"; return nil })
+	if !errors.Is(err, repository.ErrIDCardInUse) {
+		t.Errorf("UpdateUser taking another user's ID card: error = %v, want ErrIDCardInUse", err)
+	}
+}
+
+func TestVideoAnalysesNearbyAndScope(t *testing.T) {
+	c := NewMemoryClient()
+	now := time.Now()
+	for _, a := range []models.VideoAnalysis{
+		{VideoID: "far", Department: mapusa, CreatedAt: now, Location: models.Location{Latitude: 15.60, Longitude: 73.75}},
+		{VideoID: "near", Department: mapusa, CreatedAt: now, Location: models.Location{Latitude: 15.545, Longitude: 73.755}},
+		{VideoID: "madgaon", Department: madgaon, CreatedAt: now, Location: models.Location{Latitude: 15.544, Longitude: 73.755}},
+		{VideoID: "outside", Department: mapusa, CreatedAt: now, Location: models.Location{Latitude: 16.5, Longitude: 73.75}},
+	} {
+		if err := c.CreateVideoAnalysis(context.Background(), a); err != nil {
+			t.Fatalf("CreateVideoAnalysis(%s): %v", a.VideoID, err)
+		}
+	}
+	if err := c.CreateVideoAnalysis(context.Background(), models.VideoAnalysis{VideoID: "near"}); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("CreateVideoAnalysis of a duplicate: error = %v, want ErrAlreadyExists", err)
+	}
+
+	tests := []struct {
+		name  string
+		scope repository.Scope
+		want  []string
+	}{
+		{"unscoped", repository.Scope{}, []string{"madgaon", "near", "far"}},
+		{"mapusa", repository.DepartmentScope(mapusa), []string{"near", "far"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := repository.WithScope(context.Background(), tt.scope)
+			nearby, err := c.GetVideoAnalysesNearby(ctx, 15.5439, 73.7553, 10)
+			if err != nil {
+				t.Fatalf("GetVideoAnalysesNearby: %v", err)
+			}
+			var got []string
+			for _, a := range nearby {
+				got = append(got, a.VideoID)
+				if a.Geohash == "" {
+					t.Errorf("%s was stored without a geohash", a.VideoID)
+				}
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("GetVideoAnalysesNearby = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	ctx := repository.WithScope(context.Background(), repository.DepartmentScope(mapusa))
+	if _, err := c.GetVideoAnalysisByID(ctx, "madgaon"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetVideoAnalysisByID of another department: error = %v, want ErrNotFound", err)
+	}
+	if err := c.SaveVideoAnalysis(ctx, models.VideoAnalysis{VideoID: "x", Department: madgaon}); !errors.Is(err, repository.ErrOutOfScope) {
+		t.Errorf("SaveVideoAnalysis into another department: error = %v, want ErrOutOfScope", err)
+	}
+}
+
+func TestListVideoAnalysesPages(t *testing.T) {
+	c := NewMemoryClient()
+	base := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	// Pairs of analyses share a timestamp so the cursor has to break ties
+	// on the document ID
+	var want []string
+	for i := 9; i >= 0; i-- {
+		for _, suffix := range []string{"b", "a"} {
+			id := string(rune('0'+i)) + suffix
+			want = append(want, id)
+			level := "low"
+			if i%2 == 0 {
+				level = "high"
+			}
+			err := c.CreateVideoAnalysis(context.Background(), models.VideoAnalysis{
+				VideoID:   id,
+				Timestamp: base.Add(time.Duration(i) * time.Minute),
+				Analysis:  models.Analysis{CrowdLevel: level},
+			})
+			if err != nil {
+				t.Fatalf("CreateVideoAnalysis: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   repository.VideoAnalysisFilter
+		pageSize int
+		want     []string
+	}{
+		{"pages of three", repository.VideoAnalysisFilter{}, 3, want},
+		{"one page", repository.VideoAnalysisFilter{}, 20, want},
+		{"filtered", repository.VideoAnalysisFilter{CrowdLevel: "high", From: base.Add(5 * time.Minute)},
+			2, []string{"8b", "8a", "6b", "6a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := repository.VideoAnalysisQuery{Filter: tt.filter, PageSize: tt.pageSize}
+			var got []string
+			for pages := 0; ; pages++ {
+				if pages > len(want) {
+					t.Fatal("paging did not end")
+				}
+				page, err := c.ListVideoAnalyses(context.Background(), q)
+				if err != nil {
+					t.Fatalf("ListVideoAnalyses: %v", err)
+				}
+				if len(page.Items) > tt.pageSize {
+					t.Fatalf("page of %d items, want at most %d", len(page.Items), tt.pageSize)
+				}
+				for _, a := range page.Items {
+					got = append(got, a.VideoID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("listed %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	cursor := repository.EncodeCursor(repository.Cursor{Field: "timestamp", Value: base, ID: "0a"})
+	_, err := c.ListVideoAnalyses(context.Background(), repository.VideoAnalysisQuery{
+		Filter: repository.VideoAnalysisFilter{TimeField: "created_at"},
+		Cursor: cursor,
+	})
+	if !errors.Is(err, repository.ErrInvalidCursor) {
+		t.Errorf("cursor of another sort field: error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestOfficerLocationScope(t *testing.T) {
+	c := NewMemoryClient()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	for _, location := range []models.OfficerLocation{
+		{PhoneNumber: "+919405061349", Department: mapusa, ReceivedAt: now},
+		{PhoneNumber: "+919405061350", Department: mapusa, ReceivedAt: now.Add(-time.Hour)},
+		{PhoneNumber: "+919175045787", Department: madgaon, ReceivedAt: now},
+	} {
+		if err := c.SaveOfficerLocation(context.Background(), location); err != nil {
+			t.Fatalf("SaveOfficerLocation: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		scope   repository.Scope
+		since   time.Time
+		visible []string
+	}{
+		{"unscoped", repository.Scope{}, now.Add(-2 * time.Hour), []string{"+919175045787", "+919405061349", "+919405061350"}},
+		{"fresh only", repository.Scope{}, now.Add(-time.Minute), []string{"+919175045787", "+919405061349"}},
+		{"mapusa", repository.DepartmentScope(mapusa), now.Add(-2 * time.Hour), []string{"+919405061349", "+919405061350"}},
+		{"madgaon", repository.DepartmentScope(madgaon), now.Add(-2 * time.Hour), []string{"+919175045787"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := repository.WithScope(context.Background(), tt.scope)
+			locations, err := c.ListOfficerLocations(ctx, tt.since)
+			if err != nil {
+				t.Fatalf("ListOfficerLocations: %v", err)
+			}
+			var got []string
+			for _, location := range locations {
+				got = append(got, location.PhoneNumber)
+			}
+			sort.Strings(got)
+			if !equalStrings(got, tt.visible) {
+				t.Errorf("ListOfficerLocations = %v, want %v", got, tt.visible)
+			}
+
+			for _, phone := range []string{"+919405061349", "+919175045787"} {
+				_, err := c.GetOfficerLocation(ctx, phone)
+				if want := contains(tt.visible, phone); (err == nil) != want {
+					t.Errorf("GetOfficerLocation(%s) error = %v, visible %v", phone, err, want)
+				} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("GetOfficerLocation(%s) error = %v, want ErrNotFound", phone, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCountAlerts(t *testing.T) {
+	c := NewMemoryClient()
+	ctx := context.Background()
+	for i, status := range []string{models.AlertStatusOpen, models.AlertStatusOpen, models.AlertStatusAcknowledged, models.AlertStatusResolved} {
+		alert := models.Alert{ID: string(rune('a' + i)), Status: status, Department: mapusa}
+		if err := c.CreateAlert(ctx, alert); err != nil {
+			t.Fatalf("CreateAlert: %v", err)
+		}
+	}
+	for status, want := range map[string]int{
+		models.AlertStatusOpen:         2,
+		models.AlertStatusAcknowledged: 1,
+		models.AlertStatusResolved:     1,
+		"unknown":                      0,
+	} {
+		if got, err := c.CountAlerts(ctx, status); err != nil || got != want {
+			t.Errorf("CountAlerts(%s) = %d, %v, want %d", status, got, err, want)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func (c *Client) GetIncident(ctx context.Context, incidentID string) (*models.Incident, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	incident, ok := c.incidents[incidentID]
+	if !ok || !repository.ScopeFromContext(ctx).Allows(incident.Department) {
+		return nil, repository.ErrNotFound
+	}
+	incident = copyIncident(incident)
+	return &incident, nil
+}
+
+func (c *Client) ListIncidents(ctx context.Context, filter repository.IncidentFilter) ([]models.Incident, error) {
+	var ok bool
+	if filter.Department, ok = repository.ScopeFromContext(ctx).Narrow(filter.Department); !ok {
+		return []models.Incident{}, nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	incidents := []models.Incident{}
+	for _, incident := range c.incidents {
+		if filter.Matches(incident) {
+			incidents = append(incidents, copyIncident(incident))
+		}
+	}
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].CreatedAt.After(incidents[j].CreatedAt) })
+	if filter.Limit > 0 && len(incidents) > filter.Limit {
+		incidents = incidents[:filter.Limit]
+	}
+	return incidents, nil
+}
+
+func (c *Client) CreateIncident(ctx context.Context, incident models.Incident) error {
+	if !repository.ScopeFromContext(ctx).Allows(incident.Department) {
+		return repository.ErrOutOfScope
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.incidents[incident.ID]; exists {
+		return repository.ErrAlreadyExists
+	}
+	c.incidents[incident.ID] = copyIncident(incident)
+	return nil
+}
+
+func (c *Client) UpdateIncident(ctx context.Context, incidentID string, update func(*models.Incident) error) (*models.Incident, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored, ok := c.incidents[incidentID]
+	if !ok || !repository.ScopeFromContext(ctx).Allows(stored.Department) {
+		return nil, repository.ErrNotFound
+	}
+	incident := copyIncident(stored)
+	if err := update(&incident); err != nil {
+		return nil, err
+	}
+	c.incidents[incidentID] = incident
+	result := copyIncident(incident)
+	return &result, nil
+}
+
+// copyIncident detaches the slices and pointers a caller may append to or
+// modify from the stored incident.
+func copyIncident(incident models.Incident) models.Incident {
+	incident.VideoIDs = append([]string(nil), incident.VideoIDs...)
+	incident.Timeline = append([]models.IncidentEvent(nil), incident.Timeline...)
+	if incident.Location != nil {
+		location := *incident.Location
+		incident.Location = &location
+	}
+	if incident.AssignedTo != nil {
+		officer := *incident.AssignedTo
+		incident.AssignedTo = &officer
+	}
+	return incident
+}
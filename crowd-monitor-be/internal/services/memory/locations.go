@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func (c *Client) SaveOfficerLocation(ctx context.Context, location models.OfficerLocation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.locations[location.PhoneNumber] = location
+	return nil
+}
+
+func (c *Client) GetOfficerLocation(ctx context.Context, phoneNumber string) (*models.OfficerLocation, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	location, ok := c.locations[phoneNumber]
+	if !ok || !repository.ScopeFromContext(ctx).Allows(location.Department) {
+		return nil, repository.ErrNotFound
+	}
+	return &location, nil
+}
+
+func (c *Client) ListOfficerLocations(ctx context.Context, since time.Time) ([]models.OfficerLocation, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	scope := repository.ScopeFromContext(ctx)
+	locations := []models.OfficerLocation{}
+	for _, location := range c.locations {
+		if !location.ReceivedAt.Before(since) && scope.Allows(location.Department) {
+			locations = append(locations, location)
+		}
+	}
+	return locations, nil
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func (c *Client) SaveOTP(ctx context.Context, code models.OTPCode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.otpCodes[code.PhoneNumber] = code
+	return nil
+}
+
+func (c *Client) GetOTP(ctx context.Context, phoneNumber string) (*models.OTPCode, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	code, ok := c.otpCodes[phoneNumber]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &code, nil
+}
+
+func (c *Client) DeleteOTP(ctx context.Context, phoneNumber string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.otpCodes, phoneNumber)
+	return nil
+}
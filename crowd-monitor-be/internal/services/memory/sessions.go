@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func (c *Client) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	session, ok := c.sessions[sessionID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &session, nil
+}
+
+func (c *Client) CreateSession(ctx context.Context, session models.Session) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.sessions[session.ID]; exists {
+		return repository.ErrAlreadyExists
+	}
+	c.sessions[session.ID] = session
+	return nil
+}
+
+func (c *Client) UpdateSession(ctx context.Context, sessionID string, update func(*models.Session) error) (*models.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session, ok := c.sessions[sessionID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if err := update(&session); err != nil {
+		return nil, err
+	}
+	c.sessions[sessionID] = session
+	return &session, nil
+}
+
+func (c *Client) ListSessions(ctx context.Context, phoneNumber string) ([]models.Session, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sessions := []models.Session{}
+	for _, session := range c.sessions {
+		if session.PhoneNumber == phoneNumber {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+func (c *Client) SaveRevocation(ctx context.Context, revocation models.Revocation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revocations[revocation.ID] = revocation
+	return nil
+}
+
+// ListRevocations also drops the expired revocations.
+func (c *Client) ListRevocations(ctx context.Context, now time.Time) ([]models.Revocation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	revocations := []models.Revocation{}
+	for id, revocation := range c.revocations {
+		if !revocation.ExpiresAt.After(now) {
+			delete(c.revocations, id)
+			continue
+		}
+		revocations = append(revocations, revocation)
+	}
+	return revocations, nil
+}
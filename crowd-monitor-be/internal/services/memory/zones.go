@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+func (c *Client) GetZone(ctx context.Context, zoneID string) (*models.Zone, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	zone, ok := c.zones[zoneID]
+	if !ok || !repository.ScopeFromContext(ctx).Allows(zone.Department) {
+		return nil, repository.ErrNotFound
+	}
+	return &zone, nil
+}
+
+func (c *Client) ListZones(ctx context.Context) ([]models.Zone, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	scope := repository.ScopeFromContext(ctx)
+	zones := make([]models.Zone, 0, len(c.zones))
+	for _, zone := range c.zones {
+		if scope.Allows(zone.Department) {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].ID < zones[j].ID })
+	return zones, nil
+}
+
+func (c *Client) CreateZone(ctx context.Context, zone models.Zone) error {
+	if !repository.ScopeFromContext(ctx).Allows(zone.Department) {
+		return repository.ErrOutOfScope
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.zones[zone.ID]; exists {
+		return repository.ErrAlreadyExists
+	}
+	c.zones[zone.ID] = zone
+	return nil
+}
+
+func (c *Client) UpdateZone(ctx context.Context, zone models.Zone) error {
+	scope := repository.ScopeFromContext(ctx)
+	if !scope.Allows(zone.Department) {
+		return repository.ErrOutOfScope
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stored, exists := c.zones[zone.ID]; !exists || !scope.Allows(stored.Department) {
+		return repository.ErrNotFound
+	}
+	c.zones[zone.ID] = zone
+	return nil
+}
+
+func (c *Client) DeleteZone(ctx context.Context, zoneID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stored, exists := c.zones[zoneID]; !exists || !repository.ScopeFromContext(ctx).Allows(stored.Department) {
+		return repository.ErrNotFound
+	}
+	delete(c.zones, zoneID)
+	return nil
+}
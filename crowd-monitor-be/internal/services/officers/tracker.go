@@ -0,0 +1,157 @@
+// Package officers tracks where officers are and finds the closest
+// available ones to a point.
+package officers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+// ErrUnknownOfficer is returned for pings from a phone number that is not
+// a registered user.
+var ErrUnknownOfficer = errors.New("unknown officer")
+
+// Ping is one location report from an officer's app.
+type Ping struct {
+	Latitude   float64
+	Longitude  float64
+	AccuracyM  float64
+	Available  bool
+	RecordedAt time.Time // defaults to the receive time
+}
+
+// NearbyOfficer is an officer with their distance from the query point.
+type NearbyOfficer struct {
+	Location   models.OfficerLocation `json:"location"`
+	DistanceKm float64                `json:"distance_km"`
+}
+
+// Tracker stores location pings and answers nearest-officer queries. Pings
+// older than maxAge are treated as unknown locations.
+type Tracker struct {
+	store  repository.OfficerLocationStore
+	users  repository.UserRepository
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+func NewTracker(store repository.OfficerLocationStore, users repository.UserRepository, maxAge time.Duration) *Tracker {
+	return &Tracker{
+		store:  store,
+		users:  users,
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+// RecordPing stores the officer's latest location along with their name,
+// rank and department so queries need no user lookups.
+func (t *Tracker) RecordPing(ctx context.Context, phoneNumber string, ping Ping) (*models.OfficerLocation, error) {
+	user, err := t.users.GetUserByPhoneNumber(ctx, phoneNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUnknownOfficer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.now().UTC()
+	recordedAt := ping.RecordedAt.UTC()
+	if recordedAt.IsZero() || recordedAt.After(now) {
+		recordedAt = now
+	}
+	location := models.OfficerLocation{
+		PhoneNumber: user.PhoneNumber,
+		Name:        user.Name,
+		Rank:        user.Rank,
+		Department:  user.Department,
+		Latitude:    ping.Latitude,
+		Longitude:   ping.Longitude,
+		AccuracyM:   ping.AccuracyM,
+		Available:   ping.Available,
+		RecordedAt:  recordedAt,
+		ReceivedAt:  now,
+	}
+	if err := t.store.SaveOfficerLocation(ctx, location); err != nil {
+		return nil, fmt.Errorf("failed to store location: %v", err)
+	}
+	return &location, nil
+}
+
+// Location returns the officer's last known location, fresh or not.
+func (t *Tracker) Location(ctx context.Context, phoneNumber string) (*models.OfficerLocation, error) {
+	return t.store.GetOfficerLocation(ctx, phoneNumber)
+}
+
+// Nearest returns available officers with a fresh location, nearest first.
+// department and radiusKm are ignored when empty or zero, and limit caps
+// the result when positive.
+func (t *Tracker) Nearest(ctx context.Context, lat, lon, radiusKm float64, department string, limit int) ([]NearbyOfficer, error) {
+	locations, err := t.store.ListOfficerLocations(ctx, t.now().Add(-t.maxAge))
+	if err != nil {
+		return nil, err
+	}
+	return nearest(locations, lat, lon, radiusKm, department, limit), nil
+}
+
+func nearest(locations []models.OfficerLocation, lat, lon, radiusKm float64, department string, limit int) []NearbyOfficer {
+	nearby := []NearbyOfficer{}
+	for _, location := range locations {
+		if !location.Available || (department != "" && location.Department != department) {
+			continue
+		}
+		distance := geo.Distance(lat, lon, location.Latitude, location.Longitude)
+		if radiusKm > 0 && distance > radiusKm {
+			continue
+		}
+		nearby = append(nearby, NearbyOfficer{Location: location, DistanceKm: distance})
+	}
+	sort.Slice(nearby, func(i, j int) bool { return nearby[i].DistanceKm < nearby[j].DistanceKm })
+	if limit > 0 && len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby
+}
+
+// OfficersNear lets the tracker route alerts. While no officer of the
+// department has reported a fresh location at all it falls back to the
+// whole department roster so alerts still reach someone. Without a
+// department there is no roster to fall back to; texting every active user
+// of every department is never what a rule means.
+func (t *Tracker) OfficersNear(ctx context.Context, lat, lon, radiusKm float64, department string) ([]models.User, error) {
+	locations, err := t.store.ListOfficerLocations(ctx, t.now().Add(-t.maxAge))
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := false
+	for _, location := range locations {
+		if department == "" || location.Department == department {
+			tracked = true
+			break
+		}
+	}
+	if tracked {
+		var officers []models.User
+		for _, n := range nearest(locations, lat, lon, radiusKm, department, 0) {
+			officers = append(officers, n.Location.Officer())
+		}
+		return officers, nil
+	}
+
+	if department == "" {
+		slog.WarnContext(ctx, "No officer has a fresh location and the rule names no department, alerting nobody")
+		return nil, nil
+	}
+	slog.InfoContext(ctx, "No officer of the department has a fresh location, alerting the department roster", "department", department)
+	active := false
+	return t.users.SearchUsers(ctx, repository.UserFilter{Department: department, Deactivated: &active})
+}
@@ -0,0 +1,188 @@
+package officers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+const (
+	mapusa  = "Mapusa Police Department"
+	madgaon = "Madgaon Police Department"
+)
+
+// newTestTracker registers officers across two departments and returns a
+// tracker whose clock the caller can move.
+func newTestTracker(t *testing.T, now *time.Time) *Tracker {
+	t.Helper()
+	store := memory.NewMemoryClient()
+	for _, user := range []models.User{
+		{PhoneNumber: "+919405061349", Name: "Kavita Naik", Rank: "PI", Department: mapusa},
+		{PhoneNumber: "+919405061350", Name: "Anil Gaonkar", Rank: "ASI", Department: mapusa},
+		{PhoneNumber: "+919405061351", Name: "Rohan Desai", Rank: "PSI", Department: mapusa, Deactivated: true},
+		{PhoneNumber: "+919175045787", Name: "Sunita Fernandes", Rank: "ASI", Department: madgaon},
+	} {
+		if err := store.CreateUser(context.Background(), user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	tracker := NewTracker(store, store, 30*time.Minute)
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+func phones(users []models.User) string {
+	var numbers []string
+	for _, user := range users {
+		numbers = append(numbers, user.PhoneNumber)
+	}
+	return fmt.Sprint(numbers)
+}
+
+func TestRecordPing(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, &now)
+	ctx := context.Background()
+
+	if _, err := tracker.RecordPing(ctx, "+919000000000", Ping{}); !errors.Is(err, ErrUnknownOfficer) {
+		t.Errorf("ping from an unknown number: error = %v, want ErrUnknownOfficer", err)
+	}
+
+	tests := []struct {
+		name       string
+		recordedAt time.Time
+		want       time.Time
+	}{
+		{"defaults to the receive time", time.Time{}, now},
+		{"keeps the device time", now.Add(-time.Minute), now.Add(-time.Minute)},
+		{"clamps future times", now.Add(time.Hour), now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := tracker.RecordPing(ctx, "+919405061350", Ping{Latitude: 15.55, Longitude: 73.75, RecordedAt: tt.recordedAt})
+			if err != nil {
+				t.Fatalf("RecordPing: %v", err)
+			}
+			if !location.RecordedAt.Equal(tt.want) || !location.ReceivedAt.Equal(now) {
+				t.Errorf("recorded at %v, received at %v, want %v and %v", location.RecordedAt, location.ReceivedAt, tt.want, now)
+			}
+			if location.Rank != "ASI" || location.Department != mapusa {
+				t.Errorf("location = %+v, want the officer's rank and department copied", location)
+			}
+		})
+	}
+}
+
+func TestNearest(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, &now)
+	ctx := context.Background()
+
+	pings := []struct {
+		phone     string
+		lat       float64
+		available bool
+		age       time.Duration
+	}{
+		{"+919405061349", 15.60, true, 0},
+		{"+919405061350", 15.55, true, 0},
+		{"+919405061351", 15.545, false, 0},
+		{"+919175045787", 15.546, true, time.Hour},
+	}
+	for _, p := range pings {
+		now = time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC).Add(-p.age)
+		if _, err := tracker.RecordPing(ctx, p.phone, Ping{Latitude: p.lat, Longitude: 73.7553, Available: p.available}); err != nil {
+			t.Fatalf("RecordPing: %v", err)
+		}
+	}
+	now = time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		radiusKm   float64
+		department string
+		limit      int
+		want       string
+	}{
+		{"nearest first", 0, "", 0, "[+919405061350 +919405061349]"},
+		{"limit", 0, "", 1, "[+919405061350]"},
+		{"radius", 1, "", 0, "[+919405061350]"},
+		{"department", 0, madgaon, 0, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nearby, err := tracker.Nearest(ctx, 15.5439, 73.7553, tt.radiusKm, tt.department, tt.limit)
+			if err != nil {
+				t.Fatalf("Nearest: %v", err)
+			}
+			numbers := []string{}
+			for _, n := range nearby {
+				numbers = append(numbers, n.Location.PhoneNumber)
+			}
+			if got := fmt.Sprint(numbers); got != tt.want {
+				t.Errorf("Nearest = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOfficersNear(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, &now)
+	ctx := context.Background()
+
+	// Nobody is tracked yet
+	officers, err := tracker.OfficersNear(ctx, 15.5439, 73.7553, 5, "")
+	if err != nil || officers != nil {
+		t.Errorf("OfficersNear without a department = %v, %v, want nobody", officers, err)
+	}
+	officers, err = tracker.OfficersNear(ctx, 15.5439, 73.7553, 5, mapusa)
+	if err != nil {
+		t.Fatalf("OfficersNear: %v", err)
+	}
+	if got := phones(officers); got != "[+919405061349 +919405061350]" {
+		t.Errorf("roster fallback = %s, want the active Mapusa officers", got)
+	}
+
+	// Once someone in the department is tracked only tracked officers nearby count
+	if _, err := tracker.RecordPing(ctx, "+919405061350", Ping{Latitude: 15.60, Longitude: 73.7553, Available: true}); err != nil {
+		t.Fatalf("RecordPing: %v", err)
+	}
+	tests := []struct {
+		name       string
+		radiusKm   float64
+		department string
+		want       string
+	}{
+		{"tracked officer in range", 10, mapusa, "[+919405061350]"},
+		{"tracked officer out of range", 1, mapusa, "[]"},
+		{"any department", 10, "", "[+919405061350]"},
+		{"untracked department falls back", 1, madgaon, "[+919175045787]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			officers, err := tracker.OfficersNear(ctx, 15.5439, 73.7553, tt.radiusKm, tt.department)
+			if err != nil {
+				t.Fatalf("OfficersNear: %v", err)
+			}
+			if got := phones(officers); got != tt.want {
+				t.Errorf("OfficersNear = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// A stale ping no longer counts as tracked
+	now = now.Add(time.Hour)
+	officers, err = tracker.OfficersNear(ctx, 15.5439, 73.7553, 1, mapusa)
+	if err != nil {
+		t.Fatalf("OfficersNear: %v", err)
+	}
+	if got := phones(officers); got != "[+919405061349 +919405061350]" {
+		t.Errorf("OfficersNear after the ping went stale = %s, want the roster", got)
+	}
+}
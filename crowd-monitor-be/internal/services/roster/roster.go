@@ -0,0 +1,297 @@
+// Package roster imports and exports the officer roster as CSV so officers
+// can be onboarded in bulk instead of one POST /users at a time.
+package roster
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+// Columns is the CSV layout, named after the models.User JSON fields.
+// deactivated is exported for reference and ignored on import; officers
+// are deactivated through the API so their sessions end.
+var Columns = []string{"phone_number", "name", "rank", "department", "id_card_number", "role", "deactivated"}
+
+// required are the columns an import file must have.
+var required = []string{"phone_number", "name", "rank", "department"}
+
+// Row actions in a Report.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+)
+
+// ErrInvalidFile is returned when the file cannot be read as a roster at
+// all, as opposed to individual rows failing validation.
+var ErrInvalidFile = errors.New("invalid roster file")
+
+// RowResult is the outcome of one data row.
+type RowResult struct {
+	Line        int      `json:"line"` // line in the file, the header is line 1
+	PhoneNumber string   `json:"phone_number"`
+	Action      string   `json:"action,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// Report describes an import. Nothing is written unless every row is valid.
+type Report struct {
+	DryRun    bool        `json:"dry_run"`
+	Committed bool        `json:"committed"`
+	Total     int         `json:"total"`
+	Valid     int         `json:"valid"`
+	Invalid   int         `json:"invalid"`
+	Created   int         `json:"created"`
+	Updated   int         `json:"updated"`
+	Rows      []RowResult `json:"rows"`
+}
+
+type Service struct {
+	users repository.UserRepository
+}
+
+func NewRosterService(users repository.UserRepository) *Service {
+	return &Service{users: users}
+}
+
+// Import validates every row of the CSV in r and, unless dryRun is set or
+// a row is invalid, writes them all. Rows for registered phone numbers
+// update those users in the columns the file has and keep their other
+// fields, including the deactivation status. check applies the caller's
+// rules for the resulting user, such as known ranks and what the caller
+// may grant.
+func (s *Service) Import(ctx context.Context, r io.Reader, dryRun bool, check func(models.User) error) (*Report, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1 // short rows leave the trailing columns empty
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	index, err := columnIndex(header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Uniqueness is checked against every department, not just the
+	// caller's.
+	existing, err := s.users.GetAllUsers(repository.Unscoped(ctx))
+	if err != nil {
+		return nil, err
+	}
+	byPhone := make(map[string]models.User, len(existing))
+	idCards := make(map[string]string, len(existing)) // id card number -> phone number
+	for _, user := range existing {
+		byPhone[user.PhoneNumber] = user
+		if user.IDCardNumber != "" {
+			idCards[user.IDCardNumber] = user.PhoneNumber
+		}
+	}
+
+	scope := repository.ScopeFromContext(ctx)
+	report := &Report{DryRun: dryRun, Rows: []RowResult{}}
+	seenPhones := make(map[string]int)  // phone number -> line
+	seenIDCards := make(map[string]int) // id card number -> line
+	var valid []models.User
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if blank(record) {
+			continue
+		}
+
+		current, registered := byPhone[index.field(record, "phone_number")]
+		user := current
+		index.apply(&user, record)
+		result := RowResult{Line: line, PhoneNumber: user.PhoneNumber}
+		fail := func(format string, args ...interface{}) {
+			result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+		}
+
+		if !models.IsE164(user.PhoneNumber) {
+			fail("phone_number must be in E.164 format, e.g. +919876543210")
+		} else if first, ok := seenPhones[user.PhoneNumber]; ok {
+			fail("phone_number repeats line %d", first)
+		} else {
+			seenPhones[user.PhoneNumber] = line
+		}
+		if user.Name == "" {
+			fail("name is required")
+		}
+		if user.IDCardNumber != "" {
+			if first, ok := seenIDCards[user.IDCardNumber]; ok {
+				fail("id_card_number repeats line %d", first)
+			} else if holder, ok := idCards[user.IDCardNumber]; ok && holder != user.PhoneNumber {
+				fail("id_card_number is already assigned to another user")
+			}
+			seenIDCards[user.IDCardNumber] = line
+		}
+		if !scope.Allows(user.Department) {
+			fail("department is outside your own")
+		}
+
+		result.Action = ActionCreate
+		if registered {
+			result.Action = ActionUpdate
+			if !scope.Allows(current.Department) {
+				fail("phone_number is registered in another department")
+			} else if err := check(current); err != nil {
+				fail("cannot edit the registered user: %v", err)
+			}
+		}
+		if err := check(user); err != nil {
+			fail("%v", err)
+		}
+
+		report.Total++
+		if len(result.Errors) > 0 {
+			result.Action = ""
+			report.Invalid++
+		} else {
+			report.Valid++
+			valid = append(valid, user)
+			if result.Action == ActionCreate {
+				report.Created++
+			} else {
+				report.Updated++
+			}
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if dryRun || report.Invalid > 0 || len(valid) == 0 {
+		return report, nil
+	}
+	if err := s.users.ImportUsers(ctx, valid); err != nil {
+		return nil, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// WriteCSV writes users as CSV in the Columns layout. Cells a spreadsheet
+// would evaluate as a formula are prefixed with ', which Import removes.
+func WriteCSV(w io.Writer, users []models.User) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Columns); err != nil {
+		return err
+	}
+	for _, user := range users {
+		err := writer.Write([]string{
+			escapeCell(user.PhoneNumber),
+			escapeCell(user.Name),
+			escapeCell(user.Rank),
+			escapeCell(user.Department),
+			escapeCell(user.IDCardNumber),
+			escapeCell(user.Role),
+			strconv.FormatBool(user.Deactivated),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// columns maps column names to their position in a record.
+type columns map[string]int
+
+func columnIndex(header []string) (columns, error) {
+	index := make(columns, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known(name) {
+			return nil, fmt.Errorf("%w: unknown column %q, expected %s", ErrInvalidFile, name, strings.Join(Columns, ", "))
+		}
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidFile, name)
+		}
+		index[name] = i
+	}
+	for _, name := range required {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidFile, name)
+		}
+	}
+	return index, nil
+}
+
+// field returns the named cell of record, empty when the row is short or
+// the file has no such column.
+func (c columns) field(record []string, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return unescapeCell(strings.TrimSpace(record[i]))
+}
+
+// apply sets the fields of user whose columns the file has.
+func (c columns) apply(user *models.User, record []string) {
+	for name, dst := range map[string]*string{
+		"phone_number":   &user.PhoneNumber,
+		"name":           &user.Name,
+		"rank":           &user.Rank,
+		"department":     &user.Department,
+		"id_card_number": &user.IDCardNumber,
+		"role":           &user.Role,
+	} {
+		if _, ok := c[name]; ok {
+			*dst = c.field(record, name)
+		}
+	}
+	user.Rank = strings.ToUpper(user.Rank)
+}
+
+// escapeCell keeps a spreadsheet from running a cell as a formula.
+func escapeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCell undoes escapeCell. Spreadsheets drop the ' themselves when
+// saving, so both forms are accepted.
+func unescapeCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+func known(name string) bool {
+	for _, column := range Columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+func blank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+package roster
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+const (
+	mapusa  = "Mapusa Police Department"
+	madgaon = "Madgaon Police Department"
+)
+
+func newTestService(t *testing.T) (*Service, *memory.Client) {
+	t.Helper()
+	store := memory.NewMemoryClient()
+	for _, user := range []models.User{
+		{PhoneNumber: "+919405061350", Name: "Anil Gaonkar", Rank: "ASI", Department: mapusa, IDCardNumber: "322", Deactivated: true},
+		{PhoneNumber: "+919175045787", Name: "Rajesh Kumar", Rank: "ASI", Department: madgaon, IDCardNumber: "123"},
+	} {
+		if err := store.CreateUser(context.Background(), user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	return NewRosterService(store), store
+}
+
+// belowDYSP accepts any user below DYSP, standing in for the handler's
+// validation and grant checks.
+func belowDYSP(user models.User) error {
+	if user.Rank == "DYSP" {
+		return fmt.Errorf("cannot grant a rank above your own")
+	}
+	return nil
+}
+
+func TestImportInvalidFile(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"empty", ""},
+		{"unknown column", "phone_number,name,rank,department,badge\n"},
+		{"duplicate column", "phone_number,name,name,rank,department\n"},
+		{"missing column", "phone_number,name,rank\n"},
+		{"bad quoting", "phone_number,name,rank,department\n\"+91,x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			if _, err := s.Import(context.Background(), strings.NewReader(tt.csv), false, belowDYSP); !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("Import error = %v, want ErrInvalidFile", err)
+			}
+		})
+	}
+}
+
+func TestImportRows(t *testing.T) {
+	const header = "\ufeffPhone_Number, name, rank, department, id_card_number\n"
+	tests := []struct {
+		name       string
+		rows       string
+		scope      repository.Scope
+		wantErrors map[int]string // line -> first error
+		created    int
+		updated    int
+	}{
+		{"create and update", "+919000000001,New Officer,si," + mapusa + ",900\n\n+919405061350,Anil G.,ASI," + mapusa + ",322\n",
+			repository.Scope{}, nil, 1, 1},
+		{"short row", "+919000000001,New Officer,ASI\n", repository.Scope{}, nil, 1, 0},
+		{"bad phone number", "9000000001,New Officer,ASI," + mapusa + "\n", repository.Scope{},
+			map[int]string{2: "phone_number must be in E.164 format, e.g. +919876543210"}, 0, 0},
+		{"repeated phone number", "+919000000001,A,ASI," + mapusa + "\n+919000000001,B,ASI," + mapusa + "\n", repository.Scope{},
+			map[int]string{3: "phone_number repeats line 2"}, 1, 0},
+		{"missing name", "+919000000001,,ASI," + mapusa + "\n", repository.Scope{},
+			map[int]string{2: "name is required"}, 0, 0},
+		{"repeated id card", "+919000000001,A,ASI," + mapusa + ",900\n+919000000002,B,ASI," + mapusa + ",900\n", repository.Scope{},
+			map[int]string{3: "id_card_number repeats line 2"}, 1, 0},
+		{"id card of another officer", "+919000000001,A,ASI," + mapusa + ",123\n", repository.Scope{},
+			map[int]string{2: "id_card_number is already assigned to another user"}, 0, 0},
+		{"other department", "+919000000001,A,ASI," + madgaon + "\n", repository.DepartmentScope(mapusa),
+			map[int]string{2: "department is outside your own"}, 0, 0},
+		{"officer of another department", "+919175045787,Rajesh,ASI," + mapusa + "\n", repository.DepartmentScope(mapusa),
+			map[int]string{2: "phone_number is registered in another department"}, 0, 0},
+		{"failed check", "+919000000001,A,DYSP," + mapusa + "\n", repository.Scope{},
+			map[int]string{2: "cannot grant a rank above your own"}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestService(t)
+			ctx := repository.WithScope(context.Background(), tt.scope)
+			report, err := s.Import(ctx, strings.NewReader(header+tt.rows), false, belowDYSP)
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			for _, row := range report.Rows {
+				want, failed := tt.wantErrors[row.Line]
+				switch {
+				case failed && (len(row.Errors) == 0 || row.Errors[0] != want):
+					t.Errorf("line %d errors = %v, want %q", row.Line, row.Errors, want)
+				case !failed && len(row.Errors) > 0:
+					t.Errorf("line %d errors = %v, want none", row.Line, row.Errors)
+				}
+			}
+			if report.Invalid != len(tt.wantErrors) || report.Created != tt.created || report.Updated != tt.updated {
+				t.Errorf("report = %+v, want %d invalid, %d created, %d updated", report, len(tt.wantErrors), tt.created, tt.updated)
+			}
+
+			users, _ := store.GetAllUsers(context.Background())
+			want := 2
+			if report.Committed {
+				want += tt.created
+			}
+			if len(users) != want {
+				t.Errorf("%d users after import, want %d", len(users), want)
+			}
+			if report.Committed != (report.Invalid == 0) {
+				t.Errorf("committed = %v with %d invalid rows", report.Committed, report.Invalid)
+			}
+		})
+	}
+}
+
+func TestImportKeepsMissingColumns(t *testing.T) {
+	s, store := newTestService(t)
+	ctx := context.Background()
+	csv := "phone_number,name,rank,department,deactivated\n+919405061350,Anil G.,SI," + mapusa + ",false\n"
+	if _, err := s.Import(ctx, strings.NewReader(csv), false, belowDYSP); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	user, err := store.GetUserByPhoneNumber(ctx, "+919405061350")
+	if err != nil {
+		t.Fatalf("GetUserByPhoneNumber: %v", err)
+	}
+	if user.Name != "Anil G." || user.Rank != "SI" || !user.Deactivated {
+		t.Errorf("user = %+v, want updated and still deactivated", user)
+	}
+	if user.IDCardNumber != "322" {
+		t.Errorf("id_card_number = %q, want 322 kept as the file has no such column", user.IDCardNumber)
+	}
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	s, store := newTestService(t)
+	ctx := context.Background()
+	user := models.User{PhoneNumber: "+919000000001", Name: "=HYPERLINK(\"http://x\")", Rank: "ASI", Department: mapusa, IDCardNumber: "-1"}
+	if err := store.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []models.User{user}); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the export: %v", err)
+	}
+	want := []string{"'+919000000001", "'=HYPERLINK(\"http://x\")", "ASI", mapusa, "'-1", "", "false"}
+	if got := records[1]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("exported row = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := WriteCSV(&buf, []models.User{user}); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	if _, err := s.Import(ctx, &buf, false, belowDYSP); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	got, err := store.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+	if err != nil {
+		t.Fatalf("GetUserByPhoneNumber: %v", err)
+	}
+	if got.Name != user.Name || got.IDCardNumber != user.IDCardNumber {
+		t.Errorf("imported %+v, want the escaping removed", got)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	s, store := newTestService(t)
+	ctx := context.Background()
+	csv := "phone_number,name,rank,department\n+919000000001,New Officer,ASI," + mapusa + "\n"
+	report, err := s.Import(ctx, strings.NewReader(csv), true, belowDYSP)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !report.DryRun || report.Committed || report.Created != 1 {
+		t.Errorf("report = %+v, want one row to create and nothing committed", report)
+	}
+	if _, err := store.GetUserByPhoneNumber(ctx, "+919000000001"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("dry run created the user: %v", err)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	s, store := newTestService(t)
+	ctx := context.Background()
+	users, err := store.GetAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetAllUsers: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, users); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), strings.Join(Columns, ",")+"\n") {
+		t.Errorf("export starts %q, want the column header", buf.String())
+	}
+
+	report, err := s.Import(ctx, &buf, true, belowDYSP)
+	if err != nil {
+		t.Fatalf("Import of an export: %v", err)
+	}
+	if report.Invalid != 0 || report.Updated != len(users) {
+		t.Errorf("report = %+v, want every exported user updated", report)
+	}
+}
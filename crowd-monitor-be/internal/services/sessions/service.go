@@ -0,0 +1,322 @@
+// Package sessions keeps officers logged in with short-lived access tokens
+// renewed by rotating refresh tokens, and revokes them on logout or when an
+// administrator ends every session of an officer.
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
+)
+
+// revocationRefresh bounds how long a revocation made by another server
+// process takes to reach this one. Revocations made here apply at once.
+const revocationRefresh = 30 * time.Second
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired, revoked or
+	// already rotated refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrNoSession is returned when logging out with a token issued
+	// before sessions existed.
+	ErrNoSession = errors.New("token does not belong to a session")
+)
+
+// Tokens is the credential pair handed to a client at login and refresh.
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+type Service struct {
+	tokens     *tokens.Service
+	repo       repository.SessionRepository
+	users      repository.UserRepository
+	refreshTTL time.Duration
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	revoked  map[string]time.Time // revocationID -> RevokedAt
+	loadedAt time.Time
+}
+
+// NewSessionService keeps sessions alive for refreshTTL after their last
+// refresh.
+func NewSessionService(tokenService *tokens.Service, repo repository.SessionRepository, users repository.UserRepository, refreshTTL time.Duration) *Service {
+	return &Service{
+		tokens:     tokenService,
+		repo:       repo,
+		users:      users,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+		revoked:    make(map[string]time.Time),
+	}
+}
+
+// Start opens a session for user after a successful login.
+func (s *Service) Start(ctx context.Context, user models.User) (*Tokens, error) {
+	now := s.now().UTC()
+	session := models.Session{
+		ID:          uuid.NewString(),
+		PhoneNumber: user.PhoneNumber,
+		CreatedAt:   now,
+		RefreshedAt: now,
+		ExpiresAt:   now.Add(s.refreshTTL),
+	}
+	refreshToken, hash, err := newRefreshToken(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	session.TokenHash = hash
+
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return s.issue(user, session, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new pair. The presented token is
+// rotated out; presenting it again revokes the whole session, since one of
+// the two holders is not the officer. The access token carries the user's
+// current rank, department and role.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Tokens, *models.User, error) {
+	sessionID, _, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	presented := hashToken(refreshToken)
+	next, nextHash, err := newRefreshToken(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.now().UTC()
+	reused := false
+	session, err := s.repo.UpdateSession(ctx, sessionID, func(session *models.Session) error {
+		reused = false
+		switch {
+		case !session.Active(now):
+			return ErrInvalidRefreshToken
+		case session.PreviousHash != "" && presented == session.PreviousHash:
+			reused = true
+			session.Revoked, session.RevokedAt = true, now
+			return nil
+		case presented != session.TokenHash:
+			return ErrInvalidRefreshToken
+		}
+		session.PreviousHash, session.TokenHash = session.TokenHash, nextHash
+		session.RefreshedAt = now
+		session.ExpiresAt = now.Add(s.refreshTTL)
+		return nil
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if reused {
+		slog.WarnContext(ctx, "Refresh token reuse detected, session revoked", "session_id", session.ID, "phone_number", session.PhoneNumber)
+		if err := s.revoke(ctx, models.RevocationSession, session.ID, now); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.users.GetUserByPhoneNumber(ctx, session.PhoneNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Deactivated {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	pair, err := s.issue(*user, *session, next)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// Logout revokes the session the access token was issued in, along with its
+// refresh token and every access token issued in it.
+func (s *Service) Logout(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return ErrNoSession
+	}
+	now := s.now().UTC()
+	_, err := s.repo.UpdateSession(ctx, sessionID, func(session *models.Session) error {
+		if !session.Revoked {
+			session.Revoked, session.RevokedAt = true, now
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.revoke(ctx, models.RevocationSession, sessionID, now)
+}
+
+// RevokeAll ends every session of the officer and rejects every access
+// token issued to them so far. It returns the number of sessions that were
+// still active.
+func (s *Service) RevokeAll(ctx context.Context, phoneNumber string) (int, error) {
+	now := s.now().UTC()
+	if err := s.revoke(ctx, models.RevocationUser, phoneNumber, now); err != nil {
+		return 0, err
+	}
+
+	list, err := s.repo.ListSessions(ctx, phoneNumber)
+	if err != nil {
+		return 0, err
+	}
+	ended := 0
+	for _, session := range list {
+		if !session.Active(now) {
+			continue
+		}
+		_, err := s.repo.UpdateSession(ctx, session.ID, func(session *models.Session) error {
+			session.Revoked, session.RevokedAt = true, now
+			return nil
+		})
+		if err != nil {
+			return ended, err
+		}
+		// The user revocation spares tokens issued in its own second, so
+		// reject those of the ended sessions by session
+		if err := s.revoke(ctx, models.RevocationSession, session.ID, now); err != nil {
+			return ended, err
+		}
+		ended++
+	}
+	return ended, nil
+}
+
+// Verify checks the access token like tokens.Service.Verify and then
+// against the revocation list. It implements tokens.Verifier.
+func (s *Service) Verify(ctx context.Context, token string) (*tokens.Claims, error) {
+	claims, err := s.tokens.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	s.reload(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if claims.SessionID != "" {
+		if _, revoked := s.revoked[revocationID(models.RevocationSession, claims.SessionID)]; revoked {
+			return nil, fmt.Errorf("%w: session has ended", tokens.ErrInvalidToken)
+		}
+	}
+	if revokedAt, revoked := s.revoked[revocationID(models.RevocationUser, claims.PhoneNumber)]; revoked {
+		// iat has whole seconds; a login right after the revocation must
+		// not be rejected for the rest of its lifetime
+		if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(revokedAt.Truncate(time.Second)) {
+			return nil, fmt.Errorf("%w: token has been revoked", tokens.ErrInvalidToken)
+		}
+	}
+	return claims, nil
+}
+
+func (s *Service) issue(user models.User, session models.Session, refreshToken string) (*Tokens, error) {
+	accessToken, accessExpiresAt, err := s.tokens.Issue(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// revoke records the revocation and applies it locally at once. It is kept
+// for one access token lifetime, after which every token it covers has
+// expired.
+func (s *Service) revoke(ctx context.Context, kind, subject string, now time.Time) error {
+	revocation := models.Revocation{
+		ID:        revocationID(kind, subject),
+		Kind:      kind,
+		Subject:   subject,
+		RevokedAt: now,
+		ExpiresAt: now.Add(s.tokens.TTL()),
+	}
+	if err := s.repo.SaveRevocation(ctx, revocation); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[revocation.ID] = revocation.RevokedAt
+	return nil
+}
+
+// reload refreshes the revocation list at most every revocationRefresh. A
+// failed reload keeps the previous list.
+func (s *Service) reload(ctx context.Context) {
+	s.mu.RLock()
+	fresh := s.now().Sub(s.loadedAt) <= revocationRefresh
+	s.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	now := s.now()
+	list, err := s.repo.ListRevocations(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reload token revocations", "error", err)
+		return
+	}
+	revoked := make(map[string]time.Time, len(list))
+	for _, revocation := range list {
+		revoked[revocation.ID] = revocation.RevokedAt
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Keep revocations made here while the list was loading.
+	for id, at := range s.revoked {
+		if _, ok := revoked[id]; !ok && now.Sub(at) < revocationRefresh {
+			revoked[id] = at
+		}
+	}
+	s.revoked, s.loadedAt = revoked, now
+}
+
+func revocationID(kind, subject string) string {
+	return kind + ":" + subject
+}
+
+// newRefreshToken returns a token of the form "<session ID>.<secret>" and
+// its digest.
+func newRefreshToken(sessionID string) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token := sessionID + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,180 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+	"github.com/jimil-28/crowd-monitor/internal/services/tokens"
+)
+
+const officer = "+919405061349"
+
+func newTestService(t *testing.T) (*Service, *memory.Client) {
+	t.Helper()
+	store := memory.NewMemoryClient()
+	err := store.CreateUser(context.Background(), models.User{PhoneNumber: officer, Name: "Kavita Naik", Rank: "DYSP", Department: "Mapusa Police Department"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	keys, err := tokens.LoadKeySet("", "test-secret")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	tokenService, err := tokens.NewTokenService(keys, "crowd-monitor-test", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenService: %v", err)
+	}
+	return NewSessionService(tokenService, store, store, time.Hour), store
+}
+
+func start(t *testing.T, s *Service) *Tokens {
+	t.Helper()
+	pair, err := s.Start(context.Background(), models.User{PhoneNumber: officer, Rank: "DYSP"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return pair
+}
+
+func TestRefreshRotates(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	first := start(t, s)
+
+	second, user, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if user.PhoneNumber != officer || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("Refresh = %+v for %s, want a new refresh token for %s", second, user.PhoneNumber, officer)
+	}
+	third, _, err := s.Refresh(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh with the rotated token: %v", err)
+	}
+
+	// Only the token rotated out last is recognised as reuse; older ones are
+	// merely invalid and leave the session alone
+	if _, _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("Refresh with a stale token: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.Verify(ctx, third.AccessToken); err != nil {
+		t.Fatalf("Verify after a stale token: %v", err)
+	}
+
+	if _, _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("reused refresh token: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, _, err := s.Refresh(ctx, third.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh after reuse: error = %v, want the session revoked", err)
+	}
+	for name, token := range map[string]string{"first": first.AccessToken, "third": third.AccessToken} {
+		if _, err := s.Verify(ctx, token); !errors.Is(err, tokens.ErrInvalidToken) {
+			t.Errorf("Verify %s access token after reuse: error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestRefreshRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, s *Service, store *memory.Client, pair *Tokens) string
+	}{
+		{"malformed", func(t *testing.T, s *Service, store *memory.Client, pair *Tokens) string {
+			return "no-dot"
+		}},
+		{"unknown session", func(t *testing.T, s *Service, store *memory.Client, pair *Tokens) string {
+			return "missing." + pair.RefreshToken
+		}},
+		{"forged secret", func(t *testing.T, s *Service, store *memory.Client, pair *Tokens) string {
+			return pair.RefreshToken + "x"
+		}},
+		{"expired", func(t *testing.T, s *Service, store *memory.Client, pair *Tokens) string {
+			s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+			return pair.RefreshToken
+		}},
+		{"logged out", func(t *testing.T, s *Service, store *memory.Client, pair *Tokens) string {
+			claims, err := s.Verify(context.Background(), pair.AccessToken)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if err := s.Logout(context.Background(), claims.SessionID); err != nil {
+				t.Fatalf("Logout: %v", err)
+			}
+			return pair.RefreshToken
+		}},
+		{"deactivated officer", func(t *testing.T, s *Service, store *memory.Client, pair *Tokens) string {
+			_, err := store.UpdateUser(context.Background(), officer, func(user *models.User) error {
+				user.Deactivated = true
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("UpdateUser: %v", err)
+			}
+			return pair.RefreshToken
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestService(t)
+			token := tt.setup(t, s, store, start(t, s))
+			if _, _, err := s.Refresh(context.Background(), token); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("Refresh error = %v, want ErrInvalidRefreshToken", err)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	pair, other := start(t, s), start(t, s)
+
+	claims, err := s.Verify(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := s.Logout(ctx, claims.SessionID); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := s.Verify(ctx, pair.AccessToken); !errors.Is(err, tokens.ErrInvalidToken) {
+		t.Errorf("Verify after logout: error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Verify(ctx, other.AccessToken); err != nil {
+		t.Errorf("Verify of another session after logout: %v", err)
+	}
+	if err := s.Logout(ctx, ""); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Logout without a session: error = %v, want ErrNoSession", err)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	first, second := start(t, s), start(t, s)
+
+	ended, err := s.RevokeAll(ctx, officer)
+	if err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+	if ended != 2 {
+		t.Errorf("RevokeAll ended %d sessions, want 2", ended)
+	}
+	for _, pair := range []*Tokens{first, second} {
+		if _, err := s.Verify(ctx, pair.AccessToken); !errors.Is(err, tokens.ErrInvalidToken) {
+			t.Errorf("Verify after RevokeAll: error = %v, want ErrInvalidToken", err)
+		}
+		if _, _, err := s.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Refresh after RevokeAll: error = %v, want ErrInvalidRefreshToken", err)
+		}
+	}
+
+	// A login in the same second as the revocation is accepted at once
+	if _, err := s.Verify(ctx, start(t, s).AccessToken); err != nil {
+		t.Errorf("Verify of a login after RevokeAll: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package tokens issues and verifies the JWTs officers authenticate with.
+// Tokens are signed with one active key out of a set; every key in the set
+// verifies, so keys can be rotated without logging anyone out.
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// DefaultKeyID names the key configured through JWT_SECRET. Tokens without
+// a kid header, issued before key rotation, are verified with it.
+const DefaultKeyID = "default"
+
+// Key is one signing key. Keys loaded from only a public key verify tokens
+// but cannot sign them.
+type Key struct {
+	ID        string
+	Algorithm string
+	signing   interface{}
+	verifying interface{}
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// CanSign reports whether the key holds a secret or private key.
+func (k *Key) CanSign() bool {
+	return k.signing != nil
+}
+
+// KeySet is the keys tokens are verified with and the one new tokens are
+// signed with.
+type KeySet struct {
+	keys   map[string]*Key
+	active *Key
+}
+
+// keyFile is the layout of JWT_KEYS_FILE:
+//
+//	{
+//	  "active": "2025-06",
+//	  "keys": [
+//	    {"kid": "2025-06", "alg": "EdDSA", "private_key_file": "2025-06.pem"},
+//	    {"kid": "2025-01", "alg": "RS256", "public_key_file": "2025-01.pub.pem"},
+//	    {"kid": "legacy", "alg": "HS256", "secret": "..."}
+//	  ]
+//	}
+//
+// Key file paths are relative to the directory of the keys file. Keys are
+// PEM encoded, e.g. from openssl genpkey -algorithm ed25519.
+type keyFile struct {
+	Active string         `json:"active"`
+	Keys   []keyFileEntry `json:"keys"`
+}
+
+type keyFileEntry struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"`
+	Secret         string `json:"secret"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+}
+
+// LoadKeySet builds the key set from the keys file and the shared secret,
+// either of which may be empty. The secret becomes the DefaultKeyID HS256
+// key; it signs only when no keys file names an active key.
+func LoadKeySet(path, secret string) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key)}
+	if secret != "" {
+		key := &Key{ID: DefaultKeyID, Algorithm: AlgHS256, signing: []byte(secret), verifying: []byte(secret)}
+		set.keys[key.ID] = key
+		set.active = key
+	}
+	if path == "" {
+		return set, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys file: %v", err)
+	}
+	var file keyFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse keys file: %v", err)
+	}
+
+	dir := filepath.Dir(path)
+	for _, entry := range file.Keys {
+		key, err := loadKey(dir, entry)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", entry.ID, err)
+		}
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key %q", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+
+	if file.Active != "" {
+		active, ok := set.keys[file.Active]
+		if !ok {
+			return nil, fmt.Errorf("active key %q is not in the keys file", file.Active)
+		}
+		if !active.CanSign() {
+			return nil, fmt.Errorf("active key %q has no private key", file.Active)
+		}
+		set.active = active
+	}
+	return set, nil
+}
+
+func loadKey(dir string, entry keyFileEntry) (*Key, error) {
+	if entry.ID == "" {
+		return nil, fmt.Errorf("kid is required")
+	}
+	key := &Key{ID: entry.ID, Algorithm: entry.Algorithm}
+
+	readPEM := func(name string) ([]byte, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		return os.ReadFile(name)
+	}
+
+	switch entry.Algorithm {
+	case AlgHS256:
+		if entry.Secret == "" {
+			return nil, fmt.Errorf("HS256 keys need a secret")
+		}
+		key.signing, key.verifying = []byte(entry.Secret), []byte(entry.Secret)
+
+	case AlgRS256:
+		switch {
+		case entry.PrivateKeyFile != "":
+			raw, err := readPEM(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			key.signing, key.verifying = private, &private.PublicKey
+		case entry.PublicKeyFile != "":
+			raw, err := readPEM(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			key.verifying = public
+		default:
+			return nil, fmt.Errorf("RS256 keys need a private_key_file or public_key_file")
+		}
+
+	case AlgEdDSA:
+		switch {
+		case entry.PrivateKeyFile != "":
+			raw, err := readPEM(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := private.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("private key is not an Ed25519 key")
+			}
+			key.signing, key.verifying = signer, signer.Public()
+		case entry.PublicKeyFile != "":
+			raw, err := readPEM(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			key.verifying = public
+		default:
+			return nil, fmt.Errorf("EdDSA keys need a private_key_file or public_key_file")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported alg %q, expected %s, %s or %s", entry.Algorithm, AlgHS256, AlgRS256, AlgEdDSA)
+	}
+	return key, nil
+}
+
+// GenerateEphemeral adds a random Ed25519 key and makes it active. Tokens it
+// signs stop verifying when the process exits; it is meant for local
+// development without configured keys.
+func (s *KeySet) GenerateEphemeral() error {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	key := &Key{ID: "ephemeral", Algorithm: AlgEdDSA, signing: private, verifying: public}
+	s.keys[key.ID] = key
+	s.active = key
+	return nil
+}
+
+// Active is the key new tokens are signed with, or nil if none can sign.
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+// Lookup returns the key for a token's kid header. An empty kid selects
+// DefaultKeyID.
+func (s *KeySet) Lookup(kid string) (*Key, bool) {
+	if kid == "" {
+		kid = DefaultKeyID
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// publicKeys returns the asymmetric keys, which are the ones safe to publish.
+func (s *KeySet) publicKeys() []*Key {
+	var keys []*Key
+	for _, key := range s.keys {
+		switch key.verifying.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,128 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/jimil-28/crowd-monitor/internal/access"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+// ErrInvalidToken wraps every reason a token is rejected.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the JWT claims of an officer's token. Rank, department and
+// role drive permission checks.
+type Claims struct {
+	PhoneNumber string `json:"phone_number"`
+	Rank        string `json:"rank,omitempty"`
+	Department  string `json:"department,omitempty"`
+	Role        string `json:"role,omitempty"`
+	SessionID   string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func (c Claims) Valid() error {
+	if c.PhoneNumber == "" {
+		return errors.New("token missing required claims")
+	}
+	return c.RegisteredClaims.Valid()
+}
+
+func (c Claims) Principal() access.Principal {
+	return access.Principal{
+		PhoneNumber: c.PhoneNumber,
+		Rank:        c.Rank,
+		Department:  c.Department,
+		Role:        c.Role,
+	}
+}
+
+// Verifier checks a bearer token and returns its claims.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+type Service struct {
+	keys   *KeySet
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTokenService signs tokens valid for ttl with the active key of keys.
+func NewTokenService(keys *KeySet, issuer string, ttl time.Duration) (*Service, error) {
+	if keys.Active() == nil {
+		return nil, errors.New("no signing key configured")
+	}
+	return &Service{
+		keys:   keys,
+		issuer: issuer,
+		ttl:    ttl,
+		now:    time.Now,
+	}, nil
+}
+
+// TTL is how long issued access tokens stay valid.
+func (s *Service) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue signs an access token for user in the session with the active key
+// and returns it with its expiry.
+func (s *Service) Issue(user models.User, sessionID string) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(s.ttl)
+	claims := Claims{
+		PhoneNumber: user.PhoneNumber,
+		Rank:        user.Rank,
+		Department:  user.Department,
+		Role:        user.Role,
+		SessionID:   sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    s.issuer,
+			Subject:   user.PhoneNumber,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	key := s.keys.Active()
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.signing)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Verify checks the signature with the key named by the kid header, which
+// must use the algorithm the token claims, and then the expiry and issuer.
+// Tokens without an issuer predate it and are accepted.
+func (s *Service) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.verifying, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !claims.VerifyIssuer(s.issuer, false) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	return &claims, nil
+}
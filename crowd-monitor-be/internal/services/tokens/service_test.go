@@ -0,0 +1,217 @@
+package tokens
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+)
+
+const issuer = "crowd-monitor-test"
+
+// writePEM stores der as a PEM block of type in dir and returns its name.
+func writePEM(t *testing.T, dir, name, typ string, der []byte) string {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return name
+}
+
+// writeKeysFile stores file as keys.json in dir and returns its path.
+func writeKeysFile(t *testing.T, dir string, file keyFile) string {
+	t.Helper()
+	raw, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("marshal keys file: %v", err)
+	}
+	path := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+// rotatedKeys is a key set after a rotation from the RSA key "old", now
+// only held as a public key, to the Ed25519 key "new". oldKey still signs
+// so tests can forge tokens from before the rotation.
+func rotatedKeys(t *testing.T) (set *KeySet, oldKey *rsa.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	oldPublic, err := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: %v", err)
+	}
+	newPrivate, err := x509.MarshalPKCS8PrivateKey(newKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+
+	path := writeKeysFile(t, dir, keyFile{
+		Active: "new",
+		Keys: []keyFileEntry{
+			{ID: "new", Algorithm: AlgEdDSA, PrivateKeyFile: writePEM(t, dir, "new.pem", "PRIVATE KEY", newPrivate)},
+			{ID: "old", Algorithm: AlgRS256, PublicKeyFile: writePEM(t, dir, "old.pub.pem", "PUBLIC KEY", oldPublic)},
+		},
+	})
+	set, err = LoadKeySet(path, "legacy-secret")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	return set, oldKey
+}
+
+// sign builds a token with the given header fields and signs it.
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims Claims, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	set, oldKey := rotatedKeys(t)
+	s, err := NewTokenService(set, issuer, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenService: %v", err)
+	}
+	now := time.Now()
+	claims := func(iss string, expiresAt time.Time) Claims {
+		return Claims{PhoneNumber: "+919405061349", RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    iss,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		}}
+	}
+	valid := claims(issuer, now.Add(time.Minute))
+
+	issued, _, err := s.Issue(models.User{PhoneNumber: "+919405061349", Rank: "DYSP"}, "sid")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	oldPublic, _ := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	oldPublicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: oldPublic})
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"issued with the active key", issued, true},
+		{"signed before the rotation", sign(t, jwt.SigningMethodRS256, "old", valid, oldKey), true},
+		{"legacy token without a kid", sign(t, jwt.SigningMethodHS256, "", valid, []byte("legacy-secret")), true},
+		{"legacy token without an issuer", sign(t, jwt.SigningMethodHS256, "", claims("", now.Add(time.Minute)), []byte("legacy-secret")), true},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, "stolen", valid, oldKey), false},
+		{"HS256 under an RSA kid", sign(t, jwt.SigningMethodHS256, "old", valid, oldPublicPEM), false},
+		{"RS256 under the HS256 kid", sign(t, jwt.SigningMethodRS256, DefaultKeyID, valid, oldKey), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, DefaultKeyID, valid, jwt.UnsafeAllowNoneSignatureType), false},
+		{"wrong secret", sign(t, jwt.SigningMethodHS256, "", valid, []byte("guess")), false},
+		{"expired", sign(t, jwt.SigningMethodRS256, "old", claims(issuer, now.Add(-time.Minute)), oldKey), false},
+		{"other issuer", sign(t, jwt.SigningMethodRS256, "old", claims("someone-else", now.Add(time.Minute)), oldKey), false},
+		{"no phone number", sign(t, jwt.SigningMethodRS256, "old", Claims{RegisteredClaims: valid.RegisteredClaims}, oldKey), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Verify(context.Background(), tt.token)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if got.PhoneNumber != "+919405061349" {
+					t.Errorf("phone number = %q", got.PhoneNumber)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+
+	parsed, _, _ := jwt.NewParser().ParseUnverified(issued, &Claims{})
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != AlgEdDSA {
+		t.Errorf("issued token header = %v, want kid new signed with EdDSA", parsed.Header)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	set, _ := rotatedKeys(t)
+	s, err := NewTokenService(set, issuer, time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenService: %v", err)
+	}
+	jwks := s.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want the two asymmetric ones", len(jwks.Keys))
+	}
+	newKey, oldKey := jwks.Keys[0], jwks.Keys[1]
+	if newKey.KeyID != "new" || newKey.KeyType != "OKP" || newKey.Curve != "Ed25519" || newKey.X == "" {
+		t.Errorf("Ed25519 key = %+v", newKey)
+	}
+	if oldKey.KeyID != "old" || oldKey.KeyType != "RSA" || oldKey.E != "AQAB" || oldKey.N == "" {
+		t.Errorf("RSA key = %+v", oldKey)
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(public)
+	publicFile := writePEM(t, dir, "ed.pub.pem", "PUBLIC KEY", der)
+
+	tests := []struct {
+		name string
+		file keyFile
+	}{
+		{"active key missing", keyFile{Active: "a", Keys: []keyFileEntry{{ID: "b", Algorithm: AlgHS256, Secret: "s"}}}},
+		{"active key cannot sign", keyFile{Active: "a", Keys: []keyFileEntry{{ID: "a", Algorithm: AlgEdDSA, PublicKeyFile: publicFile}}}},
+		{"duplicate kid", keyFile{Keys: []keyFileEntry{{ID: "a", Algorithm: AlgHS256, Secret: "s"}, {ID: "a", Algorithm: AlgHS256, Secret: "t"}}}},
+		{"kid required", keyFile{Keys: []keyFileEntry{{Algorithm: AlgHS256, Secret: "s"}}}},
+		{"unsupported alg", keyFile{Keys: []keyFileEntry{{ID: "a", Algorithm: "ES256", Secret: "s"}}}},
+		{"HS256 without a secret", keyFile{Keys: []keyFileEntry{{ID: "a", Algorithm: AlgHS256}}}},
+		{"missing key file", keyFile{Keys: []keyFileEntry{{ID: "a", Algorithm: AlgRS256, PublicKeyFile: "missing.pem"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadKeySet(writeKeysFile(t, dir, tt.file), ""); err == nil {
+				t.Error("LoadKeySet succeeded, want an error")
+			}
+		})
+	}
+
+	set, err := LoadKeySet("", "")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	if _, err := NewTokenService(set, issuer, time.Minute); err == nil {
+		t.Error("NewTokenService without a signing key succeeded")
+	}
+}
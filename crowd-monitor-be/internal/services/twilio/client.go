@@ -1,6 +1,7 @@
 package twilio
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -8,6 +9,8 @@ import (
 	twilioApi "github.com/twilio/twilio-go/rest/verify/v2"
 )
 
+// Client sends and checks login codes through Twilio Verify. It implements
+// auth.OTPProvider.
 type Client struct {
 	twilioClient *twilio.RestClient
 	serviceSid   string
@@ -29,12 +32,21 @@ func NewTwilioClient(accountSid, authToken, serviceSid string) (*Client, error)
 	}, nil
 }
 
-func (c *Client) SendOTP(phoneNumber string) error {
+// Ping fetches the Verify service, which checks that Twilio is reachable
+// and the credentials and service SID are valid.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.twilioClient.VerifyV2.FetchService(c.serviceSid)
+	return err
+}
+
+func (c *Client) SendOTP(ctx context.Context, phoneNumber string) error {
 	params := &twilioApi.CreateVerificationParams{}
 	params.SetTo(phoneNumber)
 	params.SetChannel("sms")
 
+	done := observe(ctx, "verify_send", "verify.twilio.com", phoneNumber)
 	_, err := c.twilioClient.VerifyV2.CreateVerification(c.serviceSid, params)
+	done(err)
 	if err != nil {
 		return fmt.Errorf("failed to send OTP: %v", err)
 	}
@@ -42,12 +54,14 @@ func (c *Client) SendOTP(phoneNumber string) error {
 	return nil
 }
 
-func (c *Client) VerifyOTP(phoneNumber, code string) (bool, error) {
+func (c *Client) VerifyOTP(ctx context.Context, phoneNumber, code string) (bool, error) {
 	params := &twilioApi.CreateVerificationCheckParams{}
 	params.SetTo(phoneNumber)
 	params.SetCode(code)
 
+	done := observe(ctx, "verify_check", "verify.twilio.com", phoneNumber)
 	resp, err := c.twilioClient.VerifyV2.CreateVerificationCheck(c.serviceSid, params)
+	done(err)
 	if err != nil {
 		return false, fmt.Errorf("failed to verify OTP: %v", err)
 	}
@@ -0,0 +1,46 @@
+package twilio
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/logging"
+	"github.com/jimil-28/crowd-monitor/internal/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	requestDuration = metrics.NewHistogramVec("crowd_monitor_twilio_request_duration_seconds",
+		"Twilio API calls by operation (verify_send, verify_check, sms) and outcome (success or error).",
+		metrics.DefBuckets, "operation", "outcome")
+
+	tracer = otel.Tracer("github.com/jimil-28/crowd-monitor/internal/services/twilio")
+)
+
+// observe times a Twilio API call to host in a client span until the
+// returned func is called with its error. The Twilio SDK takes no context,
+// so the call itself cannot be cancelled or carry the trace.
+func observe(ctx context.Context, operation, host, to string) func(error) {
+	start := time.Now()
+	_, span := tracer.Start(ctx, "twilio."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.ServerAddress(host),
+			attribute.String("twilio.to", logging.MaskPhone(to)),
+		),
+	)
+	return func(err error) {
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+			span.RecordError(err)
+			span.SetStatus(codes.Error, logging.Mask(err.Error()))
+		}
+		requestDuration.Observe(time.Since(start).Seconds(), operation, outcome)
+		span.End()
+	}
+}
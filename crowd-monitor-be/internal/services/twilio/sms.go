@@ -0,0 +1,63 @@
+package twilio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/twilio/twilio-go"
+	twilioMessaging "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+// SMSSender sends plain text messages through the Twilio Messaging API.
+type SMSSender struct {
+	twilioClient *twilio.RestClient
+	accountSid   string
+	from         string
+}
+
+// NewSMSSender sends from a phone number, or through a messaging service
+// when from is a messaging service SID (MG...).
+func NewSMSSender(accountSid, authToken, from string) (*SMSSender, error) {
+	if accountSid == "" || authToken == "" || from == "" {
+		return nil, errors.New("missing Twilio SMS credentials")
+	}
+
+	client := twilio.NewRestClientWithParams(twilio.ClientParams{
+		Username: accountSid,
+		Password: authToken,
+	})
+
+	return &SMSSender{
+		twilioClient: client,
+		accountSid:   accountSid,
+		from:         from,
+	}, nil
+}
+
+// Ping fetches the account, which checks that Twilio is reachable and the
+// credentials are valid.
+func (s *SMSSender) Ping(ctx context.Context) error {
+	_, err := s.twilioClient.Api.FetchAccount(s.accountSid)
+	return err
+}
+
+func (s *SMSSender) SendSMS(ctx context.Context, to, body string) error {
+	params := &twilioMessaging.CreateMessageParams{}
+	params.SetTo(to)
+	params.SetBody(body)
+	if strings.HasPrefix(s.from, "MG") {
+		params.SetMessagingServiceSid(s.from)
+	} else {
+		params.SetFrom(s.from)
+	}
+
+	done := observe(ctx, "sms", "api.twilio.com", to)
+	_, err := s.twilioClient.Api.CreateMessage(params)
+	done(err)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Package zones manages named geofenced places, assigns analyses to them
+// and summarizes their current crowd status.
+package zones
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimil-28/crowd-monitor/internal/geo"
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+// indexRefresh bounds how long zone changes made by another server process
+// take to reach this one's lookup index.
+const indexRefresh = time.Minute
+
+// ErrInvalidZone is returned when a zone's fields fail validation.
+var ErrInvalidZone = errors.New("invalid zone")
+
+// Input is the editable part of a zone.
+type Input struct {
+	Name            string
+	Department      string
+	Geometry        json.RawMessage // GeoJSON Polygon, MultiPolygon or Feature
+	WarningCapacity int
+	SafeCapacity    int
+}
+
+type indexedZone struct {
+	id         string
+	department string
+	shape      geo.MultiPolygon
+	box        geo.BoundingBox
+}
+
+type Service struct {
+	repo     repository.ZoneRepository
+	analyses repository.VideoAnalysisRepository
+	window   time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	index    []indexedZone
+	loadedAt time.Time
+}
+
+// NewZoneService summarizes zone status over the analyses received in the
+// last window.
+func NewZoneService(repo repository.ZoneRepository, analyses repository.VideoAnalysisRepository, window time.Duration) *Service {
+	return &Service{
+		repo:     repo,
+		analyses: analyses,
+		window:   window,
+		now:      time.Now,
+	}
+}
+
+// build validates input and fills in the zone's geometry, area and
+// thresholds.
+func build(zone *models.Zone, input Input) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidZone)
+	}
+	shape, err := geo.ParseGeometry(input.Geometry)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidZone, err)
+	}
+	if input.WarningCapacity < 0 || input.SafeCapacity < 0 {
+		return fmt.Errorf("%w: capacities must not be negative", ErrInvalidZone)
+	}
+	if input.WarningCapacity > 0 && input.SafeCapacity > 0 && input.WarningCapacity > input.SafeCapacity {
+		return fmt.Errorf("%w: warning_capacity must not exceed safe_capacity", ErrInvalidZone)
+	}
+
+	zone.Name = input.Name
+	zone.Department = input.Department
+	zone.Geometry = shape.GeoJSON()
+	zone.AreaSquareMeters = shape.AreaSquareMeters()
+	zone.WarningCapacity = input.WarningCapacity
+	zone.SafeCapacity = input.SafeCapacity
+	return nil
+}
+
+// Create stores a new zone, generating an ID when id is empty.
+func (s *Service) Create(ctx context.Context, id string, input Input) (*models.Zone, error) {
+	if id == "" {
+		id = uuid.NewString()
+	} else if strings.Contains(id, "/") {
+		return nil, fmt.Errorf("%w: id must not contain '/'", ErrInvalidZone)
+	}
+	now := s.now().UTC()
+	zone := models.Zone{ID: id, CreatedAt: now, UpdatedAt: now}
+	if err := build(&zone, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return &zone, nil
+}
+
+func (s *Service) Update(ctx context.Context, id string, input Input) (*models.Zone, error) {
+	zone, err := s.repo.GetZone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := build(zone, input); err != nil {
+		return nil, err
+	}
+	zone.UpdatedAt = s.now().UTC()
+
+	if err := s.repo.UpdateZone(ctx, *zone); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return zone, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	if err := s.repo.DeleteZone(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Time{}
+}
+
+// Locate returns the IDs of the zones containing the point and the
+// department of the first of them that has one. Zones are matched against an
+// in-memory index of every department's zones, reloaded at most every
+// indexRefresh.
+func (s *Service) Locate(ctx context.Context, lat, lon float64) ([]string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.now().Sub(s.loadedAt) > indexRefresh {
+		zones, err := s.repo.ListZones(repository.Unscoped(ctx))
+		if err != nil {
+			return nil, "", err
+		}
+		index := make([]indexedZone, 0, len(zones))
+		for _, zone := range zones {
+			shape, err := zone.Shape()
+			if err != nil {
+				slog.WarnContext(ctx, "Skipping zone with invalid geometry", "zone_id", zone.ID, "error", err)
+				continue
+			}
+			index = append(index, indexedZone{id: zone.ID, department: zone.Department, shape: shape, box: shape.BoundingBox()})
+		}
+		s.index, s.loadedAt = index, s.now()
+	}
+
+	var ids []string
+	var department string
+	for _, z := range s.index {
+		if z.box.Contains(lat, lon) && z.shape.Contains(lat, lon) {
+			ids = append(ids, z.id)
+			if department == "" {
+				department = z.department
+			}
+		}
+	}
+	return ids, department, nil
+}
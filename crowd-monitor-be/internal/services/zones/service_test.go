@@ -0,0 +1,101 @@
+package zones
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+// square is a GeoJSON polygon of side 0.01 degrees with its south-west
+// corner at lat, lon.
+func square(lat, lon float64) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"type":"Polygon","coordinates":[[[%[2]v,%[1]v],[%[4]v,%[1]v],[%[4]v,%[3]v],[%[2]v,%[3]v],[%[2]v,%[1]v]]]}`,
+		lat, lon, lat+0.01, lon+0.01))
+}
+
+func TestCreateValidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		input   Input
+		wantErr bool
+	}{
+		{"valid", "", Input{Name: "Market", Geometry: square(15.59, 73.81), WarningCapacity: 100, SafeCapacity: 200}, false},
+		{"name required", "", Input{Name: " ", Geometry: square(15.59, 73.81)}, true},
+		{"geometry required", "", Input{Name: "Market"}, true},
+		{"negative capacity", "", Input{Name: "Market", Geometry: square(15.59, 73.81), SafeCapacity: -1}, true},
+		{"warning above safe", "", Input{Name: "Market", Geometry: square(15.59, 73.81), WarningCapacity: 300, SafeCapacity: 200}, true},
+		{"slash in the id", "a/b", Input{Name: "Market", Geometry: square(15.59, 73.81)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewMemoryClient()
+			zone, err := NewZoneService(store, store, 0).Create(context.Background(), tt.id, tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidZone) {
+					t.Errorf("Create error = %v, want ErrInvalidZone", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if zone.ID == "" || zone.AreaSquareMeters <= 0 {
+				t.Errorf("zone = %+v, want a generated ID and an area", zone)
+			}
+		})
+	}
+}
+
+func TestLocate(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryClient()
+	s := NewZoneService(store, store, 0)
+	zones := []struct {
+		id         string
+		department string
+		lat, lon   float64
+	}{
+		{"market", "", 15.59, 73.81},
+		{"church", "Mapusa Police Department", 15.595, 73.815},
+	}
+	for _, z := range zones {
+		if _, err := s.Create(ctx, z.id, Input{Name: z.id, Department: z.department, Geometry: square(z.lat, z.lon)}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		lat, lon       float64
+		want           string
+		wantDepartment string
+	}{
+		{"outside every zone", 15.5, 73.7, "[]", ""},
+		{"one zone", 15.591, 73.811, "[market]", ""},
+		{"overlap", 15.598, 73.818, "[church market]", "Mapusa Police Department"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, department, err := s.Locate(ctx, tt.lat, tt.lon)
+			if err != nil {
+				t.Fatalf("Locate: %v", err)
+			}
+			if got := fmt.Sprint(ids); got != tt.want || department != tt.wantDepartment {
+				t.Errorf("Locate = %s in %q, want %s in %q", got, department, tt.want, tt.wantDepartment)
+			}
+		})
+	}
+
+	// Deleting a zone drops it from the index straight away
+	if err := s.Delete(ctx, "market"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if ids, _, _ := s.Locate(ctx, 15.591, 73.811); len(ids) != 0 {
+		t.Errorf("Locate after delete = %v, want none", ids)
+	}
+}
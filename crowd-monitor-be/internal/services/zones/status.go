@@ -0,0 +1,141 @@
+package zones
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+)
+
+// Status is a zone's current crowd picture, built from the latest analysis
+// of each source (camera, or the analysis itself when it has no camera)
+// received within the service window.
+type Status struct {
+	ZoneID          string `json:"zone_id"`
+	Name            string `json:"name"`
+	Department      string `json:"department"`
+	Level           string `json:"level"`
+	WarningCapacity int    `json:"warning_capacity"`
+	SafeCapacity    int    `json:"safe_capacity"`
+	// EstimatedCount sums the latest count from each source.
+	EstimatedCount float64 `json:"estimated_count"`
+	DensityPerSqM  float64 `json:"density_per_m2"`
+	// OccupancyPercent is EstimatedCount as a share of SafeCapacity.
+	OccupancyPercent     float64    `json:"occupancy_percent,omitempty"`
+	CrowdLevel           string     `json:"crowd_level,omitempty"`
+	InterventionRequired bool       `json:"police_intervention_required"`
+	Sources              int        `json:"sources"`
+	Analyses             int        `json:"analyses"`
+	LastAnalysisAt       *time.Time `json:"last_analysis_at,omitempty"`
+	WindowSeconds        int        `json:"window_seconds"`
+}
+
+// Status summarizes one zone. Every page of the window is read: a source
+// whose latest analysis sits on a later page would otherwise drop out of
+// the count.
+func (s *Service) Status(ctx context.Context, zone models.Zone) (*Status, error) {
+	query := repository.VideoAnalysisQuery{
+		Filter: repository.VideoAnalysisFilter{
+			TimeField: "created_at",
+			From:      s.now().Add(-s.window),
+			ZoneID:    zone.ID,
+		},
+		PageSize: repository.MaxPageSize,
+	}
+	var analyses []models.VideoAnalysis
+	for {
+		page, err := s.analyses.ListVideoAnalyses(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	return summarize(zone, analyses, s.window), nil
+}
+
+// Statuses summarizes every zone, optionally only those of department.
+func (s *Service) Statuses(ctx context.Context, department string) ([]Status, error) {
+	zones, err := s.repo.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, zone := range zones {
+		if department != "" && zone.Department != department {
+			continue
+		}
+		status, err := s.Status(ctx, zone)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// summarize expects analyses newest first.
+func summarize(zone models.Zone, analyses []models.VideoAnalysis, window time.Duration) *Status {
+	status := &Status{
+		ZoneID:          zone.ID,
+		Name:            zone.Name,
+		Department:      zone.Department,
+		Level:           models.ZoneLevelUnknown,
+		WarningCapacity: zone.WarningCapacity,
+		SafeCapacity:    zone.SafeCapacity,
+		Analyses:        len(analyses),
+		WindowSeconds:   int(window.Seconds()),
+	}
+	if len(analyses) == 0 {
+		return status
+	}
+	latest := analyses[0].CreatedAt
+	status.LastAnalysisAt = &latest
+	status.CrowdLevel = analyses[0].Analysis.CrowdLevel
+
+	seen := map[string]bool{}
+	counted := false
+	for _, a := range analyses {
+		source := a.CameraID
+		if source == "" {
+			source = "video:" + a.VideoID
+		}
+		if seen[source] {
+			continue
+		}
+		seen[source] = true
+
+		if a.Analysis.InterventionRequired() {
+			status.InterventionRequired = true
+		}
+		if count, ok := a.Analysis.Count(); ok {
+			status.EstimatedCount += count
+			counted = true
+		}
+	}
+	status.Sources = len(seen)
+	if !counted {
+		return status
+	}
+
+	if zone.AreaSquareMeters > 0 {
+		status.DensityPerSqM = status.EstimatedCount / zone.AreaSquareMeters
+	}
+	if zone.SafeCapacity > 0 {
+		status.OccupancyPercent = 100 * status.EstimatedCount / float64(zone.SafeCapacity)
+	}
+	switch {
+	case zone.SafeCapacity > 0 && status.EstimatedCount > float64(zone.SafeCapacity):
+		status.Level = models.ZoneLevelCritical
+	case zone.WarningCapacity > 0 && status.EstimatedCount >= float64(zone.WarningCapacity):
+		status.Level = models.ZoneLevelWarning
+	default:
+		status.Level = models.ZoneLevelNormal
+	}
+	return status
+}
@@ -0,0 +1,111 @@
+package zones
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/models"
+	"github.com/jimil-28/crowd-monitor/internal/repository"
+	"github.com/jimil-28/crowd-monitor/internal/services/memory"
+)
+
+func TestSummarize(t *testing.T) {
+	zone := models.Zone{ID: "z1", AreaSquareMeters: 1000, WarningCapacity: 100, SafeCapacity: 200}
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	analysis := func(videoID, cameraID, count string) models.VideoAnalysis {
+		return models.VideoAnalysis{VideoID: videoID, CameraID: cameraID, CreatedAt: at, Analysis: models.Analysis{CrowdCount: count}}
+	}
+
+	tests := []struct {
+		name      string
+		zone      models.Zone
+		analyses  []models.VideoAnalysis
+		wantLevel string
+		wantCount float64
+		sources   int
+	}{
+		{"no analyses", zone, nil, models.ZoneLevelUnknown, 0, 0},
+		{"no counts", zone, []models.VideoAnalysis{analysis("v1", "cam1", "many")}, models.ZoneLevelUnknown, 0, 1},
+		{"normal", zone, []models.VideoAnalysis{analysis("v1", "cam1", "99")}, models.ZoneLevelNormal, 99, 1},
+		{"warning at the threshold", zone, []models.VideoAnalysis{analysis("v1", "cam1", "100")}, models.ZoneLevelWarning, 100, 1},
+		{"critical over safe capacity", zone, []models.VideoAnalysis{analysis("v1", "cam1", "201")}, models.ZoneLevelCritical, 201, 1},
+		{"latest analysis per camera", zone, []models.VideoAnalysis{
+			analysis("v3", "cam1", "50"),
+			analysis("v2", "cam2", "60"),
+			analysis("v1", "cam1", "500"),
+		}, models.ZoneLevelWarning, 110, 2},
+		{"analyses without a camera each count", zone, []models.VideoAnalysis{
+			analysis("v2", "", "70"),
+			analysis("v1", "", "70"),
+		}, models.ZoneLevelWarning, 140, 2},
+		{"no thresholds", models.Zone{ID: "z2"}, []models.VideoAnalysis{analysis("v1", "cam1", "5000")}, models.ZoneLevelNormal, 5000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := summarize(tt.zone, tt.analyses, time.Hour)
+			if status.Level != tt.wantLevel || status.EstimatedCount != tt.wantCount || status.Sources != tt.sources {
+				t.Errorf("level %s, count %v, sources %d, want %s, %v, %d",
+					status.Level, status.EstimatedCount, status.Sources, tt.wantLevel, tt.wantCount, tt.sources)
+			}
+			if status.Analyses != len(tt.analyses) || status.WindowSeconds != 3600 {
+				t.Errorf("analyses %d over %ds, want %d over 3600s", status.Analyses, status.WindowSeconds, len(tt.analyses))
+			}
+		})
+	}
+
+	status := summarize(zone, []models.VideoAnalysis{analysis("v1", "cam1", "150")}, time.Hour)
+	if status.DensityPerSqM != 0.15 || status.OccupancyPercent != 75 {
+		t.Errorf("density %v, occupancy %v, want 0.15 and 75", status.DensityPerSqM, status.OccupancyPercent)
+	}
+}
+
+func TestStatusReadsEveryPage(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryClient()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	zone := models.Zone{ID: "z1", WarningCapacity: 1000}
+
+	// One analysis from each of more cameras than fit on a page, plus one
+	// from before the window
+	cameras := repository.MaxPageSize + 50
+	for i := 0; i < cameras; i++ {
+		err := store.CreateVideoAnalysis(ctx, models.VideoAnalysis{
+			VideoID:   fmt.Sprintf("v%d", i),
+			CameraID:  fmt.Sprintf("cam%d", i),
+			CreatedAt: now.Add(-time.Duration(i) * time.Second),
+			Analysis:  models.Analysis{CrowdCount: "4"},
+			ZoneIDs:   []string{"z1"},
+		})
+		if err != nil {
+			t.Fatalf("CreateVideoAnalysis: %v", err)
+		}
+	}
+	err := store.CreateVideoAnalysis(ctx, models.VideoAnalysis{
+		VideoID:   "old",
+		CameraID:  "cam-old",
+		CreatedAt: now.Add(-2 * time.Hour),
+		Analysis:  models.Analysis{CrowdCount: "1000"},
+		ZoneIDs:   []string{"z1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateVideoAnalysis: %v", err)
+	}
+
+	s := NewZoneService(store, store, time.Hour)
+	s.now = func() time.Time { return now }
+	status, err := s.Status(ctx, zone)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Sources != cameras || status.EstimatedCount != float64(4*cameras) {
+		t.Errorf("%d sources counting %v, want %d counting %d", status.Sources, status.EstimatedCount, cameras, 4*cameras)
+	}
+	if status.Level != models.ZoneLevelWarning {
+		t.Errorf("level = %s, want warning", status.Level)
+	}
+	if status.LastAnalysisAt == nil || !status.LastAnalysisAt.Equal(now) {
+		t.Errorf("last analysis at %v, want %v", status.LastAnalysisAt, now)
+	}
+}
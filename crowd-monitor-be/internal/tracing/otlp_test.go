@@ -0,0 +1,170 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"", map[string]string{}, false},
+		{"api-key=abc", map[string]string{"api-key": "abc"}, false},
+		{" a = 1 , b=x%3Dy,", map[string]string{"a": "1", "b": "x=y"}, false},
+		{"novalue", nil, true},
+		{"=value", nil, true},
+		{"a=%zz", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHeaders(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHeaders(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseHeaders(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for name, value := range tt.want {
+			if got[name] != value {
+				t.Errorf("ParseHeaders(%q)[%s] = %q, want %q", tt.in, name, got[name], value)
+			}
+		}
+	}
+}
+
+// export records a parent and a failed child span through exporter.
+func export(t *testing.T, exporter sdktrace.SpanExporter) (parent, child trace.SpanContext) {
+	t.Helper()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	tracer := provider.Tracer("crowd-monitor/test")
+	ctx, parentSpan := tracer.Start(context.Background(), "GET /api/v1/zones", trace.WithSpanKind(trace.SpanKindServer))
+	_, childSpan := tracer.Start(ctx, "firestore.ListZones", trace.WithAttributes(
+		attribute.String("db.system", "firestore"),
+		attribute.Int("zones", 3),
+		attribute.Bool("cached", false),
+		attribute.Float64("ratio", 0.5),
+		attribute.StringSlice("ids", []string{"a", "b"}),
+	))
+	childSpan.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", 2)))
+	childSpan.SetStatus(codes.Error, "deadline exceeded")
+	childSpan.End()
+	parentSpan.End()
+	return parentSpan.SpanContext(), childSpan.SpanContext()
+}
+
+func TestHTTPExporter(t *testing.T) {
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	// Spans are exported one at a time by the syncer; keep every body
+	var bodies []string
+	exporter := NewHTTPExporter(server.URL, map[string]string{"api-key": "abc"})
+	parent, child := export(t, exporterFunc(func(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+		err := exporter.ExportSpans(ctx, spans)
+		bodies = append(bodies, string(body))
+		return err
+	}))
+
+	if header.Get("api-key") != "abc" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("request headers = %v", header)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("exported %d batches, want 2", len(bodies))
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal([]byte(bodies[0]), &req); err != nil {
+		t.Fatalf("decode %s: %v", bodies[0], err)
+	}
+	span := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if req.ResourceSpans[0].ScopeSpans[0].Scope.Name != "crowd-monitor/test" {
+		t.Errorf("scope = %+v", req.ResourceSpans[0].ScopeSpans[0].Scope)
+	}
+	if span.TraceID != child.TraceID().String() || span.SpanID != child.SpanID().String() || span.ParentSpanID != parent.SpanID().String() {
+		t.Errorf("span IDs = %s/%s parent %s", span.TraceID, span.SpanID, span.ParentSpanID)
+	}
+	if span.Kind != int(trace.SpanKindInternal) || span.Status.Code != 2 || span.Status.Message != "deadline exceeded" {
+		t.Errorf("kind %d, status %+v, want internal and error", span.Kind, span.Status)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "retry" {
+		t.Errorf("events = %+v", span.Events)
+	}
+	for _, want := range []string{
+		`{"key":"db.system","value":{"stringValue":"firestore"}}`,
+		`{"key":"zones","value":{"intValue":"3"}}`,
+		`{"key":"cached","value":{"boolValue":false}}`,
+		`{"key":"ratio","value":{"doubleValue":0.5}}`,
+		`{"key":"ids","value":{"arrayValue":{"values":[{"stringValue":"a"},{"stringValue":"b"}]}}}`,
+	} {
+		if !strings.Contains(bodies[0], want) {
+			t.Errorf("export lacks %s", want)
+		}
+	}
+	if !strings.Contains(bodies[1], `"kind":2`) || strings.Contains(bodies[1], "parentSpanId") {
+		t.Errorf("server span = %s, want kind 2 without a parent", bodies[1])
+	}
+}
+
+func TestHTTPExporterError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var exportErr error
+	exporter := NewHTTPExporter(server.URL, nil)
+	export(t, exporterFunc(func(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+		exportErr = exporter.ExportSpans(ctx, spans)
+		return nil
+	}))
+	if exportErr == nil || !strings.Contains(exportErr.Error(), "429") || !strings.Contains(exportErr.Error(), "quota exceeded") {
+		t.Errorf("ExportSpans error = %v, want the status and body", exportErr)
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	var out strings.Builder
+	exporter := NewWriterExporter(nopCloser{&out})
+	export(t, exporter)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrote %d lines, want one per batch:\n%s", len(lines), out.String())
+	}
+	for _, line := range lines {
+		var req otlpRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil || len(req.ResourceSpans) != 1 {
+			t.Errorf("line %s: %v", line, err)
+		}
+	}
+}
+
+// exporterFunc adapts a function to sdktrace.SpanExporter.
+type exporterFunc func(ctx context.Context, spans []sdktrace.ReadOnlySpan) error
+
+func (f exporterFunc) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return f(ctx, spans)
+}
+
+func (f exporterFunc) Shutdown(ctx context.Context) error { return nil }
@@ -0,0 +1,139 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started by the
+// Gin middleware and by the services and repositories they call through the
+// request context, and are exported as OTLP JSON to a collector, stdout or a
+// file.
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jimil-28/crowd-monitor/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporters selectable through TRACING_EXPORTERS.
+const (
+	ExporterOTLP   = "otlp"   // OTLP/HTTP JSON to Options.Endpoint
+	ExporterStdout = "stdout" // one OTLP JSON line per batch on stdout
+	ExporterFile   = "file"   // the same lines in Options.File, rotated like the log
+)
+
+// Options configure Setup.
+type Options struct {
+	Exporters   []string
+	ServiceName string
+	// Endpoint is the full OTLP/HTTP traces URL; Headers are sent with
+	// every export.
+	Endpoint string
+	Headers  map[string]string
+	// File is rotated once it reaches FileMaxSize bytes or FileMaxAge and
+	// rotated files are removed after FileRetention.
+	File          string
+	FileMaxSize   int64
+	FileMaxAge    time.Duration
+	FileRetention time.Duration
+	// SampleRatio is the share of new traces recorded; requests that carry
+	// a sampled traceparent are always recorded.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and W3C trace context
+// propagation. Without exporters spans are not recorded. The returned
+// func flushes pending spans and closes the exporters.
+func Setup(opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	if len(opts.Exporters) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	}
+	for _, name := range opts.Exporters {
+		var exporter sdktrace.SpanExporter
+		switch name {
+		case ExporterOTLP:
+			if opts.Endpoint == "" {
+				return nil, errors.New("the otlp trace exporter needs an endpoint")
+			}
+			exporter = NewHTTPExporter(opts.Endpoint, opts.Headers)
+		case ExporterStdout:
+			exporter = NewWriterExporter(nopCloser{os.Stdout})
+		case ExporterFile:
+			if opts.File == "" {
+				return nil, errors.New("the file trace exporter needs a file")
+			}
+			file, err := logging.OpenRotatingFile(opts.File, opts.FileMaxSize, opts.FileMaxAge, opts.FileRetention)
+			if err != nil {
+				return nil, err
+			}
+			exporter = NewWriterExporter(file)
+		default:
+			return nil, fmt.Errorf("unknown trace exporter %q, expected %s, %s or %s", name, ExporterOTLP, ExporterStdout, ExporterFile)
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("Tracing error", "error", err)
+	}))
+	return provider.Shutdown, nil
+}
+
+// WriterExporter writes each batch of spans as one line of OTLP JSON.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+func NewWriterExporter(w io.WriteCloser) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+func (e *WriterExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	line, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(line, '\n'))
+	return err
+}
+
+func (e *WriterExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.w.Close()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
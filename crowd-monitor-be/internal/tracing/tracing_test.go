@@ -0,0 +1,21 @@
+package tracing
+
+import "testing"
+
+func TestSetupRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unknown exporter", Options{Exporters: []string{"jaeger"}}},
+		{"otlp without an endpoint", Options{Exporters: []string{ExporterOTLP}}},
+		{"file without a path", Options{Exporters: []string{ExporterFile}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Setup(tt.opts); err == nil {
+				t.Error("Setup succeeded, want an error")
+			}
+		})
+	}
+}